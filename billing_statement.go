@@ -1,6 +1,9 @@
 package payrex
 
-import "iter"
+import (
+	"iter"
+	"net/url"
+)
 
 // TODO: update BillingStatement fields as the BillingStatement official API docs become more accurate
 
@@ -21,16 +24,18 @@ type BillingStatement struct {
 		UnitPrice          int    `json:"unit_price"`
 		Quantity           int    `json:"quantity"`
 	} `json:"line_items"`
-	PaymentIntent            *PaymentIntent     `json:"payment_intent"`
-	BillingDetailsCollection string             `json:"billing_details_collection"`
-	CustomerID               string             `json:"customer_id"`
-	Description              *string            `json:"description"`
-	MerchantName             *string            `json:"billing_statement_merchant_name"`
-	MerchantNumber           *string            `json:"billing_statement_merchant_number"`
-	URL                      *string            `json:"billing_statement_url"`
-	StatementDescriptor      *string            `json:"statement_descriptor"`
-	PaymentSettings          PaymentSettings    `json:"payment_settings"`
-	Metadata                 *map[string]string `json:"metadata"`
+	PaymentIntent            *PaymentIntent  `json:"payment_intent"`
+	BillingDetailsCollection string          `json:"billing_details_collection"`
+	CustomerID               string          `json:"customer_id"`
+	Description              *string         `json:"description"`
+	MerchantName             *string         `json:"billing_statement_merchant_name"`
+	MerchantNumber           *string         `json:"billing_statement_merchant_number"`
+	URL                      *string         `json:"billing_statement_url"`
+	StatementDescriptor      *string         `json:"statement_descriptor"`
+	PaymentSettings          PaymentSettings `json:"payment_settings"`
+	Metadata                 Metadata        `json:"metadata"`
+
+	Extra ExtraFields `json:"-"`
 }
 
 // PaymentSettings lists fields that can modify the behavior of the payment processing for a [BillingStatement].
@@ -145,30 +150,36 @@ func (s *ServiceBillingStatements) Void(id string) (*BillingStatement, error) {
 //
 // API reference: https://docs.payrexhq.com/docs/api/billing_statements/create
 type BillingStatementCreateParams struct {
-	CustomerID               string             `form:"customer_id"`
-	Currency                 Currency           `form:"currency"`
-	Description              *string            `form:"description"`
-	BillingDetailsCollection *string            `form:"billing_details_collection"`
-	PaymentSettings          PaymentSettings    `form:"payment_settings"`
-	Metadata                 *map[string]string `form:"metadata"`
+	CustomerID               string          `form:"customer_id"`
+	Currency                 Currency        `form:"currency"`
+	Description              *string         `form:"description"`
+	BillingDetailsCollection *string         `form:"billing_details_collection"`
+	PaymentSettings          PaymentSettings `form:"payment_settings"`
+	Metadata                 Metadata        `form:"metadata"`
+
+	Extra url.Values `form:",extra"`
 }
 
 // BillingStatementUpdateParams represents the available [ServiceBillingStatements.Update] parameters.
 //
 // API reference: https://docs.payrexhq.com/docs/api/billing_statements/update
 type BillingStatementUpdateParams struct {
-	CustomerID               *string            `form:"customer_id"`
-	Description              *string            `form:"description"`
-	BillingDetailsCollection *string            `form:"billing_details_collection"`
-	PaymentSettings          *PaymentSettings   `form:"payment_settings"`
-	Metadata                 *map[string]string `form:"metadata"`
+	CustomerID               *string          `form:"customer_id"`
+	Description              *string          `form:"description"`
+	BillingDetailsCollection *string          `form:"billing_details_collection"`
+	PaymentSettings          *PaymentSettings `form:"payment_settings"`
+	Metadata                 Metadata         `form:"metadata"`
+
+	Extra url.Values `form:",extra"`
 }
 
 // BillingStatementListParams represents the available [ServiceBillingStatements.List] parameters.
 //
 // API reference: https://docs.payrexhq.com/docs/api/billing_statements/list
 type BillingStatementListParams struct {
-	Limit  *int    `form:"int"`
+	Limit  *int    `form:"limit"`
 	Before *string `form:"before"`
 	After  *string `form:"after"`
+
+	Extra url.Values `form:",extra"`
 }
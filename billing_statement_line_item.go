@@ -1,5 +1,7 @@
 package payrex
 
+import "net/url"
+
 // BillingStatementLineItem is a line item of a [BillingStatement] that pertains
 // to a business's products or services.
 //
@@ -13,6 +15,8 @@ type BillingStatementLineItem struct {
 	Description        string `json:"description"`
 	UnitPrice          int    `json:"unit_price"`
 	Quantity           int    `json:"quantity"`
+
+	Extra ExtraFields `json:"-"`
 }
 
 // ServiceBillingStatementLineItems is used to interact with [BillingStatementLineItem] resources,
@@ -62,6 +66,8 @@ type BillingStatementLineItemCreateParams struct {
 	Description        string `form:"description"`
 	UnitPrice          int    `form:"unit_price"`
 	Quantity           int    `form:"quantity"`
+
+	Extra url.Values `form:",extra"`
 }
 
 // BillingStatementLineItemUpdateParams represents the available [ServiceBillingStatementLineItems.Update] parameters.
@@ -71,4 +77,6 @@ type BillingStatementLineItemUpdateParams struct {
 	Description *string `form:"description"`
 	UnitPrice   *int    `form:"unit_price"`
 	Quantity    *int    `form:"quantity"`
+
+	Extra url.Values `form:",extra"`
 }
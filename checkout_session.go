@@ -1,6 +1,9 @@
 package payrex
 
-import "iter"
+import (
+	"iter"
+	"net/url"
+)
 
 // CheckoutSession is used to notify your application about events in your PayRex account.
 //
@@ -17,13 +20,15 @@ type CheckoutSession struct {
 	Currency                 Currency                  `json:"currency"`
 	LineItems                []CheckoutSessionLineItem `json:"line_items"`
 	PaymentIntent            *PaymentIntent            `json:"payment_intent"`
-	Metadata                 *map[string]string        `json:"metadata"`
+	Metadata                 Metadata                  `json:"metadata"`
 	SuccessURL               string                    `json:"success_url"`
 	CancelURL                string                    `json:"cancel_url"`
 	PaymentMethods           []PaymentMethod           `json:"payment_methods"`
 	Description              *string                   `json:"description"`
 	SubmitType               string                    `json:"submit_type"`
 	ExpiresAt                int                       `json:"expires_at"`
+
+	Extra ExtraFields `json:"-"`
 }
 
 // CheckoutSessionStatus enumerates the valid values for the [CheckoutSession].Status field.
@@ -99,7 +104,7 @@ type CheckoutSessionCreateParams struct {
 	CustomerReferenceID      *string                         `form:"customer_reference_id"`
 	Currency                 Currency                        `form:"currency"`
 	LineItems                []CheckoutSessionLineItemParams `form:"line_items"`
-	Metadata                 *map[string]string              `form:"metadata"`
+	Metadata                 Metadata                        `form:"metadata"`
 	SuccessURL               string                          `form:"success_url"`
 	CancelURL                string                          `form:"cancel_url"`
 	ExpiresAt                *int                            `form:"expires_at"`
@@ -108,6 +113,8 @@ type CheckoutSessionCreateParams struct {
 	Description              *string                         `form:"description"`
 	SubmitType               *string                         `form:"submit_type"`
 	PaymentMethodOptions     *PaymentMethodOptions           `form:"payment_method_options"`
+
+	Extra url.Values `form:",extra"`
 }
 
 type CheckoutSessionLineItemParams struct {
@@ -122,7 +129,9 @@ type CheckoutSessionLineItemParams struct {
 
 // ListCheckoutSessionsParams represents the available [ServiceCheckoutSessions.List] parameters.
 type ListCheckoutSessionsParams struct {
-	Limit  *int    `form:"int"`
+	Limit  *int    `form:"limit"`
 	Before *string `form:"before"`
 	After  *string `form:"after"`
+
+	Extra url.Values `form:",extra"`
 }
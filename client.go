@@ -2,7 +2,9 @@
 package payrex
 
 import (
+	"fmt"
 	"net/http"
+	"strings"
 )
 
 // Client is the PayRex client. It contains all the services
@@ -29,9 +31,11 @@ type Client struct {
 	// Webhooks is the service for invoking /webhooks APIs.
 	Webhooks ServiceWebhooks
 
-	apiBaseURL string
-	apiKey     string
-	httpClient *http.Client
+	apiBaseURL   string
+	apiKey       string
+	httpClient   *http.Client
+	requiredMode Mode
+	driftHandler func(Drift)
 }
 
 // NewClient creates a new [Client] instance.
@@ -54,3 +58,50 @@ func (c *Client) WithHTTPClient(httpClient *http.Client) *Client {
 	c.httpClient = httpClient
 	return c
 }
+
+// WithBaseURL replaces the base URL of the PayRex API used for making requests,
+// e.g. to point the client to a fake PayRex server in tests.
+func (c *Client) WithBaseURL(baseURL string) *Client {
+	c.apiBaseURL = strings.TrimSuffix(baseURL, "/")
+	return c
+}
+
+// Mode returns the mode of the API key of the client, detected from its prefix.
+//
+// Returns an empty string if the mode of the API key is not known.
+func (c *Client) Mode() Mode {
+	return ModeFromAPIKey(c.apiKey)
+}
+
+// WithRequiredMode makes the client refuse to make requests with
+// [ErrAPIKeyModeMismatch] if the mode of its API key is not the given mode.
+//
+// Useful for making sure a live API key is never used outside production,
+// and a test API key is never used in production:
+//
+//	payrexClient := payrex.NewClient(os.Getenv("PAYREX_API_KEY"))
+//	if os.Getenv("ENV") == "production" {
+//		payrexClient.WithRequiredMode(payrex.ModeLive)
+//	} else {
+//		payrexClient.WithRequiredMode(payrex.ModeTest)
+//	}
+func (c *Client) WithRequiredMode(mode Mode) *Client {
+	c.requiredMode = mode
+	return c
+}
+
+// checkMode returns an error if the mode of the API key is not the required mode.
+func (c *Client) checkMode() error {
+	if c.requiredMode == "" {
+		return nil
+	}
+
+	if mode := c.Mode(); mode != c.requiredMode {
+		if mode == "" {
+			mode = "unknown"
+		}
+		return fmt.Errorf("%w: API key is for %s mode, but %s mode is required", ErrAPIKeyModeMismatch, mode, c.requiredMode)
+	}
+
+	return nil
+}
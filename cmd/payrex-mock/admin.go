@@ -0,0 +1,176 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/angelofallars/payrex-go"
+	"github.com/angelofallars/payrex-go/payrextest"
+)
+
+// routeAdmin registers the admin API, used to inspect the mock and to simulate
+// what PayRex does on its own. Parameters are passed as form values.
+func (m *mock) routeAdmin(mux *http.ServeMux) {
+	mux.HandleFunc("GET /_admin/state", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, m.server.State())
+	})
+
+	mux.HandleFunc("GET /_admin/events", m.handleListEvents)
+	mux.HandleFunc("POST /_admin/events/{id}/resend", func(w http.ResponseWriter, r *http.Request) {
+		if err := m.server.ResendEvent(r.PathValue("id")); err != nil {
+			writeError(w, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	})
+	mux.HandleFunc("GET /_admin/webhook_deliveries", m.handleListWebhookDeliveries)
+
+	mux.HandleFunc("POST /_admin/payment_intents/{id}/attach_payment_method", m.paymentIntentAction(
+		func(id string, r *http.Request) (*payrex.PaymentIntent, error) {
+			return m.server.AttachPaymentMethod(id, payrex.PaymentMethod(r.FormValue("payment_method")))
+		}))
+	mux.HandleFunc("POST /_admin/payment_intents/{id}/process", m.paymentIntentAction(
+		func(id string, r *http.Request) (*payrex.PaymentIntent, error) {
+			return m.server.ProcessPaymentIntent(id)
+		}))
+	mux.HandleFunc("POST /_admin/payment_intents/{id}/succeed", m.paymentIntentAction(
+		func(id string, r *http.Request) (*payrex.PaymentIntent, error) {
+			return m.server.SucceedPaymentIntent(id)
+		}))
+	mux.HandleFunc("POST /_admin/payment_intents/{id}/fail", m.paymentIntentAction(
+		func(id string, r *http.Request) (*payrex.PaymentIntent, error) {
+			return m.server.FailPaymentIntent(id)
+		}))
+	mux.HandleFunc("POST /_admin/payment_intents/{id}/pay", m.paymentIntentAction(
+		func(id string, r *http.Request) (*payrex.PaymentIntent, error) {
+			return m.server.PayPaymentIntent(id, payrex.PaymentMethod(r.FormValue("payment_method")))
+		}))
+
+	mux.HandleFunc("POST /_admin/payouts", func(w http.ResponseWriter, r *http.Request) {
+		mode := payrex.ModeTest
+		if r.FormValue("mode") == string(payrex.ModeLive) {
+			mode = payrex.ModeLive
+		}
+
+		payout, err := m.server.CreatePayout(mode)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, payout)
+	})
+
+	mux.HandleFunc("POST /_admin/clock", m.handleSetClock)
+}
+
+// paymentIntentAction returns a handler that runs a simulated action on a payment intent.
+func (m *mock) paymentIntentAction(action func(id string, r *http.Request) (*payrex.PaymentIntent, error)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		paymentIntent, err := action(r.PathValue("id"), r)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, paymentIntent)
+	}
+}
+
+// handleSetClock freezes the clock of the mock at the 'time' in RFC 3339 format,
+// or moves it forward by the 'advance' duration, e.g. "24h".
+func (m *mock) handleSetClock(w http.ResponseWriter, r *http.Request) {
+	switch {
+	case r.FormValue("time") != "":
+		t, err := time.Parse(time.RFC3339, r.FormValue("time"))
+		if err != nil {
+			writeError(w, fmt.Errorf("invalid time: %w", err))
+			return
+		}
+		m.server.SetTime(t)
+	case r.FormValue("advance") != "":
+		d, err := time.ParseDuration(r.FormValue("advance"))
+		if err != nil {
+			writeError(w, fmt.Errorf("invalid advance duration: %w", err))
+			return
+		}
+		m.server.Advance(d)
+	default:
+		writeError(w, fmt.Errorf("either 'time' or 'advance' is required"))
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{"time": m.server.Now()})
+}
+
+// handleListEvents responds with the payloads of the events created so far, from oldest to newest.
+func (m *mock) handleListEvents(w http.ResponseWriter, r *http.Request) {
+	events := m.server.Events()
+
+	payloads := make([]json.RawMessage, 0, len(events))
+	for _, event := range events {
+		payload, err := event.Payload()
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		payloads = append(payloads, payload)
+	}
+
+	writeJSON(w, http.StatusOK, payloads)
+}
+
+// webhookDelivery is the JSON encoding of a [payrextest.WebhookDelivery].
+type webhookDelivery struct {
+	EventID    string           `json:"event_id"`
+	EventType  payrex.EventType `json:"event_type"`
+	WebhookID  string           `json:"webhook_id"`
+	URL        string           `json:"url"`
+	Attempt    int              `json:"attempt"`
+	StatusCode int              `json:"status_code,omitempty"`
+	Error      string           `json:"error,omitempty"`
+	Time       time.Time        `json:"time"`
+}
+
+func (m *mock) handleListWebhookDeliveries(w http.ResponseWriter, r *http.Request) {
+	deliveries := m.server.WebhookDeliveries()
+
+	response := make([]webhookDelivery, len(deliveries))
+	for i, delivery := range deliveries {
+		response[i] = newWebhookDelivery(delivery)
+	}
+
+	writeJSON(w, http.StatusOK, response)
+}
+
+func newWebhookDelivery(delivery *payrextest.WebhookDelivery) webhookDelivery {
+	d := webhookDelivery{
+		EventID:    delivery.Event.ID,
+		EventType:  delivery.Event.Type,
+		WebhookID:  delivery.WebhookID,
+		URL:        delivery.URL,
+		Attempt:    delivery.Attempt,
+		StatusCode: delivery.StatusCode,
+		Time:       delivery.Time,
+	}
+	if delivery.Err != nil {
+		d.Error = delivery.Err.Error()
+	}
+	return d
+}
+
+func writeError(w http.ResponseWriter, err error) {
+	statusCode := http.StatusBadRequest
+	if errors.Is(err, payrextest.ErrNotFound) {
+		statusCode = http.StatusNotFound
+	}
+
+	writeJSON(w, statusCode, map[string]string{"error": err.Error()})
+}
+
+func writeJSON(w http.ResponseWriter, statusCode int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	_ = json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,171 @@
+// Command payrex-mock serves a mock of the PayRex API for local development,
+// built on the fake server of the payrextest package.
+//
+// Resources and events are kept in a state file between restarts, and an admin API under
+// /_admin/ simulates payments, payouts and the passing of time. Events are
+// delivered to the webhooks created through the mock API, signed like PayRex does.
+//
+// Usage:
+//
+//	payrex-mock [-addr localhost:8090] [-state payrex-mock.json] [-seed fixture.json]
+//
+// The admin API is unauthenticated and exposes webhook secret keys, so the mock only
+// listens on localhost by default.
+//
+// Point a client at the mock with [payrex.Client.WithBaseURL]. Any API key is accepted,
+// and API keys starting with 'sk_live_' create live mode resources.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/angelofallars/payrex-go/internal/atomicfile"
+	"github.com/angelofallars/payrex-go/payrextest"
+)
+
+func main() {
+	addr := flag.String("addr", "localhost:8090", "address to listen on")
+	statePath := flag.String("state", "payrex-mock.json", "file to keep the state in between restarts, or empty to keep it in memory")
+	seedPath := flag.String("seed", "", "JSON fixture to load when there is no state file yet")
+	webhookAttempts := flag.Int("webhook-attempts", 3, "max attempts to deliver an event to a webhook")
+	webhookBackoff := flag.Duration("webhook-backoff", time.Second, "delay before retrying a webhook delivery, doubled for each retry")
+	flag.Parse()
+
+	if err := run(*addr, *statePath, *seedPath, *webhookAttempts, *webhookBackoff); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// run serves the mock until the process is interrupted.
+func run(addr, statePath, seedPath string, webhookAttempts int, webhookBackoff time.Duration) error {
+	server := payrextest.NewUnstartedServer().WithWebhookRetries(webhookAttempts, webhookBackoff)
+	defer server.Close()
+
+	loaded, err := loadState(server, statePath)
+	if err != nil {
+		return err
+	}
+	if !loaded && seedPath != "" {
+		if _, err := loadState(server, seedPath); err != nil {
+			return err
+		}
+		log.Printf("seeded from %s", seedPath)
+	}
+
+	mock := &mock{server: server, statePath: statePath}
+	httpServer := &http.Server{Addr: addr, Handler: mock.handler()}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	errs := make(chan error, 1)
+	go func() {
+		log.Printf("serving the PayRex mock API on %s", addr)
+		errs <- httpServer.ListenAndServe()
+	}()
+
+	select {
+	case err := <-errs:
+		return err
+	case <-ctx.Done():
+	}
+
+	log.Print("shutting down")
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	if err := httpServer.Shutdown(shutdownCtx); err != nil {
+		return fmt.Errorf("could not shut down: %w", err)
+	}
+	return mock.save()
+}
+
+// mock is the mock PayRex API, saving its state to a file after every change.
+type mock struct {
+	server    *payrextest.Server
+	statePath string
+
+	saveMu sync.Mutex
+}
+
+// handler returns the handler of the mock API and of the admin API.
+func (m *mock) handler() http.Handler {
+	mux := http.NewServeMux()
+	m.routeAdmin(mux)
+	mux.Handle("/", m.server)
+
+	return m.persist(mux)
+}
+
+// persist saves the state of the server after every request that may change it.
+func (m *mock) persist(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		log.Printf("%s %s", r.Method, r.URL.Path)
+
+		next.ServeHTTP(w, r)
+
+		if r.Method == http.MethodGet || r.Method == http.MethodHead {
+			return
+		}
+		if err := m.save(); err != nil {
+			log.Println(err)
+		}
+	})
+}
+
+// save atomically replaces the state file with the current state of the server.
+func (m *mock) save() error {
+	if m.statePath == "" {
+		return nil
+	}
+
+	m.saveMu.Lock()
+	defer m.saveMu.Unlock()
+
+	data, err := json.MarshalIndent(m.server.State(), "", "  ")
+	if err != nil {
+		return fmt.Errorf("could not encode state: %w", err)
+	}
+
+	if err := atomicfile.Write(m.statePath, data); err != nil {
+		return fmt.Errorf("could not save state: %w", err)
+	}
+
+	return nil
+}
+
+// loadState loads the state in the file into the server,
+// returning false if the path is empty or the file doesn't exist.
+func loadState(server *payrextest.Server, path string) (bool, error) {
+	if path == "" {
+		return false, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("could not read state file: %w", err)
+	}
+
+	var state payrextest.State
+	if err := json.Unmarshal(data, &state); err != nil {
+		return false, fmt.Errorf("could not decode state file '%s': %w", path, err)
+	}
+	server.LoadState(&state)
+
+	return true, nil
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"testing"
+
+	"github.com/angelofallars/payrex-go"
+	"github.com/angelofallars/payrex-go/payrextest"
+)
+
+func TestMock(t *testing.T) {
+	output := log.Writer()
+	log.SetOutput(io.Discard)
+	t.Cleanup(func() { log.SetOutput(output) })
+
+	statePath := filepath.Join(t.TempDir(), "payrex-mock.json")
+	server := payrextest.NewUnstartedServer()
+	defer server.Close()
+
+	httpServer := httptest.NewServer((&mock{server: server, statePath: statePath}).handler())
+	defer httpServer.Close()
+
+	client := payrex.NewClient("sk_test_123").WithBaseURL(httpServer.URL)
+
+	created, err := client.PaymentIntents.Create(&payrex.PaymentIntentCreateParams{
+		Amount:         100_00,
+		Currency:       payrex.CurrencyPHP,
+		PaymentMethods: payrex.Slice(payrex.PaymentMethodCard),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	retrieved, err := client.PaymentIntents.Retrieve(created.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if retrieved.Amount != created.Amount {
+		t.Errorf("got amount %d, want %d", retrieved.Amount, created.Amount)
+	}
+
+	resp, err := http.PostForm(httpServer.URL+"/_admin/payment_intents/"+created.ID+"/pay",
+		url.Values{"payment_method": {string(payrex.PaymentMethodCard)}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("got status %d paying the payment intent, want %d", resp.StatusCode, http.StatusOK)
+	}
+
+	resp, err = http.Post(httpServer.URL+"/_admin/events/evt_missing/resend", "", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("got status %d resending a missing event, want %d", resp.StatusCode, http.StatusNotFound)
+	}
+
+	// The state is saved after every change, and restored by a new mock
+	restarted := payrextest.NewUnstartedServer()
+	defer restarted.Close()
+
+	loaded, err := loadState(restarted, statePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !loaded {
+		t.Fatal("got no state file saved, want one")
+	}
+
+	restartedServer := httptest.NewServer((&mock{server: restarted}).handler())
+	defer restartedServer.Close()
+
+	paid, err := payrex.NewClient("sk_test_123").WithBaseURL(restartedServer.URL).PaymentIntents.Retrieve(created.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if paid.Status != payrex.PaymentIntentStatusSucceeded {
+		t.Errorf("got status '%s' after a restart, want '%s'", paid.Status, payrex.PaymentIntentStatusSucceeded)
+	}
+}
@@ -1,6 +1,9 @@
 package payrex
 
-import "iter"
+import (
+	"iter"
+	"net/url"
+)
 
 // Customer represents the customer of your business.
 // A customer could be a person or a company.
@@ -11,12 +14,14 @@ import "iter"
 // API reference: https://docs.payrexhq.com/docs/api/customers
 type Customer struct {
 	Resource
-	BillingStatementPrefix             string             `json:"billing_statement_prefix"`
-	Currency                           Currency           `json:"currency"`
-	Email                              string             `json:"email"`
-	Name                               string             `json:"name"`
-	Metadata                           *map[string]string `json:"metadata"`
-	NextBillingStatementSequenceNumber string             `json:"next_billing_statement_sequence_number"`
+	BillingStatementPrefix             string   `json:"billing_statement_prefix"`
+	Currency                           Currency `json:"currency"`
+	Email                              string   `json:"email"`
+	Name                               string   `json:"name"`
+	Metadata                           Metadata `json:"metadata"`
+	NextBillingStatementSequenceNumber string   `json:"next_billing_statement_sequence_number"`
+
+	Extra ExtraFields `json:"-"`
 }
 
 // ServiceCustomers is used to interact with [Customer] resources,
@@ -80,34 +85,40 @@ func (s *ServiceCustomers) Delete(id string) (*DeletedResource, error) {
 //
 // API reference: https://docs.payrexhq.com/docs/api/customers/create
 type CustomerCreateParams struct {
-	Currency                           Currency           `form:"currency"`
-	Name                               string             `form:"name"`
-	Email                              string             `form:"email"`
-	BillingStatementPrefix             *string            `form:"billing_statement_prefix"`
-	NextBillingStatementSequenceNumber *string            `form:"next_billing_statement_sequence_number"`
-	Metadata                           *map[string]string `form:"metadata"`
+	Currency                           Currency `form:"currency"`
+	Name                               string   `form:"name"`
+	Email                              string   `form:"email"`
+	BillingStatementPrefix             *string  `form:"billing_statement_prefix"`
+	NextBillingStatementSequenceNumber *string  `form:"next_billing_statement_sequence_number"`
+	Metadata                           Metadata `form:"metadata"`
+
+	Extra url.Values `form:",extra"`
 }
 
 // CustomerListParams represents the available [ServiceCustomers.List] parameters.
 //
 // API reference: https://docs.payrexhq.com/docs/api/customers/list
 type CustomerListParams struct {
-	Limit    *int               `form:"int"`
-	Before   *string            `form:"before"`
-	After    *string            `form:"after"`
-	Email    *string            `form:"email"`
-	Name     *string            `form:"name"`
-	Metadata *map[string]string `form:"metadata"`
+	Limit    *int     `form:"limit"`
+	Before   *string  `form:"before"`
+	After    *string  `form:"after"`
+	Email    *string  `form:"email"`
+	Name     *string  `form:"name"`
+	Metadata Metadata `form:"metadata"`
+
+	Extra url.Values `form:",extra"`
 }
 
 // CustomerUpdateParams represents the available [ServiceCustomers.Update] parameters.
 //
 // API reference: https://docs.payrexhq.com/docs/api/customers/update
 type CustomerUpdateParams struct {
-	Currency                           *Currency          `form:"currency"`
-	Name                               *string            `form:"name"`
-	Email                              *string            `form:"email"`
-	BillingStatementPrefix             *string            `form:"billing_statement_prefix"`
-	NextBillingStatementSequenceNumber *string            `form:"next_billing_statement_sequence_number"`
-	Metadata                           *map[string]string `form:"metadata"`
+	Currency                           *Currency `form:"currency"`
+	Name                               *string   `form:"name"`
+	Email                              *string   `form:"email"`
+	BillingStatementPrefix             *string   `form:"billing_statement_prefix"`
+	NextBillingStatementSequenceNumber *string   `form:"next_billing_statement_sequence_number"`
+	Metadata                           Metadata  `form:"metadata"`
+
+	Extra url.Values `form:",extra"`
 }
@@ -1,5 +1,7 @@
 package payrex
 
+import "net/url"
+
 // TODO: add doc comments for resources when PayRex adds official docs for CustomerSession
 
 // CustomerSession represents a customer session.
@@ -12,6 +14,8 @@ type CustomerSession struct {
 	Components   []CustomerSessionComponent `json:"components"`
 	Expired      bool                       `json:"expired"`
 	ExpiredAt    int                        `json:"expired_at"`
+
+	Extra ExtraFields `json:"-"`
 }
 
 type CustomerSessionComponent struct {
@@ -60,4 +64,6 @@ func (s *ServiceCustomerSessions) Retrieve(id string) (*CustomerSession, error)
 // API reference: https://docs.payrexhq.com/docs/api/customer_sessions/create
 type CustomerSessionCreateParams struct {
 	CustomerID string `form:"customer_id"`
+
+	Extra url.Values `form:",extra"`
 }
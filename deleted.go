@@ -5,4 +5,6 @@ type DeletedResource struct {
 	// Unique identifier for the resource.
 	ID      string `json:"id"`
 	Deleted bool   `json:"deleted"`
+
+	Extra ExtraFields `json:"-"`
 }
@@ -0,0 +1,250 @@
+package payrex
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"reflect"
+	"slices"
+	"strings"
+)
+
+// DriftKind enumerates the kinds of [Drift].
+type DriftKind string
+
+const (
+	// DriftUnknownField is a field of a response that is not mapped to any field of its Go type.
+	DriftUnknownField DriftKind = "unknown_field"
+	// DriftUnknownEnumValue is a value of an enum field that is not one of the values known by this library,
+	// such as a new [PaymentIntentStatus].
+	DriftUnknownEnumValue DriftKind = "unknown_enum_value"
+)
+
+// Drift is a difference between a response of the PayRex API and the types of this library,
+// such as a field added or renamed by PayRex. Drifts are reported to the handler set with
+// [Client.WithDriftHandler].
+type Drift struct {
+	Kind DriftKind
+	// The method of the request, e.g. "GET".
+	Method string
+	// The path of the request, e.g. "/payment_intents/pi_123".
+	Path string
+	// The path of the field in the response, e.g. "payment_intent.status" or "data[0].currency".
+	Field string
+	// The Go type the field is missing from for unknown fields, or the enum type for unknown enum values.
+	Type reflect.Type
+	// The unknown value of the field, for unknown enum values.
+	Value string
+}
+
+// String returns a description of the drift for logging,
+// e.g. "GET /payment_intents/pi_123: field 'status' has unknown payrex.PaymentIntentStatus value 'requires_review'".
+func (d Drift) String() string {
+	switch d.Kind {
+	case DriftUnknownEnumValue:
+		return fmt.Sprintf("%s %s: field '%s' has unknown %s value '%s'", d.Method, d.Path, d.Field, d.Type, d.Value)
+	default:
+		return fmt.Sprintf("%s %s: field '%s' is not mapped to any field of %s", d.Method, d.Path, d.Field, d.Type)
+	}
+}
+
+// WithDriftHandler makes the client check responses for fields and enum values not known
+// by this library, and report them to the handler. Responses are still decoded as usual,
+// so requests don't fail because of drifts.
+//
+// Useful for learning about changes to the PayRex API before they cause bugs:
+//
+//	payrexClient := payrex.NewClient(apiKey).WithDriftHandler(payrex.LogDrift(slog.Default()))
+//
+// The handler is called synchronously for each drift, before the request returns.
+func (c *Client) WithDriftHandler(handler func(Drift)) *Client {
+	c.driftHandler = handler
+	return c
+}
+
+// LogDrift returns a drift handler for [Client.WithDriftHandler] that logs drifts as warnings.
+func LogDrift(logger *slog.Logger) func(Drift) {
+	return func(d Drift) {
+		logger.Warn("payrex: API response drifted from the library",
+			slog.String("kind", string(d.Kind)),
+			slog.String("method", d.Method),
+			slog.String("path", d.Path),
+			slog.String("field", d.Field),
+			slog.String("type", d.Type.String()),
+			slog.String("value", d.Value),
+		)
+	}
+}
+
+// reportDrift reports the drifts of a response body decoded into a value of the type.
+func (c *Client) reportDrift(method string, path urlPath, typ reflect.Type, body []byte) {
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+
+	var v any
+	if err := decoder.Decode(&v); err != nil {
+		return
+	}
+
+	for _, drift := range findDrift(typ, v, "") {
+		drift.Method = method
+		drift.Path = string(path)
+		c.driftHandler(drift)
+	}
+}
+
+// ignoredFields are the fields of PayRex objects deliberately not mapped to struct fields.
+// 'resource' is the name of the type of every object, such as "payment_intent".
+var ignoredFields = []string{"resource"}
+
+// findDrift returns the drifts of a decoded JSON value from the type it's decoded into,
+// sorted by field.
+func findDrift(typ reflect.Type, v any, field string) []Drift {
+	for typ.Kind() == reflect.Pointer {
+		typ = typ.Elem()
+	}
+
+	var drifts []Drift
+
+	switch v := v.(type) {
+	case map[string]any:
+		switch typ.Kind() {
+		case reflect.Map:
+			for key, value := range v {
+				drifts = append(drifts, findDrift(typ.Elem(), value, joinField(field, key))...)
+			}
+		case reflect.Struct:
+			fields := jsonFields(typ)
+			for key, value := range v {
+				structField, ok := fields[key]
+				switch {
+				case ok:
+					drifts = append(drifts, findDrift(structField.Type, value, joinField(field, key))...)
+				case !slices.Contains(ignoredFields, key):
+					drifts = append(drifts, Drift{Kind: DriftUnknownField, Field: joinField(field, key), Type: typ})
+				}
+			}
+		}
+	case []any:
+		if typ.Kind() != reflect.Slice && typ.Kind() != reflect.Array {
+			break
+		}
+		for i, value := range v {
+			drifts = append(drifts, findDrift(typ.Elem(), value, fmt.Sprintf("%s[%d]", field, i))...)
+		}
+	case string:
+		if known, ok := knownEnumValues[typ]; ok && !slices.Contains(known, v) {
+			drifts = append(drifts, Drift{Kind: DriftUnknownEnumValue, Field: field, Type: typ, Value: v})
+		}
+	}
+
+	slices.SortFunc(drifts, func(a, b Drift) int { return strings.Compare(a.Field, b.Field) })
+	return drifts
+}
+
+// jsonFields returns the fields of the struct type by their JSON names,
+// including the fields of embedded structs. The Index of each field is relative
+// to the struct type, so it can be passed to [reflect.Value.FieldByIndex].
+//
+// Returns no fields if the type is not a struct.
+func jsonFields(typ reflect.Type) map[string]reflect.StructField {
+	fields := map[string]reflect.StructField{}
+	if typ.Kind() != reflect.Struct {
+		return fields
+	}
+
+	for _, field := range reflect.VisibleFields(typ) {
+		if !field.IsExported() || field.Anonymous {
+			continue
+		}
+
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		switch name {
+		case "-":
+			continue
+		case "":
+			name = field.Name
+		}
+		fields[name] = field
+	}
+
+	return fields
+}
+
+func joinField(field, key string) string {
+	if field == "" {
+		return key
+	}
+	return field + "." + key
+}
+
+// knownEnumValues lists the known values of the enum types of responses.
+var knownEnumValues = map[reflect.Type][]string{
+	reflect.TypeFor[AllowedFunding](): enumValues(AllowedFundingCredit, AllowedFundingDebit),
+	reflect.TypeFor[BillingStatementStatus](): enumValues(
+		BillingStatementStatusOpen,
+		BillingStatementStatusDraft,
+		BillingStatementStatusPaid,
+		BillingStatementStatusVoid,
+		BillingStatementStatusUncollectible,
+	),
+	reflect.TypeFor[CaptureType](): enumValues(CaptureTypeAutomatic, CaptureTypeManual),
+	reflect.TypeFor[CheckoutSessionStatus](): enumValues(
+		CheckoutSessionStatusActive,
+		CheckoutSessionStatusCompleted,
+		CheckoutSessionStatusExpired,
+	),
+	reflect.TypeFor[Currency](): enumValues(CurrencyPHP),
+	reflect.TypeFor[CustomerSessionComponentValue](): enumValues(
+		CustomerSessionComponentValueEnabled,
+		CustomerSessionComponentValueDisabled,
+	),
+	reflect.TypeFor[EventType](): enumValues(eventTypes...),
+	reflect.TypeFor[PaymentIntentStatus](): enumValues(
+		PaymentIntentStatusAwaitingPaymentMethod,
+		PaymentIntentStatusAwaitingNextAction,
+		PaymentIntentStatusProcessing,
+		PaymentIntentStatusAwaitingCapture,
+		PaymentIntentStatusSucceeded,
+		PaymentIntentStatusCanceled,
+	),
+	reflect.TypeFor[PaymentMethod](): enumValues(
+		PaymentMethodCard,
+		PaymentMethodGCash,
+		PaymentMethodMaya,
+		PaymentMethodQRPh,
+	),
+	reflect.TypeFor[PaymentStatus](): enumValues(PaymentStatusPaid, PaymentStatusFailed),
+	reflect.TypeFor[PayoutStatus](): enumValues(
+		PayoutStatusPending,
+		PayoutStatusInTransit,
+		PayoutStatusFailed,
+		PayoutStatusSuccessful,
+	),
+	reflect.TypeFor[PayoutTransactionType](): enumValues(
+		PayoutTransactionTypePayment,
+		PayoutTransactionTypeRefund,
+		PayoutTransactionTypeAdjustment,
+	),
+	reflect.TypeFor[RefundReason](): enumValues(
+		RefundReasonFraudulent,
+		RefundReasonRequestedByCustomer,
+		RefundReasonProductOutOfStock,
+		RefundReasonServiceNotProvided,
+		RefundReasonProductWasDamaged,
+		RefundReasonServiceMisaligned,
+		RefundReasonWrongProductReceived,
+		RefundReasonOthers,
+	),
+	reflect.TypeFor[RefundStatus]():  enumValues(RefundStatusSucceeded, RefundStatusFailed, RefundStatusPending),
+	reflect.TypeFor[WebhookStatus](): enumValues(WebhookStatusEnabled, WebhookStatusDisabled),
+}
+
+func enumValues[T ~string](values ...T) []string {
+	s := make([]string, len(values))
+	for i, value := range values {
+		s[i] = string(value)
+	}
+	return s
+}
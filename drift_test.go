@@ -0,0 +1,101 @@
+package payrex
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"slices"
+	"testing"
+)
+
+func TestDriftHandler(t *testing.T) {
+	var paymentIntent map[string]any
+	if err := json.Unmarshal(readGolden(t, "payment_intent"), &paymentIntent); err != nil {
+		t.Fatal(err)
+	}
+	paymentIntent["setup_future_usage"] = "off_session"
+	paymentIntent["status"] = "requires_review"
+
+	body, err := json.Marshal(paymentIntent)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write(body)
+	}))
+	defer server.Close()
+
+	var drifts []Drift
+	client := NewClient("sk_test_123").
+		WithBaseURL(server.URL).
+		WithDriftHandler(func(d Drift) { drifts = append(drifts, d) })
+
+	got, err := client.PaymentIntents.Retrieve("pi_123")
+	if err != nil {
+		t.Fatalf("got error %v, want the request to succeed despite the drifts", err)
+	}
+	if got.Status != "requires_review" {
+		t.Errorf("got status '%s', want the unknown status 'requires_review'", got.Status)
+	}
+
+	want := []Drift{
+		{
+			Kind:   DriftUnknownField,
+			Method: http.MethodGet,
+			Path:   "/payment_intents/pi_123",
+			Field:  "setup_future_usage",
+			Type:   reflect.TypeFor[PaymentIntent](),
+		},
+		{
+			Kind:   DriftUnknownEnumValue,
+			Method: http.MethodGet,
+			Path:   "/payment_intents/pi_123",
+			Field:  "status",
+			Type:   reflect.TypeFor[PaymentIntentStatus](),
+			Value:  "requires_review",
+		},
+	}
+	if !slices.Equal(drifts, want) {
+		t.Errorf("got drifts\n%v\nwant\n%v", drifts, want)
+	}
+}
+
+func TestDriftString(t *testing.T) {
+	tests := map[string]struct {
+		drift Drift
+		want  string
+	}{
+		"unknown field": {
+			drift: Drift{
+				Kind:   DriftUnknownField,
+				Method: http.MethodGet,
+				Path:   "/payment_intents/pi_123",
+				Field:  "setup_future_usage",
+				Type:   reflect.TypeFor[PaymentIntent](),
+			},
+			want: "GET /payment_intents/pi_123: field 'setup_future_usage' is not mapped to any field of payrex.PaymentIntent",
+		},
+		"unknown enum value": {
+			drift: Drift{
+				Kind:   DriftUnknownEnumValue,
+				Method: http.MethodGet,
+				Path:   "/payment_intents/pi_123",
+				Field:  "status",
+				Type:   reflect.TypeFor[PaymentIntentStatus](),
+				Value:  "requires_review",
+			},
+			want: "GET /payment_intents/pi_123: field 'status' has unknown payrex.PaymentIntentStatus value 'requires_review'",
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			if got := tt.drift.String(); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -2,15 +2,12 @@ package payrex
 
 import (
 	"bytes"
-	"crypto/hmac"
-	"crypto/sha256"
-	"encoding/hex"
 	"encoding/json"
-	"errors"
 	"fmt"
-	"io"
 	"net/http"
-	"strings"
+	"reflect"
+	"slices"
+	"sync"
 )
 
 // Event represents updates in your PayRex account triggered either by API calls or your actions from the Dashboard.
@@ -23,15 +20,59 @@ type Event struct {
 	Type EventType `json:"type"`
 	// The name of the type of resource associated with this event.
 	// Corresponds to the first part of the Type.
-	ResourceType       EventResourceType
-	PendingWebhooks    int            `json:"pending_webhooks"`
-	PreviousAttributes map[string]any `json:"previous_attributes"`
-
-	billingStatement *BillingStatement
-	checkoutSession  *CheckoutSession
-	paymentIntent    *PaymentIntent
-	payout           *Payout
-	refund           *Refund
+	ResourceType       EventResourceType `json:"-"`
+	PendingWebhooks    int               `json:"pending_webhooks"`
+	PreviousAttributes map[string]any    `json:"previous_attributes"`
+	// The raw JSON of the resource associated with this event.
+	//
+	// Use [EventData] to decode it into a resource type.
+	Data json.RawMessage `json:"data"`
+	// The webhook secret that verified the event,
+	// if the event was parsed using a [WebhookVerifier].
+	MatchedSecret *WebhookSecretMatch `json:"-"`
+	// The tenant that the event is for, if the event was parsed using a
+	// [MultiTenantWebhookVerifier]. Not sent by PayRex, but kept when the event
+	// is encoded to JSON, such as in an [EventQueue].
+	Tenant string `json:"tenant,omitempty"`
+
+	Extra ExtraFields `json:"-"`
+
+	// decoded caches the resources decoded by [EventData].
+	// Only set on events decoded from JSON.
+	decoded *eventDataCache
+}
+
+// eventDataCache caches the resources decoded from the data of an [Event], keyed by type.
+type eventDataCache struct {
+	mu sync.Mutex
+	// data is the raw JSON the resources were decoded from,
+	// so the cache is cleared if Event.Data is replaced.
+	data      json.RawMessage
+	resources map[reflect.Type]any
+}
+
+// eventDataCacheGet returns the resource of type T decoded from the data, decoding it if it's not cached yet.
+func eventDataCacheGet[T any](c *eventDataCache, data json.RawMessage) (*T, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if !bytes.Equal(c.data, data) {
+		c.data = slices.Clone(data)
+		c.resources = map[reflect.Type]any{}
+	}
+
+	resourceType := reflect.TypeFor[T]()
+	if resource, ok := c.resources[resourceType]; ok {
+		return resource.(*T), nil
+	}
+
+	resource, err := decodeEventData[T](data)
+	if err != nil {
+		return nil, err
+	}
+	c.resources[resourceType] = resource
+
+	return resource, nil
 }
 
 // EventType enumerates the event types of an [Event]
@@ -68,80 +109,148 @@ const (
 //
 // For example, if the Event.ResourceType is [EventResourceTypeBillingStatement], you can
 // call [Event.MustBillingStatement] without issues.
+//
+// Events with a resource type not listed here are still parsed, with the
+// Event.ResourceType set to the resource name sent by PayRex. Their resource
+// can be decoded from the raw Event.Data using [EventData].
 type EventResourceType string
 
 const (
-	EventResourceTypeBillingStatement EventResourceType = "billing_statement"
-	EventResourceTypeCheckoutSession  EventResourceType = "checkout_session"
-	EventResourceTypePaymentIntent    EventResourceType = "payment_intent"
-	EventResourceTypePayout           EventResourceType = "payout"
-	EventResourceTypeRefund           EventResourceType = "refund"
+	EventResourceTypeBillingStatement         EventResourceType = "billing_statement"
+	EventResourceTypeBillingStatementLineItem EventResourceType = "billing_statement_line_item"
+	EventResourceTypeCheckoutSession          EventResourceType = "checkout_session"
+	EventResourceTypePaymentIntent            EventResourceType = "payment_intent"
+	EventResourceTypePayout                   EventResourceType = "payout"
+	EventResourceTypeRefund                   EventResourceType = "refund"
 )
 
+// EventResourceMismatchError is returned when decoding the resource of an [Event]
+// into a resource type that doesn't match the [EventResourceType] of the event.
+type EventResourceMismatchError struct {
+	// The resource type that the event resource was decoded into.
+	Expected EventResourceType
+	// The resource type of the event.
+	Actual EventResourceType
+}
+
+func (e *EventResourceMismatchError) Error() string {
+	return fmt.Sprintf("event resource is of type '%s', not '%s'", e.Actual, e.Expected)
+}
+
+// EventData decodes the resource associated with the event into a value of type T.
+//
+// If T is one of the resource types of [EventResourceType], such as [PaymentIntent],
+// an *[EventResourceMismatchError] is returned if the event's resource is of another type.
+// Other types are decoded from the raw Event.Data as is, which is useful for reading
+// resources of types not yet supported by this library.
+//
+// For events decoded from JSON, such as parsed webhook events, the resource is decoded
+// once for each type T and cached on the event, so every call returns the same value
+// until Event.Data is replaced. Copy the value before changing it.
+//
+// Example:
+//
+//	paymentIntent, err := payrex.EventData[payrex.PaymentIntent](event)
+func EventData[T any](e *Event) (*T, error) {
+	if resourceType, ok := eventResourceTypeOf[T](); ok && resourceType != e.ResourceType {
+		return nil, &EventResourceMismatchError{Expected: resourceType, Actual: e.ResourceType}
+	}
+
+	if e.decoded == nil {
+		return decodeEventData[T](e.Data)
+	}
+	return eventDataCacheGet[T](e.decoded, e.Data)
+}
+
+// decodeEventData decodes the raw JSON of an event resource into a new value of type T.
+func decodeEventData[T any](data json.RawMessage) (*T, error) {
+	var resource T
+	if err := json.Unmarshal(data, &resource); err != nil {
+		return nil, fmt.Errorf("could not decode event resource: %w", err)
+	}
+
+	return &resource, nil
+}
+
+// eventResourceTypeOf returns the [EventResourceType] of the resource type T,
+// and whether T is a known event resource type.
+func eventResourceTypeOf[T any]() (EventResourceType, bool) {
+	switch any((*T)(nil)).(type) {
+	case *BillingStatement:
+		return EventResourceTypeBillingStatement, true
+	case *BillingStatementLineItem:
+		return EventResourceTypeBillingStatementLineItem, true
+	case *CheckoutSession:
+		return EventResourceTypeCheckoutSession, true
+	case *PaymentIntent:
+		return EventResourceTypePaymentIntent, true
+	case *Payout:
+		return EventResourceTypePayout, true
+	case *Refund:
+		return EventResourceTypeRefund, true
+	default:
+		return "", false
+	}
+}
+
 // BillingStatement returns the billing statement associated with this event,
 // if the event type starts with 'billing_statement'.
 //
-// It's recommended to check the [Event].ResourceName first to see if the [EventType]
+// It's recommended to check the [Event].ResourceType first to see if the [EventType]
 // is of a billing statement.
 func (e *Event) BillingStatement() (*BillingStatement, error) {
-	if e.billingStatement == nil {
-		return nil, errors.New("billing statement in event not found")
-	}
-	return e.billingStatement, nil
+	return EventData[BillingStatement](e)
+}
+
+// BillingStatementLineItem returns the billing statement line item associated with this event,
+// if the event type starts with 'billing_statement_line_item'.
+//
+// It's recommended to check the [Event].ResourceType first to see if the [EventType]
+// is of a billing statement line item.
+func (e *Event) BillingStatementLineItem() (*BillingStatementLineItem, error) {
+	return EventData[BillingStatementLineItem](e)
 }
 
 // CheckoutSession returns the checkout session associated with this event,
 // if the event type starts with 'checkout_session'.
 //
-// It's recommended to check the [Event].ResourceName first to see if the [EventType]
+// It's recommended to check the [Event].ResourceType first to see if the [EventType]
 // is of a checkout session.
 func (e *Event) CheckoutSession() (*CheckoutSession, error) {
-	if e.checkoutSession == nil {
-		return nil, errors.New("checkout session in event not found")
-	}
-	return e.checkoutSession, nil
+	return EventData[CheckoutSession](e)
 }
 
 // PaymentIntent returns the payment intent associated with this event,
 // if the event type starts with 'payment_intent'.
 //
-// It's recommended to check the [Event].ResourceName first to see if the [EventType]
+// It's recommended to check the [Event].ResourceType first to see if the [EventType]
 // is of a payment intent.
 func (e *Event) PaymentIntent() (*PaymentIntent, error) {
-	if e.paymentIntent == nil {
-		return nil, errors.New("payment intent in event not found")
-	}
-	return e.paymentIntent, nil
+	return EventData[PaymentIntent](e)
 }
 
 // Payout returns the payout associated with this event,
 // if the event type starts with 'payout'.
 //
-// It's recommended to check the [Event].ResourceName first to see if the [EventType]
+// It's recommended to check the [Event].ResourceType first to see if the [EventType]
 // is of a payout.
 func (e *Event) Payout() (*Payout, error) {
-	if e.payout == nil {
-		return nil, errors.New("payout in event not found")
-	}
-	return e.payout, nil
+	return EventData[Payout](e)
 }
 
 // Refund returns the refund associated with this event,
 // if the event type starts with 'refund'.
 //
-// It's recommended to check the [Event].ResourceName first to see if the [EventType]
+// It's recommended to check the [Event].ResourceType first to see if the [EventType]
 // is of a refund.
 func (e *Event) Refund() (*Refund, error) {
-	if e.refund == nil {
-		return nil, errors.New("refund in event not found")
-	}
-	return e.refund, nil
+	return EventData[Refund](e)
 }
 
 // MustBillingStatement returns the billing statement associated with this event,
 // or panics if there is none.
 //
-// It's recommended to check the [Event].ResourceName first to see if the [EventType]
+// It's recommended to check the [Event].ResourceType first to see if the [EventType]
 // is of a billing statement.
 func (e *Event) MustBillingStatement() *BillingStatement {
 	billingStatement, err := e.BillingStatement()
@@ -151,10 +260,23 @@ func (e *Event) MustBillingStatement() *BillingStatement {
 	return billingStatement
 }
 
+// MustBillingStatementLineItem returns the billing statement line item associated with this event,
+// or panics if there is none.
+//
+// It's recommended to check the [Event].ResourceType first to see if the [EventType]
+// is of a billing statement line item.
+func (e *Event) MustBillingStatementLineItem() *BillingStatementLineItem {
+	billingStatementLineItem, err := e.BillingStatementLineItem()
+	if err != nil {
+		panic(err)
+	}
+	return billingStatementLineItem
+}
+
 // MustCheckoutSession returns the checkout session associated with this event,
 // or panics if there is none.
 //
-// It's recommended to check the [Event].ResourceName first to see if the [EventType]
+// It's recommended to check the [Event].ResourceType first to see if the [EventType]
 // is of a checkout session.
 func (e *Event) MustCheckoutSession() *CheckoutSession {
 	checkoutSession, err := e.CheckoutSession()
@@ -167,7 +289,7 @@ func (e *Event) MustCheckoutSession() *CheckoutSession {
 // MustPaymentIntent returns the payment intent associated with this event,
 // or panics if there is none.
 //
-// It's recommended to check the [Event].ResourceName first to see if the [EventType]
+// It's recommended to check the [Event].ResourceType first to see if the [EventType]
 // is of a payment intent.
 func (e *Event) MustPaymentIntent() *PaymentIntent {
 	paymentIntent, err := e.PaymentIntent()
@@ -180,7 +302,7 @@ func (e *Event) MustPaymentIntent() *PaymentIntent {
 // MustPayout returns the payout associated with this event,
 // or panics if there is none.
 //
-// It's recommended to check the [Event].ResourceName first to see if the [EventType]
+// It's recommended to check the [Event].ResourceType first to see if the [EventType]
 // is of a payout.
 func (e *Event) MustPayout() *Payout {
 	payout, err := e.Payout()
@@ -193,7 +315,7 @@ func (e *Event) MustPayout() *Payout {
 // MustRefund returns the refund associated with this event,
 // or panics if there is none.
 //
-// It's recommended to check the [Event].ResourceName first to see if the [EventType]
+// It's recommended to check the [Event].ResourceType first to see if the [EventType]
 // is of a refund.
 func (e *Event) MustRefund() *Refund {
 	refund, err := e.Refund()
@@ -203,31 +325,14 @@ func (e *Event) MustRefund() *Refund {
 	return refund
 }
 
-var (
-	ErrNoSignatureHeader      = errors.New("header 'Payrex-Signature' not found in request")
-	ErrInvalidSignatureFormat = errors.New("invalid PayRex signature format")
-	ErrInvalidSignature       = errors.New("invalid PayRex signature")
-)
-
 // ParseEvent parses an event from a PayRex webhook request.
 //
+// The signature is verified using a [WebhookVerifier] with the default settings.
+// Use [NewWebhookVerifier] to customize the verification.
+//
 // Reference: https://docs.payrexhq.com/docs/guide/developer_handbook/webhooks
 func ParseEvent(r *http.Request, webhookSecretKey string) (*Event, error) {
-	signatureHeader := r.Header.Get("Payrex-Signature")
-
-	if signatureHeader == "" {
-		return nil, ErrNoSignatureHeader
-	}
-
-	payload, err := io.ReadAll(r.Body)
-	if err != nil {
-		return nil, fmt.Errorf("could not read request body: %w", err)
-	}
-	_ = r.Body.Close()
-
-	r.Body = io.NopCloser(bytes.NewReader(payload))
-
-	return ParseEventFromBytes(payload, signatureHeader, webhookSecretKey)
+	return NewWebhookVerifier(webhookSecretKey).ParseEvent(r)
 }
 
 // ParseEventFromBytes parses an event from a PayRex webhook request.
@@ -235,113 +340,59 @@ func ParseEvent(r *http.Request, webhookSecretKey string) (*Event, error) {
 // If you are in an [http.HandlerFunc], it's recommended to use [ParseEvent]
 // instead to extract the event directly from an *[http.Request].
 //
+// The signature is verified using a [WebhookVerifier] with the default settings.
+// Use [NewWebhookVerifier] to customize the verification.
+//
 // Reference: https://docs.payrexhq.com/docs/guide/developer_handbook/webhooks
 func ParseEventFromBytes(payload []byte, signatureHeader, webhookSecretKey string) (*Event, error) {
-	if err := verifyWebhookSignature(payload, signatureHeader, webhookSecretKey); err != nil {
-		return nil, fmt.Errorf("webhook signature verification failed: %w", err)
-	}
-
-	// eventWithResourceName is used to parse the resource name of an [Event].
-	type eventWithResourceName struct {
-		Data struct {
-			Resource string `json:"resource"`
-		} `json:"data"`
-	}
-
-	// eventWithResource is used to parse the resource type of an [Event].
-	type eventWithResource[T any] struct {
-		Data T `json:"data"`
-	}
+	return NewWebhookVerifier(webhookSecretKey).ParseEventFromBytes(payload, signatureHeader)
+}
 
+// parseEvent parses an event from a webhook request payload
+// whose signature has already been verified.
+func parseEvent(payload []byte) (*Event, error) {
 	var event Event
 	if err := json.Unmarshal(payload, &event); err != nil {
 		return nil, fmt.Errorf("could not decode event: %w", err)
 	}
 
-	var resourceNameContainer eventWithResourceName
-	if err := json.Unmarshal(payload, &resourceNameContainer); err != nil {
-		return nil, fmt.Errorf("could not decode event resource: %w", err)
-	}
-
-	resourceName := resourceNameContainer.Data.Resource
-
-	switch resourceName {
-
-	case "billing_statement":
-		var resourceContainer eventWithResource[BillingStatement]
-		if err := json.Unmarshal(payload, &resourceContainer); err != nil {
-			return nil, fmt.Errorf("could not decode billing statement: %w", err)
-		}
-
-		event.billingStatement = &resourceContainer.Data
-		event.ResourceType = EventResourceTypeBillingStatement
-
-	case "checkout_session":
-		var resourceContainer eventWithResource[CheckoutSession]
-		if err := json.Unmarshal(payload, &resourceContainer); err != nil {
-			return nil, fmt.Errorf("could not decode checkout session: %w", err)
-		}
-
-		event.checkoutSession = &resourceContainer.Data
-		event.ResourceType = EventResourceTypeCheckoutSession
-
-	case "payment_intent":
-		var resourceContainer eventWithResource[PaymentIntent]
-		if err := json.Unmarshal(payload, &resourceContainer); err != nil {
-			return nil, fmt.Errorf("could not decode payment intent: %w", err)
-		}
-
-		event.paymentIntent = &resourceContainer.Data
-		event.ResourceType = EventResourceTypePaymentIntent
-
-	case "payout":
-		var resourceContainer eventWithResource[Payout]
-		if err := json.Unmarshal(payload, &resourceContainer); err != nil {
-			return nil, fmt.Errorf("could not decode payout: %w", err)
-		}
-
-		event.payout = &resourceContainer.Data
-		event.ResourceType = EventResourceTypePayout
-
-	case "refund":
-		var resourceContainer eventWithResource[Refund]
-		if err := json.Unmarshal(payload, &resourceContainer); err != nil {
-			return nil, fmt.Errorf("could not decode refund: %w", err)
-		}
-
-		event.refund = &resourceContainer.Data
-		event.ResourceType = EventResourceTypeRefund
-
-	default:
-		return nil, fmt.Errorf("unrecognized event resource: '%s'", resourceName)
-	}
-
 	return &event, nil
 }
 
-func verifyWebhookSignature(payload []byte, signatureHeader, webhookSecretKey string) error {
-	parts := strings.Split(signatureHeader, ",")
-	if len(parts) < 3 {
-		return ErrInvalidSignatureFormat
-	}
+// UnmarshalJSON implements [json.Unmarshaler], setting the Event.ResourceType
+// from the resource name of the event data and keeping unknown fields in Event.Extra.
+func (e *Event) UnmarshalJSON(data []byte) error {
+	// eventFields is used to decode the fields of an [Event] without calling this method.
+	type eventFields Event
 
-	timestamp := strings.SplitN(parts[0], "=", 2)[1]
-	testModeSig := strings.SplitN(parts[1], "=", 2)[1]
-	liveModeSig := strings.SplitN(parts[2], "=", 2)[1]
+	// eventResourceName is used to parse the resource name of an [Event].
+	type eventResourceName struct {
+		Resource string `json:"resource"`
+	}
 
-	comparisonSig := liveModeSig
-	if comparisonSig == "" {
-		comparisonSig = testModeSig
+	var event eventFields
+	if err := unmarshalWithExtra(data, &event, &event.Extra); err != nil {
+		return err
 	}
 
-	message := []byte(timestamp + "." + string(payload))
-	mac := hmac.New(sha256.New, []byte(webhookSecretKey))
-	mac.Write(message)
-	expectedMAC := hex.EncodeToString(mac.Sum(nil))
+	if len(event.Data) > 0 && string(event.Data) != "null" {
+		var resourceName eventResourceName
+		if err := json.Unmarshal(event.Data, &resourceName); err != nil {
+			return fmt.Errorf("could not decode event resource: %w", err)
+		}
 
-	if expectedMAC != comparisonSig {
-		return ErrInvalidSignature
+		// Resources not supported by this library are kept in the raw Event.Data,
+		// so new event types sent by PayRex don't fail parsing.
+		event.ResourceType = EventResourceType(resourceName.Resource)
 	}
 
+	*e = Event(event)
+	e.decoded = &eventDataCache{}
 	return nil
 }
+
+// MarshalJSON implements [json.Marshaler], encoding Event.Extra along with the other fields.
+func (e Event) MarshalJSON() ([]byte, error) {
+	type eventFields Event
+	return marshalWithExtra(eventFields(e), e.Extra)
+}
@@ -0,0 +1,149 @@
+package payrex
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+)
+
+// EventPreviousAttributes decodes the Event.PreviousAttributes of an update event
+// into a partial value of the resource type T.
+//
+// Only the fields that changed in the event are set; all other fields have their zero value.
+// Use [EventChanges] to tell which fields changed.
+//
+// Like [EventData], an *[EventResourceMismatchError] is returned if T is a resource type
+// that doesn't match the resource type of the event.
+func EventPreviousAttributes[T any](e *Event) (*T, error) {
+	if resourceType, ok := eventResourceTypeOf[T](); ok && resourceType != e.ResourceType {
+		return nil, &EventResourceMismatchError{Expected: resourceType, Actual: e.ResourceType}
+	}
+
+	var previous T
+	if len(e.PreviousAttributes) == 0 {
+		return &previous, nil
+	}
+
+	data, err := json.Marshal(e.PreviousAttributes)
+	if err != nil {
+		return nil, fmt.Errorf("could not encode previous attributes: %w", err)
+	}
+
+	if err := json.Unmarshal(data, &previous); err != nil {
+		return nil, fmt.Errorf("could not decode previous attributes: %w", err)
+	}
+
+	return &previous, nil
+}
+
+// EventFieldChange is a field of a resource that changed in an update event.
+type EventFieldChange struct {
+	// The JSON name of the field, e.g. "status".
+	Field string
+	// The value of the field before the event.
+	Old any
+	// The value of the field after the event.
+	New any
+}
+
+// EventFieldChanges lists the fields of a resource that changed in an update event.
+type EventFieldChanges []EventFieldChange
+
+// Get returns the change of the field with the given JSON name,
+// and whether the field changed.
+func (c EventFieldChanges) Get(field string) (EventFieldChange, bool) {
+	for _, change := range c {
+		if change.Field == field {
+			return change, true
+		}
+	}
+	return EventFieldChange{}, false
+}
+
+// Has reports whether the field with the given JSON name changed.
+func (c EventFieldChanges) Has(field string) bool {
+	_, ok := c.Get(field)
+	return ok
+}
+
+// EventChanges lists the fields of the resource that changed in an update event,
+// with their old value from the Event.PreviousAttributes and their new value
+// from the event resource, sorted by field name.
+//
+// Old and new values have the type of the corresponding field of T, so they can be
+// compared with constants directly. Fields that T doesn't have are decoded as
+// untyped JSON values instead.
+//
+// Example of reacting to a billing statement getting paid:
+//
+//	changes, err := payrex.EventChanges[payrex.BillingStatement](event)
+//	if err != nil {
+//		return err
+//	}
+//
+//	if status, ok := changes.Get("status"); ok &&
+//		status.Old == payrex.BillingStatementStatusOpen &&
+//		status.New == payrex.BillingStatementStatusPaid {
+//		// ...
+//	}
+func EventChanges[T any](e *Event) (EventFieldChanges, error) {
+	current, err := EventData[T](e)
+	if err != nil {
+		return nil, err
+	}
+
+	var currentFields map[string]any
+	if err := json.Unmarshal(e.Data, &currentFields); err != nil {
+		return nil, fmt.Errorf("could not decode event resource: %w", err)
+	}
+
+	currentValue := reflect.ValueOf(current).Elem()
+	fields := jsonFields(currentValue.Type())
+
+	changes := make(EventFieldChanges, 0, len(e.PreviousAttributes))
+	for field, previousValue := range e.PreviousAttributes {
+		change := EventFieldChange{
+			Field: field,
+			Old:   previousValue,
+			New:   currentFields[field],
+		}
+
+		if structField, ok := fields[field]; ok {
+			// Fields of nil embedded struct pointers are kept as untyped JSON values
+			if fieldValue, err := currentValue.FieldByIndexErr(structField.Index); err == nil {
+				old, err := decodeAs(previousValue, fieldValue.Type())
+				if err != nil {
+					return nil, fmt.Errorf("could not decode previous value of field '%s': %w", field, err)
+				}
+
+				change.Old = old
+				change.New = fieldValue.Interface()
+			}
+		}
+
+		changes = append(changes, change)
+	}
+
+	slices.SortFunc(changes, func(a, b EventFieldChange) int {
+		return strings.Compare(a.Field, b.Field)
+	})
+
+	return changes, nil
+}
+
+// decodeAs converts an untyped JSON value into a value of the given type.
+func decodeAs(value any, valueType reflect.Type) (any, error) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+
+	decoded := reflect.New(valueType)
+	if err := json.Unmarshal(data, decoded.Interface()); err != nil {
+		return nil, err
+	}
+
+	return decoded.Elem().Interface(), nil
+}
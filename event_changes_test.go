@@ -0,0 +1,163 @@
+package payrex
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+// newBillingStatementUpdatedEvent returns a 'billing_statement.updated' event
+// of an open billing statement with the given previous attributes.
+func newBillingStatementUpdatedEvent(t *testing.T, previousAttributes string) *Event {
+	t.Helper()
+
+	payload := `{
+		"id": "evt_123",
+		"resource": "event",
+		"type": "billing_statement.updated",
+		"data": {
+			"id": "bstm_123",
+			"resource": "billing_statement",
+			"status": "open",
+			"amount": 100000,
+			"description": "Rent",
+			"payment_settings": {"payment_methods": ["card", "gcash"]},
+			"updated_at": 1700000100,
+			"unknown_field": "new"
+		},
+		"previous_attributes": ` + previousAttributes + `
+	}`
+
+	var event Event
+	if err := json.Unmarshal([]byte(payload), &event); err != nil {
+		t.Fatal(err)
+	}
+	return &event
+}
+
+func TestEventChanges(t *testing.T) {
+	tests := map[string]struct {
+		previousAttributes string
+		want               EventFieldChanges
+	}{
+		"field": {
+			previousAttributes: `{"status": "draft", "amount": 50000}`,
+			want: EventFieldChanges{
+				{Field: "amount", Old: 50000, New: 100000},
+				{Field: "status", Old: BillingStatementStatusDraft, New: BillingStatementStatusOpen},
+			},
+		},
+		"nested field": {
+			previousAttributes: `{"payment_settings": {"payment_methods": ["card"]}}`,
+			want: EventFieldChanges{{
+				Field: "payment_settings",
+				Old:   PaymentSettings{PaymentMethods: []PaymentMethod{PaymentMethodCard}},
+				New:   PaymentSettings{PaymentMethods: []PaymentMethod{PaymentMethodCard, PaymentMethodGCash}},
+			}},
+		},
+		"embedded field": {
+			previousAttributes: `{"updated_at": 1700000000}`,
+			want:               EventFieldChanges{{Field: "updated_at", Old: 1700000000, New: 1700000100}},
+		},
+		"null before": {
+			previousAttributes: `{"description": null}`,
+			want:               EventFieldChanges{{Field: "description", Old: (*string)(nil), New: NotNil("Rent")}},
+		},
+		"unknown field": {
+			previousAttributes: `{"unknown_field": "old", "missing_field": 1}`,
+			want: EventFieldChanges{
+				{Field: "missing_field", Old: float64(1), New: nil},
+				{Field: "unknown_field", Old: "old", New: "new"},
+			},
+		},
+		"no previous attributes": {
+			previousAttributes: `{}`,
+			want:               EventFieldChanges{},
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			event := newBillingStatementUpdatedEvent(t, tt.previousAttributes)
+
+			got, err := EventChanges[BillingStatement](event)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got changes %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEventFieldChangesGet(t *testing.T) {
+	event := newBillingStatementUpdatedEvent(t, `{"status": "draft"}`)
+
+	changes, err := EventChanges[BillingStatement](event)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if status, ok := changes.Get("status"); !ok || status.Old != BillingStatementStatusDraft || status.New != BillingStatementStatusOpen {
+		t.Errorf("got change %+v (found: %t), want a change from 'draft' to 'open'", status, ok)
+	}
+	if changes.Has("amount") {
+		t.Error("got a change of the amount, want none")
+	}
+}
+
+func TestEventPreviousAttributes(t *testing.T) {
+	tests := map[string]struct {
+		previousAttributes string
+		want               BillingStatement
+	}{
+		"field": {
+			previousAttributes: `{"status": "draft"}`,
+			want:               BillingStatement{Status: BillingStatementStatusDraft},
+		},
+		"nested field": {
+			previousAttributes: `{"payment_settings": {"payment_methods": ["card"]}}`,
+			want:               BillingStatement{PaymentSettings: PaymentSettings{PaymentMethods: []PaymentMethod{PaymentMethodCard}}},
+		},
+		"null before": {
+			previousAttributes: `{"description": null}`,
+			want:               BillingStatement{},
+		},
+		"unknown field": {
+			previousAttributes: `{"unknown_field": "old"}`,
+			want:               BillingStatement{Extra: ExtraFields{"unknown_field": json.RawMessage(`"old"`)}},
+		},
+		"no previous attributes": {
+			previousAttributes: `null`,
+			want:               BillingStatement{},
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			event := newBillingStatementUpdatedEvent(t, tt.previousAttributes)
+
+			got, err := EventPreviousAttributes[BillingStatement](event)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(*got, tt.want) {
+				t.Errorf("got previous attributes %+v, want %+v", *got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEventPreviousAttributesResourceMismatch(t *testing.T) {
+	event := newBillingStatementUpdatedEvent(t, `{"status": "draft"}`)
+
+	var mismatch *EventResourceMismatchError
+	if _, err := EventPreviousAttributes[PaymentIntent](event); !errors.As(err, &mismatch) {
+		t.Errorf("got error %v, want an *EventResourceMismatchError", err)
+	}
+	if _, err := EventChanges[PaymentIntent](event); !errors.As(err, &mismatch) {
+		t.Errorf("got error %v from EventChanges, want an *EventResourceMismatchError", err)
+	}
+}
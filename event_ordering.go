@@ -0,0 +1,240 @@
+package payrex
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+)
+
+// EventFreshness enumerates how the resource of an [Event] compares to
+// the latest version of the resource seen by a [ResourceVersionTracker].
+type EventFreshness string
+
+const (
+	// The event resource is newer than any version seen before, so it can be applied.
+	EventFreshnessNewer EventFreshness = "newer"
+	// The event resource is older than a version seen before, so it should be ignored.
+	EventFreshnessStale EventFreshness = "stale"
+	// The event resource has the same update time as the latest version seen before,
+	// so it can't be told which one is newer. The resource should be retrieved
+	// from the API before applying it.
+	EventFreshnessRetrieve EventFreshness = "retrieve"
+)
+
+// ResourceVersionStore stores the latest version of resources by their ID,
+// where the version of a resource is its Resource.UpdatedAt value.
+//
+// Implementations must be safe for concurrent use.
+type ResourceVersionStore interface {
+	// Get returns the stored version of the resource, or zero if there is none.
+	Get(ctx context.Context, resourceID string) (int, error)
+	// SetIfNewer stores the version of the resource if it's greater than the stored version,
+	// and returns the version stored before the call, or zero if there was none.
+	SetIfNewer(ctx context.Context, resourceID string, updatedAt int) (int, error)
+}
+
+// ResourceVersionTracker tracks the latest version of the resources of incoming events,
+// so events delivered out of order don't overwrite newer data with older data.
+//
+// Check the event before applying it, then record its version with
+// [ResourceVersionTracker.Applied] once it was applied, so an event that failed
+// to be applied is not considered stale when it's delivered again.
+//
+// Example:
+//
+//	tracker := payrex.NewResourceVersionTracker(payrex.NewMemoryResourceVersionStore())
+//
+//	billingStatement, err := payrex.EventData[payrex.BillingStatement](event)
+//	if err != nil {
+//		return err
+//	}
+//
+//	freshness, err := tracker.Check(ctx, event)
+//	if err != nil {
+//		return err
+//	}
+//
+//	switch freshness {
+//	case payrex.EventFreshnessStale:
+//		return nil
+//	case payrex.EventFreshnessRetrieve:
+//		billingStatement, err = payrexClient.BillingStatements.Retrieve(billingStatement.ID)
+//		if err != nil {
+//			return err
+//		}
+//	}
+//
+//	if err := saveBillingStatement(ctx, billingStatement); err != nil {
+//		return err
+//	}
+//
+//	return tracker.AppliedResource(ctx, &billingStatement.Resource)
+type ResourceVersionTracker struct {
+	store ResourceVersionStore
+}
+
+// NewResourceVersionTracker creates a new [ResourceVersionTracker] instance
+// that keeps resource versions in the given store.
+func NewResourceVersionTracker(store ResourceVersionStore) *ResourceVersionTracker {
+	return &ResourceVersionTracker{store: store}
+}
+
+// Check reports whether the version of the event's resource is newer or older than
+// the latest version applied before. The version is not recorded until
+// [ResourceVersionTracker.Applied] is called.
+func (t *ResourceVersionTracker) Check(ctx context.Context, event *Event) (EventFreshness, error) {
+	resource, err := EventData[Resource](event)
+	if err != nil {
+		return "", err
+	}
+
+	return t.CheckResource(ctx, resource)
+}
+
+// CheckResource reports whether the version of a resource is newer or older than
+// the latest version applied before.
+func (t *ResourceVersionTracker) CheckResource(ctx context.Context, resource *Resource) (EventFreshness, error) {
+	latest, err := t.store.Get(ctx, resource.ID)
+	if err != nil {
+		return "", fmt.Errorf("could not get version of resource '%s': %w", resource.ID, err)
+	}
+
+	switch {
+	case latest == 0 || resource.UpdatedAt > latest:
+		return EventFreshnessNewer, nil
+	case resource.UpdatedAt < latest:
+		return EventFreshnessStale, nil
+	default:
+		return EventFreshnessRetrieve, nil
+	}
+}
+
+// Applied records the version of the event's resource as applied,
+// unless a newer version was applied before.
+func (t *ResourceVersionTracker) Applied(ctx context.Context, event *Event) error {
+	resource, err := EventData[Resource](event)
+	if err != nil {
+		return err
+	}
+
+	return t.AppliedResource(ctx, resource)
+}
+
+// AppliedResource records the version of a resource as applied,
+// unless a newer version was applied before.
+//
+// Useful for also tracking resources retrieved from the API.
+func (t *ResourceVersionTracker) AppliedResource(ctx context.Context, resource *Resource) error {
+	if _, err := t.store.SetIfNewer(ctx, resource.ID, resource.UpdatedAt); err != nil {
+		return fmt.Errorf("could not store version of resource '%s': %w", resource.ID, err)
+	}
+	return nil
+}
+
+// resourceVersions is the base of the [ResourceVersionStore] implementations,
+// optionally saving the versions to a file on every change.
+type resourceVersions struct {
+	mu       sync.Mutex
+	versions map[string]int
+	save     func(versions map[string]int) error
+}
+
+func (v *resourceVersions) get(resourceID string) int {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	return v.versions[resourceID]
+}
+
+func (v *resourceVersions) setIfNewer(resourceID string, updatedAt int) (int, error) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	previous, ok := v.versions[resourceID]
+	if updatedAt <= previous {
+		return previous, nil
+	}
+
+	v.versions[resourceID] = updatedAt
+	if err := v.save(v.versions); err != nil {
+		if ok {
+			v.versions[resourceID] = previous
+		} else {
+			delete(v.versions, resourceID)
+		}
+		return previous, err
+	}
+
+	return previous, nil
+}
+
+// MemoryResourceVersionStore is a [ResourceVersionStore] that keeps resource versions in memory.
+//
+// Versions are lost when the process exits, so use a persistent [ResourceVersionStore]
+// such as [FileResourceVersionStore] if they must be kept after a restart.
+type MemoryResourceVersionStore struct{ store resourceVersions }
+
+// NewMemoryResourceVersionStore creates a new [MemoryResourceVersionStore] instance.
+func NewMemoryResourceVersionStore() *MemoryResourceVersionStore {
+	return &MemoryResourceVersionStore{store: resourceVersions{
+		versions: map[string]int{},
+		save:     func(map[string]int) error { return nil },
+	}}
+}
+
+// Get implements [ResourceVersionStore].
+func (s *MemoryResourceVersionStore) Get(_ context.Context, resourceID string) (int, error) {
+	return s.store.get(resourceID), nil
+}
+
+// SetIfNewer implements [ResourceVersionStore].
+func (s *MemoryResourceVersionStore) SetIfNewer(_ context.Context, resourceID string, updatedAt int) (int, error) {
+	return s.store.setIfNewer(resourceID, updatedAt)
+}
+
+// FileResourceVersionStore is a [ResourceVersionStore] that keeps resource versions
+// in a JSON file, so they are kept across restarts.
+//
+// The file is rewritten on every change, and must not be shared between processes.
+type FileResourceVersionStore struct{ store resourceVersions }
+
+// NewFileResourceVersionStore creates a new [FileResourceVersionStore] instance that keeps
+// resource versions in the file at the given path. The file is created if it doesn't exist.
+func NewFileResourceVersionStore(path string) (*FileResourceVersionStore, error) {
+	versions := map[string]int{}
+
+	data, err := os.ReadFile(path)
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+	case err != nil:
+		return nil, fmt.Errorf("could not read resource version file: %w", err)
+	default:
+		if err := json.Unmarshal(data, &versions); err != nil {
+			return nil, fmt.Errorf("could not decode resource version file: %w", err)
+		}
+		// A file containing null decodes to a nil map
+		if versions == nil {
+			versions = map[string]int{}
+		}
+	}
+
+	return &FileResourceVersionStore{store: resourceVersions{
+		versions: versions,
+		save: func(versions map[string]int) error {
+			return writeFileAtomic(path, versions)
+		},
+	}}, nil
+}
+
+// Get implements [ResourceVersionStore].
+func (s *FileResourceVersionStore) Get(_ context.Context, resourceID string) (int, error) {
+	return s.store.get(resourceID), nil
+}
+
+// SetIfNewer implements [ResourceVersionStore].
+func (s *FileResourceVersionStore) SetIfNewer(_ context.Context, resourceID string, updatedAt int) (int, error) {
+	return s.store.setIfNewer(resourceID, updatedAt)
+}
@@ -0,0 +1,112 @@
+package payrex
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestResourceVersionTracker(t *testing.T) {
+	ctx := context.Background()
+	tracker := NewResourceVersionTracker(NewMemoryResourceVersionStore())
+
+	check := func(updatedAt int, want EventFreshness) {
+		t.Helper()
+
+		got, err := tracker.CheckResource(ctx, &Resource{ID: "bstm_123", UpdatedAt: updatedAt})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != want {
+			t.Errorf("got freshness '%s' for version %d, want '%s'", got, updatedAt, want)
+		}
+	}
+	applied := func(updatedAt int) {
+		t.Helper()
+
+		if err := tracker.AppliedResource(ctx, &Resource{ID: "bstm_123", UpdatedAt: updatedAt}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	check(200, EventFreshnessNewer)
+	// Not applied, e.g. because the handler failed, so the retried event is still newer
+	check(200, EventFreshnessNewer)
+
+	applied(200)
+	check(100, EventFreshnessStale)
+	check(200, EventFreshnessRetrieve)
+	check(300, EventFreshnessNewer)
+
+	// Applying an older version doesn't go back
+	applied(100)
+	check(150, EventFreshnessStale)
+}
+
+func TestFileResourceVersionStore(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "versions.json")
+
+	store, err := NewFileResourceVersionStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.SetIfNewer(ctx, "bstm_123", 200); err != nil {
+		t.Fatal(err)
+	}
+
+	reopened, err := NewFileResourceVersionStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	version, err := reopened.Get(ctx, "bstm_123")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if version != 200 {
+		t.Errorf("got version %d after reopening the store, want 200", version)
+	}
+}
+
+func TestFileResourceVersionStoreNullFile(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "versions.json")
+	if err := os.WriteFile(path, []byte("null"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	store, err := NewFileResourceVersionStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.SetIfNewer(ctx, "bstm_123", 200); err != nil {
+		t.Fatal(err)
+	}
+
+	reopened, err := NewFileResourceVersionStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if version, _ := reopened.Get(ctx, "bstm_123"); version != 200 {
+		t.Errorf("got version %d after reopening the store, want 200", version)
+	}
+}
+
+func TestFileResourceVersionStoreSaveError(t *testing.T) {
+	ctx := context.Background()
+	// The store file can't be written in a missing directory
+	store, err := NewFileResourceVersionStore(filepath.Join(t.TempDir(), "missing", "versions.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.SetIfNewer(ctx, "bstm_123", 200); err == nil {
+		t.Fatal("got no error, want an error saving the store file")
+	}
+	if version, _ := store.Get(ctx, "bstm_123"); version != 0 {
+		t.Errorf("got version %d after a failed save, want 0", version)
+	}
+	if _, ok := store.store.versions["bstm_123"]; ok {
+		t.Error("got the version kept after a failed save, want it removed")
+	}
+}
@@ -0,0 +1,246 @@
+package payrex
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"sync"
+	"time"
+)
+
+// Default settings of an [EventProcessor].
+const (
+	// DefaultEventProcessorWorkers is the default number of events processed concurrently.
+	DefaultEventProcessorWorkers = 4
+	// DefaultEventProcessorMaxAttempts is the default number of times processing an event
+	// is attempted before it's moved to the dead-letter store.
+	DefaultEventProcessorMaxAttempts = 5
+)
+
+// DefaultEventBackoff returns the delay before retrying an event that failed
+// processing for the given number of attempts: 1 second after the first attempt,
+// doubling after every attempt up to 1 hour, with up to 10% random jitter.
+func DefaultEventBackoff(attempts int) time.Duration {
+	const maxDelay = time.Hour
+
+	delay := time.Second << min(max(attempts-1, 0), 12)
+	delay = min(delay, maxDelay)
+
+	return delay + time.Duration(rand.Int64N(int64(delay)/10+1))
+}
+
+// EventProcessor processes events asynchronously with a bounded pool of workers,
+// so webhook requests can be acknowledged before their events are processed.
+//
+// Events are added to an [EventQueue] using [EventProcessor.Enqueue], and processed by
+// the handler once [EventProcessor.Run] is called. Failed events are retried with a backoff,
+// and events that fail all attempts are moved to the [DeadLetterStore], from which they
+// can be replayed using [EventProcessor.Replay]. Errors wrapped with [NoRetry] move the
+// event to the dead-letter store without retrying.
+//
+// Example of enqueueing every verified event of a [WebhookRouter]:
+//
+//	dispatcher := payrex.NewWebhookRouter(nil)
+//	payrex.HandleEventResource(dispatcher, payrex.EventTypePaymentIntentSucceeded, fulfillOrder)
+//
+//	processor := payrex.NewEventProcessor(payrex.NewMemoryEventQueue(), dispatcher.Dispatch)
+//	go processor.Run(ctx)
+//
+//	router := payrex.NewWebhookRouter(payrex.NewWebhookVerifier(webhookSecretKey))
+//	router.HandleFallback(processor.Enqueue)
+type EventProcessor struct {
+	queue        EventQueue
+	deadLetters  DeadLetterStore
+	handler      EventHandler
+	workers      int
+	maxAttempts  int
+	backoff      func(attempts int) time.Duration
+	errorHandler func(queuedEvent *QueuedEvent, err error)
+}
+
+// NewEventProcessor creates a new [EventProcessor] instance
+// that processes the events of the queue with the handler.
+func NewEventProcessor(queue EventQueue, handler EventHandler) *EventProcessor {
+	return &EventProcessor{
+		queue:        queue,
+		deadLetters:  NewMemoryDeadLetterStore(),
+		handler:      handler,
+		workers:      DefaultEventProcessorWorkers,
+		maxAttempts:  DefaultEventProcessorMaxAttempts,
+		backoff:      DefaultEventBackoff,
+		errorHandler: func(*QueuedEvent, error) {},
+	}
+}
+
+// WithWorkers sets the maximum number of events processed concurrently.
+//
+// Defaults to [DefaultEventProcessorWorkers].
+func (p *EventProcessor) WithWorkers(workers int) *EventProcessor {
+	p.workers = max(workers, 1)
+	return p
+}
+
+// WithMaxAttempts sets the number of times processing an event is attempted
+// before it's moved to the dead-letter store.
+//
+// Defaults to [DefaultEventProcessorMaxAttempts].
+func (p *EventProcessor) WithMaxAttempts(maxAttempts int) *EventProcessor {
+	p.maxAttempts = max(maxAttempts, 1)
+	return p
+}
+
+// WithBackoff sets the function returning the delay before retrying an event
+// that failed processing for the given number of attempts.
+//
+// Defaults to [DefaultEventBackoff].
+func (p *EventProcessor) WithBackoff(backoff func(attempts int) time.Duration) *EventProcessor {
+	p.backoff = backoff
+	return p
+}
+
+// WithDeadLetterStore sets the store for events that failed all processing attempts.
+//
+// Defaults to a [MemoryDeadLetterStore].
+func (p *EventProcessor) WithDeadLetterStore(deadLetters DeadLetterStore) *EventProcessor {
+	p.deadLetters = deadLetters
+	return p
+}
+
+// WithErrorHandler sets a function that is called with every error that occurs
+// while processing an event, such as errors returned from the handler. Useful for logging.
+func (p *EventProcessor) WithErrorHandler(errorHandler func(queuedEvent *QueuedEvent, err error)) *EventProcessor {
+	p.errorHandler = errorHandler
+	return p
+}
+
+// DeadLetters returns the events that failed all processing attempts, from oldest to newest.
+func (p *EventProcessor) DeadLetters(ctx context.Context) ([]*QueuedEvent, error) {
+	return p.deadLetters.List(ctx)
+}
+
+// Enqueue adds the event to the queue to be processed as soon as possible.
+//
+// Enqueue has the signature of an [EventHandler], so it can be registered
+// directly as a handler of a [WebhookRouter].
+func (p *EventProcessor) Enqueue(ctx context.Context, event *Event) error {
+	return p.queue.Enqueue(ctx, &QueuedEvent{
+		Event:       event,
+		AvailableAt: time.Now(),
+	})
+}
+
+// Replay moves the event with the given ID from the dead-letter store
+// back to the queue, to be processed again with its attempts reset.
+func (p *EventProcessor) Replay(ctx context.Context, eventID string) error {
+	queuedEvents, err := p.deadLetters.List(ctx)
+	if err != nil {
+		return fmt.Errorf("could not list dead letters: %w", err)
+	}
+
+	for _, queuedEvent := range queuedEvents {
+		if queuedEvent.Event.ID != eventID {
+			continue
+		}
+
+		if err := p.Enqueue(ctx, queuedEvent.Event); err != nil {
+			return fmt.Errorf("could not enqueue event '%s': %w", eventID, err)
+		}
+
+		return p.deadLetters.Remove(ctx, eventID)
+	}
+
+	return fmt.Errorf("%w: '%s'", ErrQueuedEventNotFound, eventID)
+}
+
+// Run processes the events of the queue until the context is done or dequeueing
+// an event fails, then waits for the events being processed to finish.
+// Returns the error of dequeueing, if any.
+//
+// Events are processed with a context that is not canceled when the context passed to Run is done.
+func (p *EventProcessor) Run(ctx context.Context) error {
+	runCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var wg sync.WaitGroup
+	errs := make(chan error, p.workers)
+
+	for range p.workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			for {
+				queuedEvent, err := p.queue.Dequeue(runCtx)
+				if runCtx.Err() != nil {
+					return
+				}
+				if err != nil {
+					errs <- fmt.Errorf("could not dequeue event: %w", err)
+					cancel()
+					return
+				}
+
+				p.process(context.WithoutCancel(ctx), queuedEvent)
+			}
+		}()
+	}
+
+	wg.Wait()
+	close(errs)
+
+	return errors.Join(collect(errs)...)
+}
+
+// process runs the handler for the event, then acknowledges, retries,
+// or moves the event to the dead-letter store depending on the result.
+func (p *EventProcessor) process(ctx context.Context, queuedEvent *QueuedEvent) {
+	queuedEvent.Attempts++
+
+	err := p.handle(ctx, queuedEvent.Event)
+	if err == nil {
+		if err := p.queue.Ack(ctx, queuedEvent.Event.ID); err != nil {
+			p.errorHandler(queuedEvent, fmt.Errorf("could not acknowledge event: %w", err))
+		}
+		return
+	}
+
+	queuedEvent.LastError = err.Error()
+	p.errorHandler(queuedEvent, fmt.Errorf("could not process event '%s' (attempt %d): %w",
+		queuedEvent.Event.ID, queuedEvent.Attempts, err))
+
+	if queuedEvent.Attempts < p.maxAttempts && !isAcknowledged(err) {
+		queuedEvent.AvailableAt = time.Now().Add(p.backoff(queuedEvent.Attempts))
+		if err := p.queue.Nack(ctx, queuedEvent); err != nil {
+			p.errorHandler(queuedEvent, fmt.Errorf("could not requeue event: %w", err))
+		}
+		return
+	}
+
+	if err := p.deadLetters.Put(ctx, queuedEvent); err != nil {
+		p.errorHandler(queuedEvent, fmt.Errorf("could not move event to dead letters: %w", err))
+		return
+	}
+	if err := p.queue.Ack(ctx, queuedEvent.Event.ID); err != nil {
+		p.errorHandler(queuedEvent, fmt.Errorf("could not acknowledge event: %w", err))
+	}
+}
+
+// handle runs the handler, recovering from panics.
+func (p *EventProcessor) handle(ctx context.Context, event *Event) (err error) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			err = fmt.Errorf("event handler panicked: %v", recovered)
+		}
+	}()
+
+	return p.handler(ctx, event)
+}
+
+func collect[T any](ch <-chan T) []T {
+	var values []T
+	for v := range ch {
+		values = append(values, v)
+	}
+	return values
+}
@@ -0,0 +1,69 @@
+package payrex
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// failingEventQueue is an [EventQueue] whose first Dequeue call fails,
+// with all other calls blocking until the context is done.
+type failingEventQueue struct {
+	*MemoryEventQueue
+	err   error
+	calls atomic.Int32
+}
+
+func (q *failingEventQueue) Dequeue(ctx context.Context) (*QueuedEvent, error) {
+	if q.calls.Add(1) == 1 {
+		return nil, q.err
+	}
+	return q.MemoryEventQueue.Dequeue(ctx)
+}
+
+func TestEventProcessorRunDequeueError(t *testing.T) {
+	queue := &failingEventQueue{MemoryEventQueue: NewMemoryEventQueue(), err: errors.New("queue unavailable")}
+	processor := NewEventProcessor(queue, func(context.Context, *Event) error { return nil }).
+		WithWorkers(4)
+
+	done := make(chan error, 1)
+	go func() { done <- processor.Run(context.Background()) }()
+
+	select {
+	case err := <-done:
+		if !errors.Is(err, queue.err) {
+			t.Errorf("got error %v, want %v", err, queue.err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Run did not return after Dequeue failed")
+	}
+}
+
+func TestEventProcessorRetriesFailedEvent(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var attempts atomic.Int32
+	processed := make(chan struct{})
+	processor := NewEventProcessor(NewMemoryEventQueue(), func(context.Context, *Event) error {
+		if attempts.Add(1) < 3 {
+			return errors.New("temporary failure")
+		}
+		close(processed)
+		return nil
+	}).WithBackoff(func(int) time.Duration { return 0 })
+
+	go processor.Run(ctx)
+
+	if err := processor.Enqueue(ctx, &Event{Resource: Resource{ID: "evt_1"}}); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case <-processed:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("event was not processed after %d attempts", attempts.Load())
+	}
+}
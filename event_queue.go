@@ -0,0 +1,495 @@
+package payrex
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"slices"
+	"sync"
+	"time"
+)
+
+var ErrQueuedEventNotFound = errors.New("queued event not found")
+
+// QueuedEvent is an [Event] waiting in an [EventQueue] or a [DeadLetterStore],
+// along with the state of its processing attempts.
+type QueuedEvent struct {
+	// The queued event.
+	Event *Event `json:"event"`
+	// The number of times processing the event has been attempted.
+	Attempts int `json:"attempts"`
+	// The earliest time the event can be processed.
+	AvailableAt time.Time `json:"available_at"`
+	// The error of the last processing attempt, if any.
+	LastError string `json:"last_error,omitempty"`
+}
+
+// EventQueue is a queue of events waiting to be processed by an [EventProcessor].
+//
+// Implementations must be safe for concurrent use.
+type EventQueue interface {
+	// Enqueue adds the event to the queue, replacing any queued event with the same ID.
+	// If the event is being processed, it's queued once processing is done.
+	Enqueue(ctx context.Context, queuedEvent *QueuedEvent) error
+	// Dequeue returns the next event available for processing, blocking until
+	// an event is available or the context is done. The event stays in the queue
+	// but is not returned again until it's acknowledged or released with Nack.
+	Dequeue(ctx context.Context) (*QueuedEvent, error)
+	// Ack removes a dequeued event from the queue after it has been processed.
+	// If the event was enqueued again while being processed, that event is queued.
+	Ack(ctx context.Context, eventID string) error
+	// Nack releases a dequeued event that failed processing, replacing it with the given
+	// event to be processed again once available. If the event was enqueued again
+	// while being processed, that event is queued instead.
+	Nack(ctx context.Context, queuedEvent *QueuedEvent) error
+}
+
+// DeadLetterStore keeps events that could not be processed after all retries,
+// so they can be inspected and replayed.
+//
+// Implementations must be safe for concurrent use.
+type DeadLetterStore interface {
+	// Put adds the event to the store, replacing any event with the same ID.
+	Put(ctx context.Context, queuedEvent *QueuedEvent) error
+	// List returns all events in the store, from oldest to newest.
+	List(ctx context.Context) ([]*QueuedEvent, error)
+	// Remove removes the event with the given ID from the store.
+	// Returns [ErrQueuedEventNotFound] if there is no such event.
+	Remove(ctx context.Context, eventID string) error
+}
+
+// queuedEvents is an ordered set of queued events keyed by event ID.
+type queuedEvents struct {
+	items  []*QueuedEvent
+	leased map[string]bool
+	// pending holds the events enqueued while an event with the same ID was leased,
+	// which are queued once the lease is released.
+	pending map[string]*QueuedEvent
+}
+
+func newQueuedEvents() queuedEvents {
+	return queuedEvents{leased: map[string]bool{}, pending: map[string]*QueuedEvent{}}
+}
+
+// clone returns a copy of the events that isn't changed by changes to the events.
+func (q *queuedEvents) clone() queuedEvents {
+	return queuedEvents{
+		items:   slices.Clone(q.items),
+		leased:  maps.Clone(q.leased),
+		pending: maps.Clone(q.pending),
+	}
+}
+
+func (q *queuedEvents) indexOf(eventID string) int {
+	return slices.IndexFunc(q.items, func(item *QueuedEvent) bool {
+		return item.Event.ID == eventID
+	})
+}
+
+func (q *queuedEvents) put(queuedEvent *QueuedEvent) {
+	item := *queuedEvent
+	if q.leased[item.Event.ID] {
+		q.pending[item.Event.ID] = &item
+		return
+	}
+
+	q.replace(&item)
+}
+
+// replace adds the item, replacing any item with the same ID.
+func (q *queuedEvents) replace(item *QueuedEvent) {
+	if i := q.indexOf(item.Event.ID); i >= 0 {
+		q.items[i] = item
+		return
+	}
+	q.items = append(q.items, item)
+}
+
+func (q *queuedEvents) remove(eventID string) bool {
+	i := q.indexOf(eventID)
+	if i < 0 {
+		return false
+	}
+	q.items = slices.Delete(q.items, i, i+1)
+	q.release(eventID)
+	return true
+}
+
+// release releases the lease of the event with the given ID,
+// then queues the event enqueued while it was leased, if any.
+func (q *queuedEvents) release(eventID string) {
+	delete(q.leased, eventID)
+
+	if pending, ok := q.pending[eventID]; ok {
+		delete(q.pending, eventID)
+		q.replace(pending)
+	}
+}
+
+// nack releases the lease of the item, replacing it with the given item
+// unless another event with the same ID was enqueued while it was leased.
+func (q *queuedEvents) nack(queuedEvent *QueuedEvent) bool {
+	eventID := queuedEvent.Event.ID
+	if !q.leased[eventID] || q.indexOf(eventID) < 0 {
+		return false
+	}
+
+	item := *queuedEvent
+	q.replace(&item)
+	q.release(eventID)
+	return true
+}
+
+// next leases and returns the unleased event that became available the earliest,
+// if any is available at the given time. Otherwise, it returns the time the next
+// unleased event becomes available, or the zero time if there is none.
+func (q *queuedEvents) next(now time.Time) (*QueuedEvent, time.Time) {
+	var earliest *QueuedEvent
+	for _, item := range q.items {
+		if q.leased[item.Event.ID] {
+			continue
+		}
+		if earliest == nil || item.AvailableAt.Before(earliest.AvailableAt) {
+			earliest = item
+		}
+	}
+
+	if earliest == nil {
+		return nil, time.Time{}
+	}
+	if earliest.AvailableAt.After(now) {
+		return nil, earliest.AvailableAt
+	}
+
+	q.leased[earliest.Event.ID] = true
+	item := *earliest
+	return &item, time.Time{}
+}
+
+func (q *queuedEvents) list() []*QueuedEvent {
+	items := make([]*QueuedEvent, len(q.items))
+	for i, item := range q.items {
+		copied := *item
+		items[i] = &copied
+	}
+	return items
+}
+
+// eventQueue is the base of the [EventQueue] implementations,
+// optionally saving the queue to a file on every change.
+type eventQueue struct {
+	mu     sync.Mutex
+	events queuedEvents
+	// changed is closed and replaced whenever an event is enqueued,
+	// to wake up all waiting Dequeue calls.
+	changed chan struct{}
+	now     func() time.Time
+	save    func(items []*QueuedEvent) error
+}
+
+func newEventQueue() eventQueue {
+	return eventQueue{
+		events:  newQueuedEvents(),
+		changed: make(chan struct{}),
+		now:     time.Now,
+		save:    func([]*QueuedEvent) error { return nil },
+	}
+}
+
+func (q *eventQueue) enqueue(queuedEvent *QueuedEvent) error {
+	if queuedEvent.Event == nil {
+		return errors.New("queued event has no event")
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	return q.updateLocked(func() bool {
+		q.events.put(queuedEvent)
+		return true
+	})
+}
+
+func (q *eventQueue) dequeue(ctx context.Context) (*QueuedEvent, error) {
+	for {
+		q.mu.Lock()
+		queuedEvent, nextAvailableAt := q.events.next(q.now())
+		changed := q.changed
+		q.mu.Unlock()
+
+		if queuedEvent != nil {
+			return queuedEvent, nil
+		}
+
+		var timer *time.Timer
+		var timerC <-chan time.Time
+		if !nextAvailableAt.IsZero() {
+			timer = time.NewTimer(nextAvailableAt.Sub(q.now()))
+			timerC = timer.C
+		}
+
+		select {
+		case <-ctx.Done():
+			stopTimer(timer)
+			return nil, ctx.Err()
+		case <-changed:
+		case <-timerC:
+		}
+		stopTimer(timer)
+	}
+}
+
+func stopTimer(timer *time.Timer) {
+	if timer != nil {
+		timer.Stop()
+	}
+}
+
+func (q *eventQueue) ack(eventID string) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	return q.updateLocked(func() bool { return q.events.remove(eventID) })
+}
+
+func (q *eventQueue) nack(queuedEvent *QueuedEvent) error {
+	if queuedEvent.Event == nil {
+		return errors.New("queued event has no event")
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	return q.updateLocked(func() bool { return q.events.nack(queuedEvent) })
+}
+
+// updateLocked changes the events, saves the queue and wakes up all waiting Dequeue calls.
+// Returns [ErrQueuedEventNotFound] if the change reports that the event wasn't found.
+//
+// If the queue can't be saved, the events are restored as they were before the change,
+// so they always match what's saved. The mutex must be held.
+func (q *eventQueue) updateLocked(change func() bool) error {
+	previous := q.events.clone()
+
+	if !change() {
+		return ErrQueuedEventNotFound
+	}
+	if err := q.save(q.events.items); err != nil {
+		q.events = previous
+		return err
+	}
+
+	close(q.changed)
+	q.changed = make(chan struct{})
+
+	return nil
+}
+
+// MemoryEventQueue is an [EventQueue] that keeps events in memory.
+//
+// Queued events are lost when the process exits, so use a persistent [EventQueue]
+// such as [FileEventQueue] if events must be processed after a restart.
+type MemoryEventQueue struct{ queue eventQueue }
+
+// NewMemoryEventQueue creates a new [MemoryEventQueue] instance.
+func NewMemoryEventQueue() *MemoryEventQueue {
+	return &MemoryEventQueue{queue: newEventQueue()}
+}
+
+// Enqueue implements [EventQueue].
+func (q *MemoryEventQueue) Enqueue(_ context.Context, queuedEvent *QueuedEvent) error {
+	return q.queue.enqueue(queuedEvent)
+}
+
+// Dequeue implements [EventQueue].
+func (q *MemoryEventQueue) Dequeue(ctx context.Context) (*QueuedEvent, error) {
+	return q.queue.dequeue(ctx)
+}
+
+// Ack implements [EventQueue].
+func (q *MemoryEventQueue) Ack(_ context.Context, eventID string) error {
+	return q.queue.ack(eventID)
+}
+
+// Nack implements [EventQueue].
+func (q *MemoryEventQueue) Nack(_ context.Context, queuedEvent *QueuedEvent) error {
+	return q.queue.nack(queuedEvent)
+}
+
+// FileEventQueue is an [EventQueue] that keeps events in a JSON file,
+// so events still in the queue are processed after a restart.
+//
+// Events that were being processed when the process exited are processed again.
+// The file is rewritten on every change, and must not be shared between processes.
+type FileEventQueue struct{ queue eventQueue }
+
+// NewFileEventQueue creates a new [FileEventQueue] instance that keeps events
+// in the file at the given path. The file is created if it doesn't exist.
+func NewFileEventQueue(path string) (*FileEventQueue, error) {
+	items, err := readQueuedEvents(path)
+	if err != nil {
+		return nil, err
+	}
+
+	q := &FileEventQueue{queue: newEventQueue()}
+	q.queue.events.items = items
+	q.queue.save = func(items []*QueuedEvent) error {
+		return writeFileAtomic(path, items)
+	}
+
+	return q, nil
+}
+
+// Enqueue implements [EventQueue].
+func (q *FileEventQueue) Enqueue(_ context.Context, queuedEvent *QueuedEvent) error {
+	return q.queue.enqueue(queuedEvent)
+}
+
+// Dequeue implements [EventQueue].
+func (q *FileEventQueue) Dequeue(ctx context.Context) (*QueuedEvent, error) {
+	return q.queue.dequeue(ctx)
+}
+
+// Ack implements [EventQueue].
+func (q *FileEventQueue) Ack(_ context.Context, eventID string) error {
+	return q.queue.ack(eventID)
+}
+
+// Nack implements [EventQueue].
+func (q *FileEventQueue) Nack(_ context.Context, queuedEvent *QueuedEvent) error {
+	return q.queue.nack(queuedEvent)
+}
+
+// readQueuedEvents reads the queued events saved in a JSON file,
+// returning no events if the file doesn't exist.
+func readQueuedEvents(path string) ([]*QueuedEvent, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("could not read queue file: %w", err)
+	}
+
+	var items []*QueuedEvent
+	if err := json.Unmarshal(data, &items); err != nil {
+		return nil, fmt.Errorf("could not decode queue file: %w", err)
+	}
+
+	return items, nil
+}
+
+// deadLetters is the base of the [DeadLetterStore] implementations,
+// optionally saving the events to a file on every change.
+type deadLetters struct {
+	mu     sync.Mutex
+	events queuedEvents
+	save   func(items []*QueuedEvent) error
+}
+
+func (d *deadLetters) put(queuedEvent *QueuedEvent) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	previous := d.events.clone()
+
+	d.events.put(queuedEvent)
+	if err := d.save(d.events.items); err != nil {
+		d.events = previous
+		return err
+	}
+
+	return nil
+}
+
+func (d *deadLetters) list() []*QueuedEvent {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	return d.events.list()
+}
+
+func (d *deadLetters) remove(eventID string) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	previous := d.events.clone()
+
+	if !d.events.remove(eventID) {
+		return ErrQueuedEventNotFound
+	}
+	if err := d.save(d.events.items); err != nil {
+		d.events = previous
+		return err
+	}
+
+	return nil
+}
+
+// MemoryDeadLetterStore is a [DeadLetterStore] that keeps events in memory.
+type MemoryDeadLetterStore struct{ store deadLetters }
+
+// NewMemoryDeadLetterStore creates a new [MemoryDeadLetterStore] instance.
+func NewMemoryDeadLetterStore() *MemoryDeadLetterStore {
+	return &MemoryDeadLetterStore{store: deadLetters{
+		events: newQueuedEvents(),
+		save:   func([]*QueuedEvent) error { return nil },
+	}}
+}
+
+// Put implements [DeadLetterStore].
+func (s *MemoryDeadLetterStore) Put(_ context.Context, queuedEvent *QueuedEvent) error {
+	return s.store.put(queuedEvent)
+}
+
+// List implements [DeadLetterStore].
+func (s *MemoryDeadLetterStore) List(_ context.Context) ([]*QueuedEvent, error) {
+	return s.store.list(), nil
+}
+
+// Remove implements [DeadLetterStore].
+func (s *MemoryDeadLetterStore) Remove(_ context.Context, eventID string) error {
+	return s.store.remove(eventID)
+}
+
+// FileDeadLetterStore is a [DeadLetterStore] that keeps events in a JSON file.
+//
+// The file is rewritten on every change, and must not be shared between processes.
+type FileDeadLetterStore struct{ store deadLetters }
+
+// NewFileDeadLetterStore creates a new [FileDeadLetterStore] instance that keeps events
+// in the file at the given path. The file is created if it doesn't exist.
+func NewFileDeadLetterStore(path string) (*FileDeadLetterStore, error) {
+	items, err := readQueuedEvents(path)
+	if err != nil {
+		return nil, err
+	}
+
+	events := newQueuedEvents()
+	events.items = items
+
+	return &FileDeadLetterStore{store: deadLetters{
+		events: events,
+		save: func(items []*QueuedEvent) error {
+			return writeFileAtomic(path, items)
+		},
+	}}, nil
+}
+
+// Put implements [DeadLetterStore].
+func (s *FileDeadLetterStore) Put(_ context.Context, queuedEvent *QueuedEvent) error {
+	return s.store.put(queuedEvent)
+}
+
+// List implements [DeadLetterStore].
+func (s *FileDeadLetterStore) List(_ context.Context) ([]*QueuedEvent, error) {
+	return s.store.list(), nil
+}
+
+// Remove implements [DeadLetterStore].
+func (s *FileDeadLetterStore) Remove(_ context.Context, eventID string) error {
+	return s.store.remove(eventID)
+}
@@ -0,0 +1,184 @@
+package payrex
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func dequeueWithin(t *testing.T, q EventQueue, timeout time.Duration) (*QueuedEvent, error) {
+	t.Helper()
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	return q.Dequeue(ctx)
+}
+
+func TestEventQueueEnqueueWhileLeased(t *testing.T) {
+	ctx := context.Background()
+	q := NewMemoryEventQueue()
+	event := &Event{Resource: Resource{ID: "evt_1"}}
+
+	if err := q.Enqueue(ctx, &QueuedEvent{Event: event}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := dequeueWithin(t, q, time.Second); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := q.Enqueue(ctx, &QueuedEvent{Event: event}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := dequeueWithin(t, q, 50*time.Millisecond); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("got error %v while the event is leased, want %v", err, context.DeadlineExceeded)
+	}
+
+	if err := q.Ack(ctx, event.ID); err != nil {
+		t.Fatal(err)
+	}
+	queuedEvent, err := dequeueWithin(t, q, time.Second)
+	if err != nil {
+		t.Fatalf("could not dequeue the event enqueued while leased: %v", err)
+	}
+	if queuedEvent.Event.ID != event.ID {
+		t.Errorf("got event %s, want %s", queuedEvent.Event.ID, event.ID)
+	}
+}
+
+func TestEventQueueNack(t *testing.T) {
+	tests := map[string]struct {
+		enqueueWhileLeased bool
+		wantAttempts       int
+	}{
+		"retried":               {wantAttempts: 1},
+		"enqueued while leased": {enqueueWhileLeased: true, wantAttempts: 0},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			q := NewMemoryEventQueue()
+			event := &Event{Resource: Resource{ID: "evt_1"}}
+
+			if err := q.Enqueue(ctx, &QueuedEvent{Event: event}); err != nil {
+				t.Fatal(err)
+			}
+			queuedEvent, err := dequeueWithin(t, q, time.Second)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.enqueueWhileLeased {
+				if err := q.Enqueue(ctx, &QueuedEvent{Event: event}); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			queuedEvent.Attempts++
+			if err := q.Nack(ctx, queuedEvent); err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := dequeueWithin(t, q, time.Second)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.Attempts != tt.wantAttempts {
+				t.Errorf("got %d attempts, want %d", got.Attempts, tt.wantAttempts)
+			}
+		})
+	}
+}
+
+func TestEventQueueNackNotLeased(t *testing.T) {
+	q := NewMemoryEventQueue()
+	event := &Event{Resource: Resource{ID: "evt_1"}}
+
+	err := q.Nack(context.Background(), &QueuedEvent{Event: event})
+	if !errors.Is(err, ErrQueuedEventNotFound) {
+		t.Errorf("got error %v, want %v", err, ErrQueuedEventNotFound)
+	}
+}
+
+func TestEventQueueSaveError(t *testing.T) {
+	ctx := context.Background()
+	saveErr := errors.New("disk full")
+
+	q := NewMemoryEventQueue()
+	failSave := func(fail bool) {
+		q.queue.save = func([]*QueuedEvent) error {
+			if fail {
+				return saveErr
+			}
+			return nil
+		}
+	}
+
+	event := &Event{Resource: Resource{ID: "evt_1"}}
+	if err := q.Enqueue(ctx, &QueuedEvent{Event: event}); err != nil {
+		t.Fatal(err)
+	}
+
+	failSave(true)
+	if err := q.Enqueue(ctx, &QueuedEvent{Event: &Event{Resource: Resource{ID: "evt_2"}}}); !errors.Is(err, saveErr) {
+		t.Fatalf("got error %v from Enqueue, want %v", err, saveErr)
+	}
+	if got := len(q.queue.events.items); got != 1 {
+		t.Errorf("got %d queued events after a failed enqueue, want 1", got)
+	}
+
+	queuedEvent, err := dequeueWithin(t, q, time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := q.Nack(ctx, &QueuedEvent{Event: event, Attempts: 1}); !errors.Is(err, saveErr) {
+		t.Fatalf("got error %v from Nack, want %v", err, saveErr)
+	}
+	if err := q.Ack(ctx, queuedEvent.Event.ID); !errors.Is(err, saveErr) {
+		t.Fatalf("got error %v from Ack, want %v", err, saveErr)
+	}
+
+	// The event is still leased and unchanged, as it was before the failed saves
+	if !q.queue.events.leased[event.ID] {
+		t.Error("got the event released after failed saves, want it still leased")
+	}
+	if got := q.queue.events.items[0].Attempts; got != 0 {
+		t.Errorf("got %d attempts after a failed nack, want 0", got)
+	}
+
+	failSave(false)
+	if err := q.Ack(ctx, event.ID); err != nil {
+		t.Fatal(err)
+	}
+	if got := len(q.queue.events.items); got != 0 {
+		t.Errorf("got %d queued events after acknowledging the event, want 0", got)
+	}
+}
+
+func TestDeadLetterStoreSaveError(t *testing.T) {
+	ctx := context.Background()
+	saveErr := errors.New("disk full")
+
+	s := NewMemoryDeadLetterStore()
+	if err := s.Put(ctx, &QueuedEvent{Event: &Event{Resource: Resource{ID: "evt_1"}}}); err != nil {
+		t.Fatal(err)
+	}
+
+	s.store.save = func([]*QueuedEvent) error { return saveErr }
+	if err := s.Put(ctx, &QueuedEvent{Event: &Event{Resource: Resource{ID: "evt_2"}}}); !errors.Is(err, saveErr) {
+		t.Fatalf("got error %v from Put, want %v", err, saveErr)
+	}
+	if err := s.Remove(ctx, "evt_1"); !errors.Is(err, saveErr) {
+		t.Fatalf("got error %v from Remove, want %v", err, saveErr)
+	}
+
+	events, err := s.List(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 1 || events[0].Event.ID != "evt_1" {
+		t.Errorf("got %d dead letters after failed saves, want only 'evt_1'", len(events))
+	}
+}
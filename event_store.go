@@ -0,0 +1,373 @@
+package payrex
+
+import (
+	"container/list"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/angelofallars/payrex-go/internal/atomicfile"
+)
+
+// DefaultEventInProgressTimeout is the default duration after which an event
+// still marked as in progress in an [EventStore] can be processed again,
+// in case the process handling it crashed.
+const DefaultEventInProgressTimeout = 10 * time.Minute
+
+// EventStatus enumerates the processing statuses of an [Event] recorded in an [EventStore].
+type EventStatus string
+
+const (
+	// The event has not been processed yet.
+	EventStatusNew EventStatus = ""
+	// The event is being processed.
+	EventStatusInProgress EventStatus = "in_progress"
+	// The event has been processed successfully.
+	EventStatusCompleted EventStatus = "completed"
+)
+
+// EventStore records the processing status of events by their ID,
+// so events delivered more than once are only processed once.
+//
+// Implementations must be safe for concurrent use.
+type EventStore interface {
+	// Begin marks the event as in progress if it's new, and returns the status
+	// the event had before the call. Only the caller receiving [EventStatusNew]
+	// should process the event.
+	Begin(ctx context.Context, eventID string) (EventStatus, error)
+	// Complete marks the event as completed.
+	Complete(ctx context.Context, eventID string) error
+	// Release removes the in-progress mark of an event whose processing failed,
+	// so it's processed again when it's redelivered.
+	Release(ctx context.Context, eventID string) error
+}
+
+// ProcessEventOnce runs the handler for the event only if the store has no record
+// of the event being in progress or completed, and reports whether the handler ran.
+//
+// The event is marked as completed if the handler succeeds, and released if the handler fails,
+// so PayRex can redeliver it. Errors wrapped with [NoRetry] also mark the event as completed.
+func ProcessEventOnce(ctx context.Context, store EventStore, event *Event, handler EventHandler) (bool, error) {
+	if event.ID == "" {
+		return true, handler(ctx, event)
+	}
+
+	status, err := store.Begin(ctx, event.ID)
+	if err != nil {
+		return false, fmt.Errorf("could not begin processing event '%s': %w", event.ID, err)
+	}
+	if status != EventStatusNew {
+		return false, nil
+	}
+
+	handlerErr := handler(ctx, event)
+
+	if handlerErr != nil && !isAcknowledged(handlerErr) {
+		if err := store.Release(ctx, event.ID); err != nil {
+			return true, errors.Join(handlerErr, fmt.Errorf("could not release event '%s': %w", event.ID, err))
+		}
+		return true, handlerErr
+	}
+
+	if err := store.Complete(ctx, event.ID); err != nil {
+		return true, errors.Join(handlerErr, fmt.Errorf("could not complete event '%s': %w", event.ID, err))
+	}
+
+	return true, handlerErr
+}
+
+// eventRecord is the processing status of an event in an [EventStore].
+type eventRecord struct {
+	ID        string      `json:"id"`
+	Status    EventStatus `json:"status"`
+	UpdatedAt time.Time   `json:"updated_at"`
+}
+
+// eventRecords is a capacity-bounded set of event records,
+// evicting the least recently updated records first.
+type eventRecords struct {
+	capacity          int
+	inProgressTimeout time.Duration
+	now               func() time.Time
+	order             *list.List
+	elements          map[string]*list.Element
+}
+
+func newEventRecords(capacity int) eventRecords {
+	return eventRecords{
+		capacity:          capacity,
+		inProgressTimeout: DefaultEventInProgressTimeout,
+		now:               time.Now,
+		order:             list.New(),
+		elements:          map[string]*list.Element{},
+	}
+}
+
+func (rs *eventRecords) begin(eventID string) EventStatus {
+	if element, ok := rs.elements[eventID]; ok {
+		record := element.Value.(*eventRecord)
+
+		isStale := record.Status == EventStatusInProgress && rs.inProgressTimeout > 0 &&
+			rs.now().Sub(record.UpdatedAt) >= rs.inProgressTimeout
+		if !isStale {
+			return record.Status
+		}
+	}
+
+	rs.set(eventID, EventStatusInProgress)
+	return EventStatusNew
+}
+
+func (rs *eventRecords) set(eventID string, status EventStatus) {
+	record := &eventRecord{ID: eventID, Status: status, UpdatedAt: rs.now()}
+
+	if element, ok := rs.elements[eventID]; ok {
+		element.Value = record
+		rs.order.MoveToBack(element)
+		return
+	}
+
+	rs.elements[eventID] = rs.order.PushBack(record)
+	rs.trim()
+}
+
+// load adds a record read from storage as the most recently updated record,
+// replacing any record with the same ID.
+func (rs *eventRecords) load(record *eventRecord) {
+	if element, ok := rs.elements[record.ID]; ok {
+		rs.order.Remove(element)
+	}
+
+	rs.elements[record.ID] = rs.order.PushBack(record)
+	rs.trim()
+}
+
+// trim evicts the least recently updated records until the records fit the capacity.
+func (rs *eventRecords) trim() {
+	for rs.capacity > 0 && rs.order.Len() > rs.capacity {
+		oldest := rs.order.Front()
+		rs.order.Remove(oldest)
+		delete(rs.elements, oldest.Value.(*eventRecord).ID)
+	}
+}
+
+// clone returns a copy of the records that isn't changed by changes to the records.
+func (rs *eventRecords) clone() eventRecords {
+	c := *rs
+	c.order = list.New()
+	c.elements = make(map[string]*list.Element, len(rs.elements))
+	for element := rs.order.Front(); element != nil; element = element.Next() {
+		record := element.Value.(*eventRecord)
+		c.elements[record.ID] = c.order.PushBack(record)
+	}
+	return c
+}
+
+func (rs *eventRecords) release(eventID string) {
+	element, ok := rs.elements[eventID]
+	if !ok || element.Value.(*eventRecord).Status != EventStatusInProgress {
+		return
+	}
+
+	rs.order.Remove(element)
+	delete(rs.elements, eventID)
+}
+
+// MemoryEventStore is an [EventStore] that keeps the statuses of the most recent events in memory.
+//
+// Statuses are lost when the process exits, so use a persistent [EventStore]
+// such as [FileEventStore] if events must not be processed again after a restart.
+type MemoryEventStore struct {
+	mu      sync.Mutex
+	records eventRecords
+}
+
+// NewMemoryEventStore creates a new [MemoryEventStore] instance that remembers
+// up to 'capacity' events, forgetting the least recently updated events first.
+//
+// A capacity of zero or less remembers every event.
+func NewMemoryEventStore(capacity int) *MemoryEventStore {
+	return &MemoryEventStore{records: newEventRecords(capacity)}
+}
+
+// WithInProgressTimeout sets the duration after which an event still marked
+// as in progress can be processed again. A timeout of zero or less disables it.
+//
+// Defaults to [DefaultEventInProgressTimeout].
+func (s *MemoryEventStore) WithInProgressTimeout(timeout time.Duration) *MemoryEventStore {
+	s.records.inProgressTimeout = timeout
+	return s
+}
+
+// WithClock replaces the function used to get the current time.
+func (s *MemoryEventStore) WithClock(now func() time.Time) *MemoryEventStore {
+	s.records.now = now
+	return s
+}
+
+// Begin implements [EventStore].
+func (s *MemoryEventStore) Begin(_ context.Context, eventID string) (EventStatus, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.records.begin(eventID), nil
+}
+
+// Complete implements [EventStore].
+func (s *MemoryEventStore) Complete(_ context.Context, eventID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.records.set(eventID, EventStatusCompleted)
+	return nil
+}
+
+// Release implements [EventStore].
+func (s *MemoryEventStore) Release(_ context.Context, eventID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.records.release(eventID)
+	return nil
+}
+
+// FileEventStore is an [EventStore] that keeps the statuses of the most recent events
+// in a JSON file, so they are kept across restarts.
+//
+// The file is rewritten on every status change, so the store is meant for
+// single-process deployments with moderate webhook traffic. The file must not
+// be shared between processes.
+type FileEventStore struct {
+	mu      sync.Mutex
+	path    string
+	records eventRecords
+}
+
+// NewFileEventStore creates a new [FileEventStore] instance that remembers up to
+// 'capacity' events in the file at the given path, forgetting the least recently
+// updated events first. The file is created if it doesn't exist.
+//
+// A capacity of zero or less remembers every event. If the file has more events
+// than the capacity, such as after lowering it, the oldest events are forgotten.
+func NewFileEventStore(path string, capacity int) (*FileEventStore, error) {
+	s := &FileEventStore{
+		path:    path,
+		records: newEventRecords(capacity),
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("could not read event store file: %w", err)
+	}
+
+	var records []eventRecord
+	if err := json.Unmarshal(data, &records); err != nil {
+		return nil, fmt.Errorf("could not decode event store file: %w", err)
+	}
+
+	// The file may have been written with a larger capacity
+	for _, record := range records {
+		s.records.load(&record)
+	}
+
+	return s, nil
+}
+
+// WithInProgressTimeout sets the duration after which an event still marked
+// as in progress can be processed again. A timeout of zero or less disables it.
+//
+// Defaults to [DefaultEventInProgressTimeout].
+func (s *FileEventStore) WithInProgressTimeout(timeout time.Duration) *FileEventStore {
+	s.records.inProgressTimeout = timeout
+	return s
+}
+
+// WithClock replaces the function used to get the current time.
+func (s *FileEventStore) WithClock(now func() time.Time) *FileEventStore {
+	s.records.now = now
+	return s
+}
+
+// Begin implements [EventStore].
+func (s *FileEventStore) Begin(_ context.Context, eventID string) (EventStatus, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var status EventStatus
+	err := s.update(func() bool {
+		status = s.records.begin(eventID)
+		return status == EventStatusNew
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return status, nil
+}
+
+// Complete implements [EventStore].
+func (s *FileEventStore) Complete(_ context.Context, eventID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.update(func() bool {
+		s.records.set(eventID, EventStatusCompleted)
+		return true
+	})
+}
+
+// Release implements [EventStore].
+func (s *FileEventStore) Release(_ context.Context, eventID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.update(func() bool {
+		s.records.release(eventID)
+		return true
+	})
+}
+
+// update changes the records and saves them if the change reports that they changed.
+// If the records can't be saved, they're restored as they were before the change,
+// including records evicted by it, so they always match the store file.
+func (s *FileEventStore) update(change func() bool) error {
+	previous := s.records.clone()
+
+	if !change() {
+		return nil
+	}
+	if err := s.save(); err != nil {
+		s.records = previous
+		return err
+	}
+
+	return nil
+}
+
+// save atomically replaces the store file with the current records.
+func (s *FileEventStore) save() error {
+	records := make([]*eventRecord, 0, s.records.order.Len())
+	for element := s.records.order.Front(); element != nil; element = element.Next() {
+		records = append(records, element.Value.(*eventRecord))
+	}
+
+	return writeFileAtomic(s.path, records)
+}
+
+// writeFileAtomic writes the JSON encoding of v to a temporary file,
+// then renames it to the path so readers never see a partially written file.
+func writeFileAtomic(path string, v any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("could not encode file: %w", err)
+	}
+
+	return atomicfile.Write(path, data)
+}
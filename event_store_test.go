@@ -0,0 +1,177 @@
+package payrex
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestFileEventStoreTrimsOnLoad(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "events.json")
+
+	store, err := NewFileEventStore(path, 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := range 5 {
+		eventID := fmt.Sprintf("evt_%d", i)
+		if _, err := store.Begin(ctx, eventID); err != nil {
+			t.Fatal(err)
+		}
+		if err := store.Complete(ctx, eventID); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	reopened, err := NewFileEventStore(path, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := reopened.records.order.Len(); got != 2 {
+		t.Errorf("got %d records after loading, want 2", got)
+	}
+
+	for i := range 5 {
+		eventID := fmt.Sprintf("evt_%d", i)
+		want := i >= 3
+		if _, ok := reopened.records.elements[eventID]; ok != want {
+			t.Errorf("got event '%s' remembered: %t, want %t", eventID, ok, want)
+		}
+	}
+
+	status, err := reopened.Begin(ctx, "evt_4")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status != EventStatusCompleted {
+		t.Errorf("got status '%s' for the most recent event, want '%s'", status, EventStatusCompleted)
+	}
+}
+
+func TestFileEventStoreSaveError(t *testing.T) {
+	ctx := context.Background()
+	dir := filepath.Join(t.TempDir(), "store")
+	if err := os.Mkdir(dir, 0o700); err != nil {
+		t.Fatal(err)
+	}
+
+	store, err := NewFileEventStore(filepath.Join(dir, "events.json"), 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.Begin(ctx, "evt_1"); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Complete(ctx, "evt_1"); err != nil {
+		t.Fatal(err)
+	}
+
+	// The store file can't be written once its directory is gone
+	if err := os.RemoveAll(dir); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := store.Begin(ctx, "evt_2"); err == nil {
+		t.Fatal("got no error from Begin, want an error saving the store file")
+	}
+	if err := store.Release(ctx, "evt_1"); err == nil {
+		t.Fatal("got no error from Release, want an error saving the store file")
+	}
+
+	// The event evicted by the failed Begin is still remembered
+	if element, ok := store.records.elements["evt_1"]; !ok || element.Value.(*eventRecord).Status != EventStatusCompleted {
+		t.Error("got event 'evt_1' forgotten after a failed save, want it still completed")
+	}
+	if _, ok := store.records.elements["evt_2"]; ok {
+		t.Error("got event 'evt_2' remembered after a failed save, want it forgotten")
+	}
+}
+
+func TestProcessEventOnce(t *testing.T) {
+	handlerErr := errors.New("handler failed")
+
+	tests := map[string]struct {
+		err         error
+		wantRetried bool
+	}{
+		"success":      {},
+		"error":        {err: handlerErr, wantRetried: true},
+		"no retry":     {err: NoRetry(handlerErr)},
+		"server error": {err: WithStatus(503, handlerErr), wantRetried: true},
+		"accepted":     {err: WithStatus(202, handlerErr)},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			store := NewMemoryEventStore(0)
+			event := &Event{Resource: Resource{ID: "evt_123"}}
+
+			calls := 0
+			handler := func(context.Context, *Event) error {
+				calls++
+				return tt.err
+			}
+
+			ran, err := ProcessEventOnce(ctx, store, event, handler)
+			if !ran {
+				t.Error("got handler not run for a new event, want it run")
+			}
+			if !errors.Is(err, tt.err) {
+				t.Errorf("got error %v, want %v", err, tt.err)
+			}
+
+			// The event is redelivered
+			ran, _ = ProcessEventOnce(ctx, store, event, handler)
+			if ran != tt.wantRetried {
+				t.Errorf("got handler run for a redelivered event: %t, want %t", ran, tt.wantRetried)
+			}
+			wantCalls := 1
+			if tt.wantRetried {
+				wantCalls = 2
+			}
+			if calls != wantCalls {
+				t.Errorf("got handler run %d times, want %d", calls, wantCalls)
+			}
+		})
+	}
+}
+
+func TestEventStoreInProgressTimeout(t *testing.T) {
+	ctx := context.Background()
+	now := time.Unix(1_700_000_000, 0)
+	store := NewMemoryEventStore(0).
+		WithInProgressTimeout(time.Minute).
+		WithClock(func() time.Time { return now })
+
+	begin := func(want EventStatus) {
+		t.Helper()
+
+		status, err := store.Begin(ctx, "evt_123")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if status != want {
+			t.Errorf("got status '%s', want '%s'", status, want)
+		}
+	}
+
+	begin(EventStatusNew)
+	now = now.Add(59 * time.Second)
+	begin(EventStatusInProgress)
+
+	// The process handling the event is assumed to have crashed
+	now = now.Add(time.Second)
+	begin(EventStatusNew)
+
+	if err := store.Complete(ctx, "evt_123"); err != nil {
+		t.Fatal(err)
+	}
+	now = now.Add(time.Hour)
+	begin(EventStatusCompleted)
+}
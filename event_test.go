@@ -0,0 +1,98 @@
+package payrex
+
+import (
+	"bytes"
+	"encoding/json"
+	"sync"
+	"testing"
+)
+
+func TestEventJSONRoundTrip(t *testing.T) {
+	event := decodeGolden[Event](t, "event")
+	event.Tenant = "acme"
+	event.Extra = ExtraFields{"request_id": json.RawMessage(`"req_123"`)}
+
+	data, err := json.Marshal(event)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var decoded Event
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatal(err)
+	}
+
+	if decoded.ID != event.ID || decoded.Type != event.Type || decoded.ResourceType != event.ResourceType {
+		t.Errorf("got event '%s' of type '%s' with resource type '%s', want event '%s' of type '%s' with resource type '%s'",
+			decoded.ID, decoded.Type, decoded.ResourceType, event.ID, event.Type, event.ResourceType)
+	}
+	if decoded.Tenant != "acme" {
+		t.Errorf("got tenant '%s', want 'acme'", decoded.Tenant)
+	}
+	var requestID string
+	if ok, err := decoded.Extra.Get("request_id", &requestID); !ok || err != nil || requestID != "req_123" {
+		t.Errorf("got extra field 'request_id' %q (found: %t, error: %v), want 'req_123'", requestID, ok, err)
+	}
+
+	reencoded, err := json.Marshal(&decoded)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(reencoded, data) {
+		t.Errorf("event encoded to\n%s\nafter a round trip, want\n%s", reencoded, data)
+	}
+}
+
+func TestEventDataIsCached(t *testing.T) {
+	event := decodeGolden[Event](t, "event")
+
+	first, err := EventData[PaymentIntent](event)
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := event.PaymentIntent()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first != second {
+		t.Error("got a newly decoded payment intent, want the cached one")
+	}
+
+	raw, err := EventData[map[string]any](event)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if (*raw)["id"] != first.ID {
+		t.Errorf("got id %v from the raw data, want '%s'", (*raw)["id"], first.ID)
+	}
+
+	event.Data = json.RawMessage(`{"resource":"payment_intent","id":"pi_replaced"}`)
+	replaced, err := EventData[PaymentIntent](event)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if replaced.ID != "pi_replaced" {
+		t.Errorf("got payment intent '%s' after replacing the data, want 'pi_replaced'", replaced.ID)
+	}
+}
+
+func TestEventDataConcurrent(t *testing.T) {
+	event := decodeGolden[Event](t, "event")
+
+	var wg sync.WaitGroup
+	results := make([]*PaymentIntent, 8)
+	for i := range results {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i], _ = EventData[PaymentIntent](event)
+		}()
+	}
+	wg.Wait()
+
+	for i, result := range results {
+		if result == nil || result != results[0] {
+			t.Errorf("got payment intent %p from call %d, want %p", result, i, results[0])
+		}
+	}
+}
@@ -0,0 +1,161 @@
+package payrex
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+var ErrUnknownEventType = errors.New("unknown event type")
+
+// EventTypeAll is the event type pattern that matches every event type.
+const EventTypeAll EventType = "*"
+
+// eventTypes lists all known event types, in the order they are documented by PayRex.
+var eventTypes = []EventType{
+	EventTypeBillingStatementCreated,
+	EventTypeBillingStatementUpdated,
+	EventTypeBillingStatementDeleted,
+	EventTypeBillingStatementFinalized,
+	EventTypeBillingStatementSent,
+	EventTypeBillingStatementMarkedUncollectible,
+	EventTypeBillingStatementVoided,
+	EventTypeBillingStatementPaid,
+	EventTypeBillingStatementWillBeDue,
+	EventTypeBillingStatementOverdue,
+	EventTypeBillingStatementLineItemCreated,
+	EventTypeBillingStatementLineItemUpdated,
+	EventTypeBillingStatementLineItemDeleted,
+	EventTypeCheckoutSessionExpired,
+	EventTypePaymentIntentAwaitingCapture,
+	EventTypePaymentIntentSucceeded,
+	EventTypePayoutDeposited,
+	EventTypeRefundCreated,
+	EventTypeRefundUpdated,
+}
+
+// EventTypes returns all known event types.
+func EventTypes() []EventType {
+	return slices.Clone(eventTypes)
+}
+
+// ParseEventType parses an event type string such as "payment_intent.succeeded",
+// returning [ErrUnknownEventType] if it's not a known event type.
+//
+// Wildcard patterns are also accepted: "*" for every event type, and
+// "<resource>.*" for every event type of a known resource, e.g. "billing_statement.*".
+func ParseEventType(s string) (EventType, error) {
+	eventType := EventType(strings.TrimSpace(s))
+
+	if eventType.Valid() {
+		return eventType, nil
+	}
+
+	if eventType.IsPattern() {
+		if _, err := ExpandEventTypes(eventType); err == nil {
+			return eventType, nil
+		}
+	}
+
+	return "", fmt.Errorf("%w: '%s'", ErrUnknownEventType, s)
+}
+
+// Valid reports whether the event type is a known event type. Patterns are not valid event types.
+func (t EventType) Valid() bool {
+	return slices.Contains(eventTypes, t)
+}
+
+// ResourceType returns the type of the resource of events of this type,
+// e.g. [EventResourceTypeBillingStatement] for "billing_statement.paid".
+//
+// Returns an empty string for [EventTypeAll].
+func (t EventType) ResourceType() EventResourceType {
+	resourceType, _ := t.split()
+	return resourceType
+}
+
+// Action returns the action part of the event type,
+// e.g. "paid" for "billing_statement.paid", or "*" for "billing_statement.*".
+func (t EventType) Action() string {
+	_, action := t.split()
+	return action
+}
+
+// IsPattern reports whether the event type is a wildcard pattern,
+// i.e. [EventTypeAll] or "<resource>.*".
+func (t EventType) IsPattern() bool {
+	return t == EventTypeAll || t.Action() == "*"
+}
+
+// Match reports whether the event type matches the other event type.
+//
+// A wildcard pattern matches every event type it covers;
+// any other event type only matches itself.
+func (t EventType) Match(eventType EventType) bool {
+	switch {
+	case t == EventTypeAll:
+		return true
+	case t.IsPattern():
+		return t.ResourceType() == eventType.ResourceType()
+	default:
+		return t == eventType
+	}
+}
+
+// split splits the event type into its resource type and action.
+func (t EventType) split() (EventResourceType, string) {
+	if t == EventTypeAll {
+		return "", "*"
+	}
+
+	i := strings.LastIndexByte(string(t), '.')
+	if i < 0 {
+		return "", ""
+	}
+	return EventResourceType(t[:i]), string(t[i+1:])
+}
+
+// ExpandEventTypes replaces the wildcard patterns in the event types with the
+// known event types they match, removing duplicates.
+//
+// Other event types are kept as they are, even if they are not known event types,
+// so event types added by PayRex after this version of the SDK can still be used.
+// Returns [ErrUnknownEventType] if a pattern matches no known event type.
+//
+// [ServiceWebhooks.Create], [ServiceWebhooks.Update] and [ServiceWebhooks.Reconcile]
+// already expand patterns, so they can be used directly in their event types:
+//
+//	webhook, err := payrexClient.Webhooks.Create(&payrex.WebhookCreateParams{
+//		URL:    "https://example.com/webhooks/payrex",
+//		Events: []payrex.EventType{"billing_statement.*", payrex.EventTypePaymentIntentSucceeded},
+//	})
+func ExpandEventTypes(patterns ...EventType) ([]EventType, error) {
+	expanded := make([]EventType, 0, len(patterns))
+
+	for _, eventType := range patterns {
+		if !eventType.IsPattern() {
+			if !slices.Contains(expanded, eventType) {
+				expanded = append(expanded, eventType)
+			}
+			continue
+		}
+
+		matched := false
+		for _, known := range eventTypes {
+			if !eventType.Match(known) {
+				continue
+			}
+			matched = true
+			if !slices.Contains(expanded, known) {
+				expanded = append(expanded, known)
+			}
+		}
+
+		if !matched {
+			return nil, fmt.Errorf("%w: '%s'", ErrUnknownEventType, eventType)
+		}
+	}
+
+	return expanded, nil
+}
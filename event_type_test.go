@@ -0,0 +1,52 @@
+package payrex
+
+import (
+	"errors"
+	"slices"
+	"testing"
+)
+
+func TestExpandEventTypes(t *testing.T) {
+	tests := map[string]struct {
+		patterns []EventType
+		want     []EventType
+		wantErr  error
+	}{
+		"event types": {
+			patterns: []EventType{EventTypeRefundUpdated, EventTypePayoutDeposited, EventTypeRefundUpdated},
+			want:     []EventType{EventTypeRefundUpdated, EventTypePayoutDeposited},
+		},
+		"pattern": {
+			patterns: []EventType{EventTypePaymentIntentSucceeded, "refund.*", EventTypeRefundCreated},
+			want:     []EventType{EventTypePaymentIntentSucceeded, EventTypeRefundCreated, EventTypeRefundUpdated},
+		},
+		"all event types": {
+			patterns: []EventType{EventTypeAll},
+			want:     eventTypes,
+		},
+		"unknown pattern": {
+			patterns: []EventType{EventTypePaymentIntentSucceeded, "foo.*"},
+			wantErr:  ErrUnknownEventType,
+		},
+		"unknown pattern of known resource type prefix": {
+			patterns: []EventType{"payment.*"},
+			wantErr:  ErrUnknownEventType,
+		},
+		"unknown event type": {
+			patterns: []EventType{"payment_intent.created", "refund.*", "payment_intent.created"},
+			want:     []EventType{"payment_intent.created", EventTypeRefundCreated, EventTypeRefundUpdated},
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, err := ExpandEventTypes(tt.patterns...)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("got error %v, want %v", err, tt.wantErr)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
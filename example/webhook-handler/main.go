@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"net/http"
@@ -11,44 +12,58 @@ import (
 
 var webhookSecretKey = os.Getenv("PAYREX_WEBHOOK_SECRET")
 
-func handleWebhook(w http.ResponseWriter, r *http.Request) {
-	event, err := payrex.ParseEvent(r, webhookSecretKey)
-	if err != nil {
-		w.WriteHeader(http.StatusUnauthorized)
-		log.Println(err)
-		return
+func main() {
+	if webhookSecretKey == "" {
+		log.Fatal("PAYREX_WEBHOOK_SECRET not set")
 	}
 
-	switch event.ResourceType {
-	case payrex.EventResourceTypeBillingStatement:
-		billingStatement := event.MustBillingStatement()
-		fmt.Printf("%+v\n", billingStatement)
+	router := payrex.NewWebhookRouter(payrex.NewWebhookVerifier(webhookSecretKey)).
+		WithErrorHandler(func(r *http.Request, err error) {
+			log.Println(err)
+		})
 
-	case payrex.EventResourceTypeCheckoutSession:
-		checkoutSession := event.MustCheckoutSession()
-		fmt.Printf("%+v\n", checkoutSession)
+	payrex.HandleEventResource(router, payrex.EventTypeBillingStatementPaid,
+		func(ctx context.Context, event *payrex.Event, billingStatement *payrex.BillingStatement) error {
+			fmt.Printf("%+v\n", billingStatement)
+			return nil
+		},
+	)
 
-	case payrex.EventResourceTypePaymentIntent:
-		paymentIntent := event.MustPaymentIntent()
-		fmt.Printf("%+v\n", paymentIntent)
+	payrex.HandleEventResource(router, payrex.EventTypeCheckoutSessionExpired,
+		func(ctx context.Context, event *payrex.Event, checkoutSession *payrex.CheckoutSession) error {
+			fmt.Printf("%+v\n", checkoutSession)
+			return nil
+		},
+	)
 
-	case payrex.EventResourceTypePayout:
-		payout := event.MustPayout()
-		fmt.Printf("%+v\n", payout)
+	payrex.HandleEventResource(router, payrex.EventTypePaymentIntentSucceeded,
+		func(ctx context.Context, event *payrex.Event, paymentIntent *payrex.PaymentIntent) error {
+			fmt.Printf("%+v\n", paymentIntent)
+			return nil
+		},
+	)
 
-	case payrex.EventResourceTypeRefund:
-		refund := event.MustRefund()
-		fmt.Printf("%+v\n", refund)
-	}
-}
+	payrex.HandleEventResource(router, payrex.EventTypePayoutDeposited,
+		func(ctx context.Context, event *payrex.Event, payout *payrex.Payout) error {
+			fmt.Printf("%+v\n", payout)
+			return nil
+		},
+	)
 
-func main() {
-	if webhookSecretKey == "" {
-		log.Fatal("PAYREX_WEBHOOK_SECRET not set")
-	}
+	payrex.HandleEventResource(router, payrex.EventTypeRefundCreated,
+		func(ctx context.Context, event *payrex.Event, refund *payrex.Refund) error {
+			fmt.Printf("%+v\n", refund)
+			return nil
+		},
+	)
+
+	router.HandleFallback(func(ctx context.Context, event *payrex.Event) error {
+		fmt.Printf("unhandled event of type '%s'\n", event.Type)
+		return nil
+	})
 
 	mux := http.NewServeMux()
-	mux.HandleFunc("/", handleWebhook)
+	mux.Handle("/", router)
 
 	server := http.Server{
 		Addr:    ":2003",
@@ -0,0 +1,257 @@
+package payrex
+
+import (
+	"bytes"
+	"encoding/json"
+	"maps"
+	"reflect"
+	"slices"
+)
+
+// ExtraFields are the fields of a resource not modeled by this library yet, by their JSON names.
+// Resources keep them as raw JSON in their Extra field, and encode them back when marshaled,
+// so they survive storing resources as JSON.
+//
+// Only the top-level fields of resources are kept. Unknown fields of nested objects,
+// such as [Billing] or [PaymentMethodOptions], are dropped.
+//
+//	var setupFutureUsage string
+//	ok, err := paymentIntent.Extra.Get("setup_future_usage", &setupFutureUsage)
+//
+// Likewise, params structs have an Extra field to send form parameters not modeled by this library yet:
+//
+//	paymentIntent, err := payrexClient.PaymentIntents.Create(&payrex.PaymentIntentCreateParams{
+//		Amount:         100_00,
+//		Currency:       payrex.CurrencyPHP,
+//		PaymentMethods: []payrex.PaymentMethod{payrex.PaymentMethodCard},
+//		Extra:          url.Values{"setup_future_usage": {"off_session"}},
+//	})
+//
+// Extra form parameters under the key of a modeled field, such as "amount" or "metadata[...]",
+// are not sent; set the modeled field instead.
+type ExtraFields map[string]json.RawMessage
+
+// Get decodes the extra field with the key into v, and reports whether the field exists.
+func (e ExtraFields) Get(key string, v any) (bool, error) {
+	data, ok := e[key]
+	if !ok {
+		return false, nil
+	}
+
+	if err := json.Unmarshal(data, v); err != nil {
+		return true, err
+	}
+
+	return true, nil
+}
+
+// unmarshalWithExtra decodes the JSON object into v, and the fields
+// of the object not mapped to any field of T into extra.
+func unmarshalWithExtra[T any](data []byte, v *T, extra *ExtraFields) error {
+	if err := json.Unmarshal(data, v); err != nil {
+		return err
+	}
+
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return err
+	}
+
+	known := jsonFields(reflect.TypeFor[T]())
+	*extra = nil
+	for key, value := range fields {
+		if _, ok := known[key]; ok || slices.Contains(ignoredFields, key) {
+			continue
+		}
+
+		if *extra == nil {
+			*extra = ExtraFields{}
+		}
+		(*extra)[key] = value
+	}
+
+	return nil
+}
+
+// marshalWithExtra encodes v as a JSON object along with the extra fields,
+// in order of their keys. Extra fields mapped to a field of T are skipped.
+func marshalWithExtra[T any](v T, extra ExtraFields) ([]byte, error) {
+	data, err := json.Marshal(v)
+	if err != nil || len(extra) == 0 {
+		return data, err
+	}
+
+	known := jsonFields(reflect.TypeFor[T]())
+
+	var buf bytes.Buffer
+	buf.Write(bytes.TrimSuffix(data, []byte("}")))
+	for _, key := range slices.Sorted(maps.Keys(extra)) {
+		if _, ok := known[key]; ok {
+			continue
+		}
+
+		if buf.Len() > 1 {
+			buf.WriteByte(',')
+		}
+
+		encodedKey, err := json.Marshal(key)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(encodedKey)
+		buf.WriteByte(':')
+
+		value := extra[key]
+		if len(value) == 0 {
+			value = json.RawMessage("null")
+		}
+		buf.Write(value)
+	}
+	buf.WriteByte('}')
+
+	return buf.Bytes(), nil
+}
+
+// UnmarshalJSON implements [json.Unmarshaler], keeping unknown fields in BillingStatement.Extra.
+func (b *BillingStatement) UnmarshalJSON(data []byte) error {
+	type billingStatementFields BillingStatement
+	return unmarshalWithExtra(data, (*billingStatementFields)(b), &b.Extra)
+}
+
+// MarshalJSON implements [json.Marshaler], encoding BillingStatement.Extra along with the other fields.
+func (b BillingStatement) MarshalJSON() ([]byte, error) {
+	type billingStatementFields BillingStatement
+	return marshalWithExtra(billingStatementFields(b), b.Extra)
+}
+
+// UnmarshalJSON implements [json.Unmarshaler], keeping unknown fields in BillingStatementLineItem.Extra.
+func (b *BillingStatementLineItem) UnmarshalJSON(data []byte) error {
+	type billingStatementLineItemFields BillingStatementLineItem
+	return unmarshalWithExtra(data, (*billingStatementLineItemFields)(b), &b.Extra)
+}
+
+// MarshalJSON implements [json.Marshaler], encoding BillingStatementLineItem.Extra along with the other fields.
+func (b BillingStatementLineItem) MarshalJSON() ([]byte, error) {
+	type billingStatementLineItemFields BillingStatementLineItem
+	return marshalWithExtra(billingStatementLineItemFields(b), b.Extra)
+}
+
+// UnmarshalJSON implements [json.Unmarshaler], keeping unknown fields in CheckoutSession.Extra.
+func (c *CheckoutSession) UnmarshalJSON(data []byte) error {
+	type checkoutSessionFields CheckoutSession
+	return unmarshalWithExtra(data, (*checkoutSessionFields)(c), &c.Extra)
+}
+
+// MarshalJSON implements [json.Marshaler], encoding CheckoutSession.Extra along with the other fields.
+func (c CheckoutSession) MarshalJSON() ([]byte, error) {
+	type checkoutSessionFields CheckoutSession
+	return marshalWithExtra(checkoutSessionFields(c), c.Extra)
+}
+
+// UnmarshalJSON implements [json.Unmarshaler], keeping unknown fields in Customer.Extra.
+func (c *Customer) UnmarshalJSON(data []byte) error {
+	type customerFields Customer
+	return unmarshalWithExtra(data, (*customerFields)(c), &c.Extra)
+}
+
+// MarshalJSON implements [json.Marshaler], encoding Customer.Extra along with the other fields.
+func (c Customer) MarshalJSON() ([]byte, error) {
+	type customerFields Customer
+	return marshalWithExtra(customerFields(c), c.Extra)
+}
+
+// UnmarshalJSON implements [json.Unmarshaler], keeping unknown fields in CustomerSession.Extra.
+func (c *CustomerSession) UnmarshalJSON(data []byte) error {
+	type customerSessionFields CustomerSession
+	return unmarshalWithExtra(data, (*customerSessionFields)(c), &c.Extra)
+}
+
+// MarshalJSON implements [json.Marshaler], encoding CustomerSession.Extra along with the other fields.
+func (c CustomerSession) MarshalJSON() ([]byte, error) {
+	type customerSessionFields CustomerSession
+	return marshalWithExtra(customerSessionFields(c), c.Extra)
+}
+
+// UnmarshalJSON implements [json.Unmarshaler], keeping unknown fields in DeletedResource.Extra.
+func (d *DeletedResource) UnmarshalJSON(data []byte) error {
+	type deletedResourceFields DeletedResource
+	return unmarshalWithExtra(data, (*deletedResourceFields)(d), &d.Extra)
+}
+
+// MarshalJSON implements [json.Marshaler], encoding DeletedResource.Extra along with the other fields.
+func (d DeletedResource) MarshalJSON() ([]byte, error) {
+	type deletedResourceFields DeletedResource
+	return marshalWithExtra(deletedResourceFields(d), d.Extra)
+}
+
+// UnmarshalJSON implements [json.Unmarshaler], keeping unknown fields in Payment.Extra.
+func (p *Payment) UnmarshalJSON(data []byte) error {
+	type paymentFields Payment
+	return unmarshalWithExtra(data, (*paymentFields)(p), &p.Extra)
+}
+
+// MarshalJSON implements [json.Marshaler], encoding Payment.Extra along with the other fields.
+func (p Payment) MarshalJSON() ([]byte, error) {
+	type paymentFields Payment
+	return marshalWithExtra(paymentFields(p), p.Extra)
+}
+
+// UnmarshalJSON implements [json.Unmarshaler], keeping unknown fields in PaymentIntent.Extra.
+func (p *PaymentIntent) UnmarshalJSON(data []byte) error {
+	type paymentIntentFields PaymentIntent
+	return unmarshalWithExtra(data, (*paymentIntentFields)(p), &p.Extra)
+}
+
+// MarshalJSON implements [json.Marshaler], encoding PaymentIntent.Extra along with the other fields.
+func (p PaymentIntent) MarshalJSON() ([]byte, error) {
+	type paymentIntentFields PaymentIntent
+	return marshalWithExtra(paymentIntentFields(p), p.Extra)
+}
+
+// UnmarshalJSON implements [json.Unmarshaler], keeping unknown fields in Payout.Extra.
+func (p *Payout) UnmarshalJSON(data []byte) error {
+	type payoutFields Payout
+	return unmarshalWithExtra(data, (*payoutFields)(p), &p.Extra)
+}
+
+// MarshalJSON implements [json.Marshaler], encoding Payout.Extra along with the other fields.
+func (p Payout) MarshalJSON() ([]byte, error) {
+	type payoutFields Payout
+	return marshalWithExtra(payoutFields(p), p.Extra)
+}
+
+// UnmarshalJSON implements [json.Unmarshaler], keeping unknown fields in PayoutTransaction.Extra.
+func (p *PayoutTransaction) UnmarshalJSON(data []byte) error {
+	type payoutTransactionFields PayoutTransaction
+	return unmarshalWithExtra(data, (*payoutTransactionFields)(p), &p.Extra)
+}
+
+// MarshalJSON implements [json.Marshaler], encoding PayoutTransaction.Extra along with the other fields.
+func (p PayoutTransaction) MarshalJSON() ([]byte, error) {
+	type payoutTransactionFields PayoutTransaction
+	return marshalWithExtra(payoutTransactionFields(p), p.Extra)
+}
+
+// UnmarshalJSON implements [json.Unmarshaler], keeping unknown fields in Refund.Extra.
+func (r *Refund) UnmarshalJSON(data []byte) error {
+	type refundFields Refund
+	return unmarshalWithExtra(data, (*refundFields)(r), &r.Extra)
+}
+
+// MarshalJSON implements [json.Marshaler], encoding Refund.Extra along with the other fields.
+func (r Refund) MarshalJSON() ([]byte, error) {
+	type refundFields Refund
+	return marshalWithExtra(refundFields(r), r.Extra)
+}
+
+// UnmarshalJSON implements [json.Unmarshaler], keeping unknown fields in Webhook.Extra.
+func (w *Webhook) UnmarshalJSON(data []byte) error {
+	type webhookFields Webhook
+	return unmarshalWithExtra(data, (*webhookFields)(w), &w.Extra)
+}
+
+// MarshalJSON implements [json.Marshaler], encoding Webhook.Extra along with the other fields.
+func (w Webhook) MarshalJSON() ([]byte, error) {
+	type webhookFields Webhook
+	return marshalWithExtra(webhookFields(w), w.Extra)
+}
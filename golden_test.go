@@ -0,0 +1,227 @@
+package payrex
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// TestGoldenResources decodes the responses in testdata/golden, taken from the PayRex API
+// reference, and checks that every field maps to a struct field and back.
+func TestGoldenResources(t *testing.T) {
+	testGolden[BillingStatement](t, "billing_statement")
+	testGolden[BillingStatementLineItem](t, "billing_statement_line_item")
+	testGolden[CheckoutSession](t, "checkout_session")
+	testGolden[Customer](t, "customer")
+	testGolden[CustomerSession](t, "customer_session")
+	testGolden[DeletedResource](t, "deleted_resource")
+	testGolden[Error](t, "error")
+	testGolden[Event](t, "event")
+	testGolden[Payment](t, "payment")
+	testGolden[PaymentIntent](t, "payment_intent")
+	testGolden[Payout](t, "payout")
+	testGolden[PayoutTransaction](t, "payout_transaction")
+	testGolden[Refund](t, "refund")
+	testGolden[Webhook](t, "webhook")
+}
+
+func TestGoldenLists(t *testing.T) {
+	testGolden[List[BillingStatement]](t, "list_billing_statements")
+	testGolden[List[CheckoutSession]](t, "list_checkout_sessions")
+	testGolden[List[Customer]](t, "list_customers")
+	testGolden[List[PayoutTransaction]](t, "list_payout_transactions")
+	testGolden[List[Webhook]](t, "list_webhooks")
+}
+
+func TestGoldenEventData(t *testing.T) {
+	event := decodeGolden[Event](t, "event")
+
+	if event.ResourceType != EventResourceTypePaymentIntent {
+		t.Fatalf("got resource type '%s', want '%s'", event.ResourceType, EventResourceTypePaymentIntent)
+	}
+
+	paymentIntent, err := EventData[PaymentIntent](event)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := decodeGolden[PaymentIntent](t, "payment_intent")
+	if !reflect.DeepEqual(paymentIntent, want) {
+		t.Errorf("event data decoded to\n%+v\nwant\n%+v", paymentIntent, want)
+	}
+}
+
+func TestGoldenFieldValues(t *testing.T) {
+	payment := decodeGolden[Payment](t, "payment")
+
+	if payment.Status != PaymentStatusPaid {
+		t.Errorf("got payment status '%s', want '%s'", payment.Status, PaymentStatusPaid)
+	}
+	if payment.Billing.Address.PostalCode != "1226" {
+		t.Errorf("got postal code '%s', want '1226'", payment.Billing.Address.PostalCode)
+	}
+	if payment.Customer == nil || payment.Customer.ID == "" {
+		t.Errorf("got no customer, want the customer of the payment")
+	}
+
+	billingStatement := decodeGolden[BillingStatement](t, "billing_statement")
+
+	if billingStatement.MerchantName == nil || *billingStatement.MerchantName != "Acme Store" {
+		t.Errorf("got merchant name %v, want 'Acme Store'", billingStatement.MerchantName)
+	}
+	if billingStatement.PaymentIntent == nil || billingStatement.PaymentIntent.Status != PaymentIntentStatusSucceeded {
+		t.Errorf("got payment intent %+v, want a succeeded payment intent", billingStatement.PaymentIntent)
+	}
+}
+
+func TestGoldenExtraFields(t *testing.T) {
+	golden := decodeAny(t, readGolden(t, "payment_intent")).(map[string]any)
+	golden["setup_future_usage"] = "off_session"
+
+	data, err := json.Marshal(golden)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var paymentIntent PaymentIntent
+	if err := json.Unmarshal(data, &paymentIntent); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(paymentIntent.Extra) != 1 {
+		t.Errorf("got extra fields %v, want only 'setup_future_usage'", paymentIntent.Extra)
+	}
+
+	var setupFutureUsage string
+	if ok, err := paymentIntent.Extra.Get("setup_future_usage", &setupFutureUsage); !ok || err != nil || setupFutureUsage != "off_session" {
+		t.Errorf("got extra field '%s' (%t, %v), want 'off_session'", setupFutureUsage, ok, err)
+	}
+
+	encoded, err := json.Marshal(paymentIntent)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, diff := range diffJSON("", stripIgnored(golden), stripIgnored(decodeAny(t, encoded))) {
+		t.Error(diff)
+	}
+}
+
+// testGolden checks that the golden file decodes into a T without drift,
+// and that encoding the T gives back the same JSON.
+func testGolden[T any](t *testing.T, name string) {
+	t.Helper()
+
+	t.Run(name, func(t *testing.T) {
+		golden := readGolden(t, name)
+
+		for _, drift := range findDrift(reflect.TypeFor[T](), decodeAny(t, golden), "") {
+			t.Error(drift)
+		}
+
+		resource := decodeGolden[T](t, name)
+		encoded, err := json.Marshal(resource)
+		if err != nil {
+			t.Fatalf("could not encode %s: %v", reflect.TypeFor[T](), err)
+		}
+
+		for _, diff := range diffJSON("", stripIgnored(decodeAny(t, golden)), stripIgnored(decodeAny(t, encoded))) {
+			t.Error(diff)
+		}
+	})
+}
+
+func decodeGolden[T any](t *testing.T, name string) *T {
+	t.Helper()
+
+	var v T
+	if err := json.Unmarshal(readGolden(t, name), &v); err != nil {
+		t.Fatalf("could not decode '%s' into %s: %v", name, reflect.TypeFor[T](), err)
+	}
+	return &v
+}
+
+func readGolden(t *testing.T, name string) []byte {
+	t.Helper()
+
+	data, err := os.ReadFile(filepath.Join("testdata", "golden", name+".json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func decodeAny(t *testing.T, data []byte) any {
+	t.Helper()
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+
+	var v any
+	if err := decoder.Decode(&v); err != nil {
+		t.Fatal(err)
+	}
+	return v
+}
+
+// stripIgnored removes the ignored fields from the objects of the JSON value.
+func stripIgnored(v any) any {
+	switch v := v.(type) {
+	case map[string]any:
+		for _, field := range ignoredFields {
+			delete(v, field)
+		}
+		for key, value := range v {
+			v[key] = stripIgnored(value)
+		}
+	case []any:
+		for i, value := range v {
+			v[i] = stripIgnored(value)
+		}
+	}
+	return v
+}
+
+// diffJSON returns the differences between two decoded JSON values.
+func diffJSON(path string, want, got any) []string {
+	wantObject, wantIsObject := want.(map[string]any)
+	gotObject, gotIsObject := got.(map[string]any)
+	if wantIsObject && gotIsObject {
+		var diffs []string
+		for key, value := range wantObject {
+			if _, ok := gotObject[key]; !ok {
+				diffs = append(diffs, fmt.Sprintf("field '%s' is missing after encoding", strings.TrimPrefix(path+"."+key, ".")))
+				continue
+			}
+			diffs = append(diffs, diffJSON(path+"."+key, value, gotObject[key])...)
+		}
+		for key := range gotObject {
+			if _, ok := wantObject[key]; !ok {
+				diffs = append(diffs, fmt.Sprintf("field '%s' is encoded but missing from the golden file", strings.TrimPrefix(path+"."+key, ".")))
+			}
+		}
+		slices.Sort(diffs)
+		return diffs
+	}
+
+	wantArray, wantIsArray := want.([]any)
+	gotArray, gotIsArray := got.([]any)
+	if wantIsArray && gotIsArray && len(wantArray) == len(gotArray) {
+		var diffs []string
+		for i := range wantArray {
+			diffs = append(diffs, diffJSON(fmt.Sprintf("%s[%d]", path, i), wantArray[i], gotArray[i])...)
+		}
+		return diffs
+	}
+
+	if !reflect.DeepEqual(want, got) {
+		return []string{fmt.Sprintf("field '%s' is %v after encoding, want %v", strings.TrimPrefix(path, "."), got, want)}
+	}
+	return nil
+}
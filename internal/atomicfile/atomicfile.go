@@ -0,0 +1,35 @@
+// Package atomicfile provides functionality to replace files atomically.
+package atomicfile
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// Write writes the data to a temporary file in the same directory as the path,
+// then renames it to the path so readers never see a partially written file.
+func Write(path string, data []byte) error {
+	file, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp*")
+	if err != nil {
+		return fmt.Errorf("could not create file: %w", err)
+	}
+	tmpPath := file.Name()
+
+	if _, err := file.Write(data); err != nil {
+		_ = file.Close()
+		_ = os.Remove(tmpPath)
+		return fmt.Errorf("could not write file: %w", err)
+	}
+	if err := file.Close(); err != nil {
+		_ = os.Remove(tmpPath)
+		return fmt.Errorf("could not write file: %w", err)
+	}
+
+	if err := os.Rename(tmpPath, path); err != nil {
+		_ = os.Remove(tmpPath)
+		return fmt.Errorf("could not replace file: %w", err)
+	}
+
+	return nil
+}
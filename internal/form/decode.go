@@ -0,0 +1,323 @@
+package form
+
+import (
+	"fmt"
+	"net/url"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// DecodeError is returned by [Decode] when a form value
+// could not be decoded into its struct field.
+type DecodeError struct {
+	// The form key of the value, e.g. "line_items[][amount]".
+	Key string
+	// The underlying error.
+	Err error
+}
+
+func (e *DecodeError) Error() string {
+	return fmt.Sprintf("invalid value for '%s': %v", e.Key, e.Err)
+}
+
+func (e *DecodeError) Unwrap() error {
+	return e.Err
+}
+
+// Decode decodes a URL-encoded form into the struct pointed to by params
+// using `form:"<value>"` tags. It's the inverse of [Encode].
+//
+// Slices of structs are split into elements by the order of the form: [Encode] adds
+// the fields of each element in the order of the struct fields, so a field that comes
+// before the previous field in the struct, or repeats it, starts a new element.
+func Decode(query string, params any) error {
+	value := reflect.ValueOf(params)
+	if value.Kind() != reflect.Pointer || value.IsNil() {
+		return fmt.Errorf("expected a non-nil pointer, got %T", params)
+	}
+
+	fields, err := parseFields(query)
+	if err != nil {
+		return err
+	}
+
+	return decodeFromForm(fields, "", value.Elem())
+}
+
+// formField is a key and value of a form, e.g. "line_items[][amount]" and "100".
+type formField struct {
+	key   string
+	value string
+}
+
+// parseFields parses a URL-encoded form into its fields, in order.
+func parseFields(query string) ([]formField, error) {
+	var fields []formField
+
+	for query != "" {
+		var field string
+		field, query, _ = strings.Cut(query, "&")
+		if field == "" {
+			continue
+		}
+
+		key, value, _ := strings.Cut(field, "=")
+		key, err := url.QueryUnescape(key)
+		if err != nil {
+			return nil, fmt.Errorf("invalid form key: %w", err)
+		}
+		value, err = url.QueryUnescape(value)
+		if err != nil {
+			return nil, &DecodeError{Key: key, Err: err}
+		}
+
+		fields = append(fields, formField{key: key, value: value})
+	}
+
+	return fields, nil
+}
+
+func decodeFromForm(fields []formField, key string, value reflect.Value) error {
+	switch value.Kind() {
+	case reflect.Struct:
+		valueType := value.Type()
+		for i := range value.NumField() {
+			field := valueType.Field(i)
+
+			tagKey := field.Tag.Get("form")
+			if tagKey == "" {
+				panic(fmt.Sprintf("'form' tag on struct field '%s.%s' not set",
+					valueType.Name(), field.Name))
+			}
+
+			if tagKey == ExtraTag {
+				if extra := extraValues(fields, key, valueType); len(extra) > 0 {
+					fieldType := value.Field(i).Type()
+					if !reflect.TypeOf(extra).ConvertibleTo(fieldType) {
+						panic(fmt.Sprintf("extra field '%s.%s' must be of type url.Values, not '%s'",
+							valueType.Name(), field.Name, fieldType))
+					}
+					value.Field(i).Set(reflect.ValueOf(extra).Convert(fieldType))
+				}
+				continue
+			}
+
+			fieldKey := tagKey
+			if key != "" {
+				fieldKey = fmt.Sprintf("%s[%s]", key, tagKey)
+			}
+
+			if err := decodeFromForm(fields, fieldKey, value.Field(i)); err != nil {
+				return err
+			}
+		}
+	case reflect.Slice:
+		elemKey := fmt.Sprintf("%s[]", key)
+		if !hasKey(fields, elemKey) {
+			return nil
+		}
+
+		elems := splitElems(fields, elemKey, value.Type().Elem())
+		slice := reflect.MakeSlice(value.Type(), len(elems), len(elems))
+		for i, elemFields := range elems {
+			if err := decodeFromForm(elemFields, elemKey, slice.Index(i)); err != nil {
+				return err
+			}
+		}
+		value.Set(slice)
+	case reflect.Map:
+		if value.Type().Key().Kind() != reflect.String {
+			panic(fmt.Sprintf("unsupported map key type '%s'", value.Type().Key()))
+		}
+
+		m := reflect.MakeMap(value.Type())
+		for _, field := range fields {
+			mapKey, ok := mapKeyOf(key, field.key)
+			if !ok {
+				continue
+			}
+
+			mapKeyValue := reflect.ValueOf(mapKey).Convert(value.Type().Key())
+			if m.MapIndex(mapKeyValue).IsValid() {
+				continue
+			}
+
+			elem := reflect.New(value.Type().Elem()).Elem()
+			elemKey := field.key
+			if key != "" {
+				elemKey = fmt.Sprintf("%s[%s]", key, mapKey)
+			}
+			if err := decodeFromForm(fields, elemKey, elem); err != nil {
+				return err
+			}
+			m.SetMapIndex(mapKeyValue, elem)
+		}
+
+		// An empty value is an empty map
+		if m.Len() > 0 || slices.Contains(fields, formField{key: key}) {
+			value.Set(m)
+		}
+	case reflect.Pointer:
+		// Values not in the form are left as nil
+		if !hasKey(fields, key) {
+			return nil
+		}
+
+		elem := reflect.New(value.Type().Elem())
+		if err := decodeFromForm(fields, key, elem.Elem()); err != nil {
+			return err
+		}
+		value.Set(elem)
+	default:
+		i := slices.IndexFunc(fields, func(field formField) bool { return field.key == key })
+		if i < 0 {
+			return nil
+		}
+
+		if err := setScalar(value, fields[i].value); err != nil {
+			return &DecodeError{Key: key, Err: err}
+		}
+	}
+
+	return nil
+}
+
+// extraValues returns the form values nested under the key that don't belong
+// to any field of the struct type, keyed relative to the key.
+func extraValues(fields []formField, key string, structType reflect.Type) url.Values {
+	fieldKeys := fieldKeysOf(structType)
+
+	extra := url.Values{}
+	for _, field := range fields {
+		name, ok := mapKeyOf(key, field.key)
+		if !ok || fieldKeys[name] {
+			continue
+		}
+
+		relativeKey := field.key
+		if key != "" {
+			relativeKey = name + strings.TrimPrefix(field.key, fmt.Sprintf("%s[%s]", key, name))
+		}
+		extra.Add(relativeKey, field.value)
+	}
+
+	return extra
+}
+
+// hasKey reports whether the form has a value for the key, or for a key nested under it.
+func hasKey(fields []formField, key string) bool {
+	return slices.ContainsFunc(fields, func(field formField) bool {
+		return field.key == key || strings.HasPrefix(field.key, key+"[")
+	})
+}
+
+// splitElems splits the form fields of the elements of a slice with the given key,
+// e.g. "line_items[]", into the form fields of each element.
+//
+// Elements of struct type start at a field that comes before the previous field
+// in the struct, or at a repeated key not nested in a slice. Other elements have one key each.
+func splitElems(fields []formField, elemKey string, elemType reflect.Type) [][]formField {
+	for elemType.Kind() == reflect.Pointer {
+		elemType = elemType.Elem()
+	}
+
+	var elems [][]formField
+	var seen map[string]bool
+	lastIndex := -1
+
+	for _, field := range fields {
+		if field.key != elemKey && !strings.HasPrefix(field.key, elemKey+"[") {
+			continue
+		}
+
+		newElem := true
+		if elemType.Kind() == reflect.Struct {
+			index := fieldIndex(elemType, elemKey, field.key)
+			repeated := seen[field.key] && !strings.Contains(strings.TrimPrefix(field.key, elemKey), "[]")
+			newElem = len(elems) == 0 || index < lastIndex || repeated
+			lastIndex = index
+		}
+
+		if newElem {
+			elems = append(elems, nil)
+			seen = map[string]bool{}
+		}
+		elems[len(elems)-1] = append(elems[len(elems)-1], field)
+		seen[field.key] = true
+	}
+
+	return elems
+}
+
+// fieldIndex returns the index of the struct field a form key nested under
+// the key of a slice element belongs to. Keys not belonging to any field
+// belong to the extra field, if any, or else come after every field.
+func fieldIndex(structType reflect.Type, elemKey, formKey string) int {
+	name, _ := mapKeyOf(elemKey, formKey)
+
+	extraIndex := structType.NumField()
+	for i := range structType.NumField() {
+		switch structType.Field(i).Tag.Get("form") {
+		case name:
+			return i
+		case ExtraTag:
+			extraIndex = i
+		}
+	}
+
+	return extraIndex
+}
+
+// mapKeyOf returns the map key of a form key nested under the key of a map,
+// e.g. "order_id" for the form key "metadata[order_id]" of the key "metadata".
+func mapKeyOf(key, formKey string) (string, bool) {
+	if key == "" {
+		mapKey, _, _ := strings.Cut(formKey, "[")
+		return mapKey, mapKey != ""
+	}
+
+	rest, ok := strings.CutPrefix(formKey, key+"[")
+	if !ok {
+		return "", false
+	}
+
+	mapKey, _, ok := strings.Cut(rest, "]")
+	return mapKey, ok && mapKey != ""
+}
+
+func setScalar(value reflect.Value, s string) error {
+	switch value.Kind() {
+	case reflect.String:
+		value.SetString(s)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return err
+		}
+		value.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(s, 10, value.Type().Bits())
+		if err != nil {
+			return err
+		}
+		value.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(s, 10, value.Type().Bits())
+		if err != nil {
+			return err
+		}
+		value.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		n, err := strconv.ParseFloat(s, value.Type().Bits())
+		if err != nil {
+			return err
+		}
+		value.SetFloat(n)
+	default:
+		panic(fmt.Sprintf("unsupported form field type '%s'", value.Type()))
+	}
+
+	return nil
+}
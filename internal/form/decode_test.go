@@ -0,0 +1,108 @@
+package form
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+type testLineItem struct {
+	Name        string  `form:"name"`
+	Amount      int     `form:"amount"`
+	Description *string `form:"description"`
+	Image       *string `form:"image"`
+}
+
+type testParams struct {
+	Amount    int               `form:"amount"`
+	Livemode  *bool             `form:"livemode"`
+	Methods   []string          `form:"payment_methods"`
+	LineItems []testLineItem    `form:"line_items"`
+	Metadata  map[string]string `form:"metadata"`
+	Options   *testOptions      `form:"payment_method_options"`
+}
+
+type testOptions struct {
+	CaptureType string   `form:"capture_type"`
+	AllowedBins []string `form:"allowed_bins"`
+}
+
+func TestDecodeRoundTrip(t *testing.T) {
+	description := "Small"
+	image := "https://example.com/mug.png"
+
+	tests := map[string]testParams{
+		"empty": {},
+		"scalars and maps": {
+			Amount:   100_00,
+			Livemode: new(bool),
+			Methods:  []string{"card", "gcash"},
+			Metadata: map[string]string{"order_id": "ord_123", "tenant_id": "7"},
+		},
+		"nested struct": {
+			Options: &testOptions{CaptureType: "manual", AllowedBins: []string{"123456", "654321"}},
+		},
+		"optional fields of some line items": {
+			LineItems: []testLineItem{
+				{Name: "Mug", Amount: 100_00},
+				{Name: "Shirt", Amount: 200_00, Description: &description},
+				{Name: "Cap", Amount: 300_00, Image: &image},
+				{Name: "Hoodie", Amount: 400_00, Description: &description, Image: &image},
+				{Name: "Sticker", Amount: 5_00},
+			},
+		},
+	}
+
+	for name, want := range tests {
+		t.Run(name, func(t *testing.T) {
+			var got testParams
+			if err := Decode(Encode(want), &got); err != nil {
+				t.Fatal(err)
+			}
+
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("got\n%+v\nwant\n%+v", got, want)
+			}
+		})
+	}
+}
+
+func TestDecodeLineItemsInFormOrder(t *testing.T) {
+	query := "line_items[][name]=Mug&line_items[][amount]=100" +
+		"&line_items[][name]=Shirt&line_items[][amount]=200&line_items[][description]=Small"
+
+	var got testParams
+	if err := Decode(query, &got); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(got.LineItems) != 2 {
+		t.Fatalf("got %d line items, want 2", len(got.LineItems))
+	}
+	if got.LineItems[0].Description != nil {
+		t.Errorf("got description '%s' for the first line item, want none", *got.LineItems[0].Description)
+	}
+	if got.LineItems[1].Description == nil || *got.LineItems[1].Description != "Small" {
+		t.Errorf("got description %v for the second line item, want 'Small'", got.LineItems[1].Description)
+	}
+}
+
+func TestDecodeInvalidValue(t *testing.T) {
+	var params testParams
+	err := Decode("amount=100&line_items[][name]=Mug&line_items[][amount]=lots", &params)
+
+	var decodeErr *DecodeError
+	if !errors.As(err, &decodeErr) {
+		t.Fatalf("got error %v, want a *DecodeError", err)
+	}
+	if decodeErr.Key != "line_items[][amount]" {
+		t.Errorf("got key '%s', want 'line_items[][amount]'", decodeErr.Key)
+	}
+}
+
+func TestDecodeInvalidForm(t *testing.T) {
+	var params testParams
+	if err := Decode("amount=%zz", &params); err == nil {
+		t.Error("got no error, want an error for an invalid escape")
+	}
+}
@@ -3,17 +3,37 @@ package form
 
 import (
 	"fmt"
+	"maps"
 	"net/url"
 	"reflect"
+	"slices"
+	"strings"
 )
 
+// ExtraTag is the 'form' tag of a struct field of type [url.Values] holding extra form values,
+// which are added to the form as is instead of under the field's key.
+//
+// Extra values whose key is the key of another field of the struct, or is nested under it,
+// are left out so they can't duplicate modeled fields; set the field itself instead.
+const ExtraTag = ",extra"
+
 // Encode returns the URL-encoded form of a struct value
 // using `form:"<value>"` tags.
+//
+// Fields are encoded in the order of the struct fields, and map entries in the order of their keys,
+// so elements of slices of structs can be told apart when fields are left out of some elements.
 func Encode(params any) string {
-	return parseIntoForm(url.Values{}, "", reflect.ValueOf(params)).Encode()
+	var fields []formField
+	parseIntoForm(&fields, "", reflect.ValueOf(params))
+
+	pairs := make([]string, len(fields))
+	for i, field := range fields {
+		pairs[i] = url.QueryEscape(field.key) + "=" + url.QueryEscape(field.value)
+	}
+	return strings.Join(pairs, "&")
 }
 
-func parseIntoForm(formValues url.Values, key string, value reflect.Value) url.Values {
+func parseIntoForm(fields *[]formField, key string, value reflect.Value) {
 	switch value.Kind() {
 	case reflect.Struct:
 		valueType := value.Type()
@@ -26,43 +46,98 @@ func parseIntoForm(formValues url.Values, key string, value reflect.Value) url.V
 					valueType.Name(), field.Name))
 			}
 
+			if tagKey == ExtraTag {
+				extra := extraField(value, i)
+				fieldKeys := fieldKeysOf(valueType)
+				for _, extraKey := range slices.Sorted(maps.Keys(extra)) {
+					if name, _, _ := strings.Cut(extraKey, "["); fieldKeys[name] {
+						continue
+					}
+					for _, v := range extra[extraKey] {
+						*fields = append(*fields, formField{key: nestKey(key, extraKey), value: v})
+					}
+				}
+				continue
+			}
+
 			fieldKey := tagKey
 			if key != "" {
 				fieldKey = fmt.Sprintf("%s[%s]", key, tagKey)
 			}
 			fieldValue := value.Field(i)
 
-			parseIntoForm(formValues, fieldKey, fieldValue)
+			parseIntoForm(fields, fieldKey, fieldValue)
 		}
 	case reflect.Slice:
 		for i := range value.Len() {
 			elem := value.Index(i)
 			elemKey := fmt.Sprintf("%s[]", key)
 
-			parseIntoForm(formValues, elemKey, elem)
+			parseIntoForm(fields, elemKey, elem)
 		}
 	case reflect.Map:
-		iter := reflect.ValueOf(value).MapRange()
-		for iter.Next() {
-			k, v := iter.Key(), iter.Value()
+		// Empty maps are sent as an empty value, e.g. to remove all metadata of a resource.
+		// Nil maps are left out like nil pointers.
+		if !value.IsNil() && value.Len() == 0 && key != "" {
+			*fields = append(*fields, formField{key: key})
+			return
+		}
+
+		mapKeys := value.MapKeys()
+		slices.SortFunc(mapKeys, func(a, b reflect.Value) int { return strings.Compare(a.String(), b.String()) })
 
+		for _, k := range mapKeys {
 			mapKey := k.String()
 			if key != "" {
 				mapKey = fmt.Sprintf("%s[%s]", key, k.String())
 			}
 
-			parseIntoForm(formValues, mapKey, v)
+			parseIntoForm(fields, mapKey, value.MapIndex(k))
 		}
 	case reflect.Pointer:
 		// Null values will not be added to the form
 		if value.IsZero() {
-			return formValues
+			return
 		}
 
-		parseIntoForm(formValues, key, value.Elem())
+		parseIntoForm(fields, key, value.Elem())
 	default:
-		formValues.Add(key, fmt.Sprintf("%v", value))
+		*fields = append(*fields, formField{key: key, value: fmt.Sprintf("%v", value)})
+	}
+}
+
+// extraField returns the values of the extra field at the index of the struct.
+func extraField(value reflect.Value, i int) url.Values {
+	field := value.Field(i)
+	if !field.Type().ConvertibleTo(reflect.TypeFor[url.Values]()) {
+		panic(fmt.Sprintf("extra field '%s.%s' must be of type url.Values, not '%s'",
+			value.Type().Name(), value.Type().Field(i).Name, field.Type()))
 	}
 
-	return formValues
+	return field.Convert(reflect.TypeFor[url.Values]()).Interface().(url.Values)
+}
+
+// fieldKeysOf returns the form keys of the fields of the struct type, except the extra field.
+func fieldKeysOf(structType reflect.Type) map[string]bool {
+	fieldKeys := map[string]bool{}
+	for i := range structType.NumField() {
+		if tagKey := structType.Field(i).Tag.Get("form"); tagKey != ExtraTag {
+			fieldKeys[tagKey] = true
+		}
+	}
+	return fieldKeys
+}
+
+// nestKey returns the form key nested under the key,
+// e.g. "card[options][type]" for the form key "options[type]" under "card".
+func nestKey(key, formKey string) string {
+	if key == "" {
+		return formKey
+	}
+
+	name, rest, nested := strings.Cut(formKey, "[")
+	if !nested {
+		return fmt.Sprintf("%s[%s]", key, name)
+	}
+	return fmt.Sprintf("%s[%s][%s", key, name, rest)
 }
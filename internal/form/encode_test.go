@@ -0,0 +1,107 @@
+package form
+
+import (
+	"net/url"
+	"reflect"
+	"testing"
+)
+
+type testExtraParams struct {
+	Amount   int               `form:"amount"`
+	Metadata map[string]string `form:"metadata"`
+	Billing  *testExtraBilling `form:"billing"`
+	Extra    url.Values        `form:",extra"`
+}
+
+type testExtraBilling struct {
+	Name  string     `form:"name"`
+	Extra url.Values `form:",extra"`
+}
+
+// testRawExtraParams has an extra field declared without the url.Values type.
+type testRawExtraParams struct {
+	Amount int                 `form:"amount"`
+	Extra  map[string][]string `form:",extra"`
+}
+
+func TestEncodeExtra(t *testing.T) {
+	tests := map[string]struct {
+		params any
+		want   string
+	}{
+		"top-level": {
+			params: testExtraParams{
+				Amount: 100,
+				Extra:  url.Values{"setup_future_usage": {"off_session"}, "options[type]": {"a", "b"}},
+			},
+			want: "amount=100&options%5Btype%5D=a&options%5Btype%5D=b&setup_future_usage=off_session",
+		},
+		"nested": {
+			params: testExtraParams{
+				Amount:  100,
+				Billing: &testExtraBilling{Name: "Juan", Extra: url.Values{"phone": {"0917"}}},
+			},
+			want: "amount=100&billing%5Bname%5D=Juan&billing%5Bphone%5D=0917",
+		},
+		"keys of modeled fields": {
+			params: testExtraParams{
+				Amount:   100,
+				Metadata: map[string]string{"order_id": "1"},
+				Extra: url.Values{
+					"amount":             {"200"},
+					"metadata[order_id]": {"2"},
+					"statement":          {"yes"},
+				},
+			},
+			want: "amount=100&metadata%5Border_id%5D=1&statement=yes",
+		},
+		"map[string][]string": {
+			params: testRawExtraParams{Amount: 100, Extra: map[string][]string{"statement": {"yes"}}},
+			want:   "amount=100&statement=yes",
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			if got := Encode(tt.params); got != tt.want {
+				t.Errorf("got %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDecodeExtra(t *testing.T) {
+	tests := map[string]struct {
+		query string
+		want  any
+	}{
+		"top-level and nested": {
+			query: "amount=100&billing%5Bname%5D=Juan&billing%5Bphone%5D=0917&options%5Btype%5D=a&options%5Btype%5D=b",
+			want: &testExtraParams{
+				Amount:  100,
+				Billing: &testExtraBilling{Name: "Juan", Extra: url.Values{"phone": {"0917"}}},
+				Extra:   url.Values{"options[type]": {"a", "b"}},
+			},
+		},
+		"no extra values": {
+			query: "amount=100&metadata%5Border_id%5D=1",
+			want:  &testExtraParams{Amount: 100, Metadata: map[string]string{"order_id": "1"}},
+		},
+		"map[string][]string": {
+			query: "amount=100&statement=yes",
+			want:  &testRawExtraParams{Amount: 100, Extra: map[string][]string{"statement": {"yes"}}},
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			got := reflect.New(reflect.TypeOf(tt.want).Elem()).Interface()
+			if err := Decode(tt.query, got); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
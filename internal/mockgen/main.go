@@ -0,0 +1,304 @@
+// Command mockgen generates the mocks of the payrexmock package
+// from the service interfaces of the payrex package.
+//
+// Usage:
+//
+//	go run ./internal/mockgen -in service_interfaces.go -out payrexmock/services.go
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"log"
+	"os"
+	"strings"
+	"text/template"
+)
+
+// aggregateInterface is the interface with a method returning each service interface.
+const aggregateInterface = "API"
+
+func main() {
+	in := flag.String("in", "service_interfaces.go", "file declaring the service interfaces")
+	out := flag.String("out", "payrexmock/services.go", "file to write the mocks to")
+	flag.Parse()
+
+	services, err := parseServices(*in)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	src, err := generate(services)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if err := os.WriteFile(*out, src, 0o644); err != nil {
+		log.Fatalf("could not write mocks: %v", err)
+	}
+}
+
+// service is a service interface to mock.
+type service struct {
+	// The name of the interface, e.g. "PaymentIntentsService".
+	Interface string
+	// The name of the service, e.g. "PaymentIntents".
+	Name    string
+	Methods []method
+}
+
+// method is a method of a service interface.
+type method struct {
+	Name    string
+	Params  []param
+	Results []string
+	// The values returned when the method is not stubbed, e.g. "nil, err".
+	NotStubbed string
+}
+
+type param struct {
+	Name string
+	Type string
+}
+
+// Signature returns the parameters and results of the method, e.g. "(id string) (*payrex.Payment, error)".
+func (m method) Signature() string {
+	params := make([]string, len(m.Params))
+	for i, p := range m.Params {
+		params[i] = p.Name + " " + p.Type
+	}
+
+	results := strings.Join(m.Results, ", ")
+	if len(m.Results) > 1 {
+		results = "(" + results + ")"
+	}
+
+	return "(" + strings.Join(params, ", ") + ") " + results
+}
+
+// Args returns the names of the parameters of the method, separated by commas.
+func (m method) Args() string {
+	names := make([]string, len(m.Params))
+	for i, p := range m.Params {
+		names[i] = p.Name
+	}
+	return strings.Join(names, ", ")
+}
+
+// parseServices returns the service interfaces declared in the file, in order.
+func parseServices(path string) ([]service, error) {
+	file, err := parser.ParseFile(token.NewFileSet(), path, nil, 0)
+	if err != nil {
+		return nil, fmt.Errorf("could not parse '%s': %w", path, err)
+	}
+
+	var services []service
+	for _, decl := range file.Decls {
+		genDecl, ok := decl.(*ast.GenDecl)
+		if !ok || genDecl.Tok != token.TYPE {
+			continue
+		}
+
+		for _, spec := range genDecl.Specs {
+			typeSpec := spec.(*ast.TypeSpec)
+			iface, ok := typeSpec.Type.(*ast.InterfaceType)
+			if !ok || typeSpec.Name.Name == aggregateInterface || !strings.HasSuffix(typeSpec.Name.Name, "Service") {
+				continue
+			}
+
+			s := service{
+				Interface: typeSpec.Name.Name,
+				Name:      strings.TrimSuffix(typeSpec.Name.Name, "Service"),
+			}
+			for _, field := range iface.Methods.List {
+				m, err := parseMethod(field)
+				if err != nil {
+					return nil, fmt.Errorf("could not parse '%s': %w", s.Interface, err)
+				}
+				s.Methods = append(s.Methods, m)
+			}
+
+			services = append(services, s)
+		}
+	}
+
+	if len(services) == 0 {
+		return nil, fmt.Errorf("no service interfaces found in '%s'", path)
+	}
+
+	return services, nil
+}
+
+func parseMethod(field *ast.Field) (method, error) {
+	funcType, ok := field.Type.(*ast.FuncType)
+	if !ok || len(field.Names) != 1 {
+		return method{}, fmt.Errorf("embedded interfaces are not supported")
+	}
+
+	m := method{Name: field.Names[0].Name}
+
+	for _, p := range funcType.Params.List {
+		typ := typeString(p.Type)
+		if len(p.Names) == 0 {
+			m.Params = append(m.Params, param{Name: fmt.Sprintf("arg%d", len(m.Params)), Type: typ})
+		}
+		for _, name := range p.Names {
+			m.Params = append(m.Params, param{Name: name.Name, Type: typ})
+		}
+	}
+
+	var notStubbed []string
+	for _, r := range funcType.Results.List {
+		typ := typeString(r.Type)
+		m.Results = append(m.Results, typ)
+
+		switch {
+		case typ == "error":
+			notStubbed = append(notStubbed, "err")
+		case strings.HasPrefix(typ, "*"):
+			notStubbed = append(notStubbed, "nil")
+		case strings.HasPrefix(typ, "iter.Seq2[") && strings.HasSuffix(typ, ", error]"):
+			elem := strings.TrimSuffix(strings.TrimPrefix(typ, "iter.Seq2["), ", error]")
+			notStubbed = append(notStubbed, "errSeq2["+elem+"](err)")
+		default:
+			return method{}, fmt.Errorf("unsupported result type '%s' of method '%s'", typ, m.Name)
+		}
+	}
+	m.NotStubbed = strings.Join(notStubbed, ", ")
+
+	return m, nil
+}
+
+// typeString returns the type as written in the payrexmock package,
+// qualifying the types of the payrex package.
+func typeString(expr ast.Expr) string {
+	switch expr := expr.(type) {
+	case *ast.Ident:
+		if ast.IsExported(expr.Name) {
+			return "payrex." + expr.Name
+		}
+		return expr.Name
+	case *ast.StarExpr:
+		return "*" + typeString(expr.X)
+	case *ast.ArrayType:
+		return "[]" + typeString(expr.Elt)
+	case *ast.MapType:
+		return "map[" + typeString(expr.Key) + "]" + typeString(expr.Value)
+	case *ast.SelectorExpr:
+		return typeString(expr.X) + "." + expr.Sel.Name
+	case *ast.IndexExpr:
+		return typeString(expr.X) + "[" + typeString(expr.Index) + "]"
+	case *ast.IndexListExpr:
+		indices := make([]string, len(expr.Indices))
+		for i, index := range expr.Indices {
+			indices[i] = typeString(index)
+		}
+		return typeString(expr.X) + "[" + strings.Join(indices, ", ") + "]"
+	default:
+		panic(fmt.Sprintf("unsupported type expression %T", expr))
+	}
+}
+
+// generate returns the formatted source of the mocks of the services.
+func generate(services []service) ([]byte, error) {
+	data := struct {
+		Services []service
+		UsesIter bool
+	}{Services: services}
+	for _, s := range services {
+		for _, m := range s.Methods {
+			if strings.Contains(m.Signature(), "iter.") {
+				data.UsesIter = true
+			}
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := mocksTemplate.Execute(&buf, data); err != nil {
+		return nil, fmt.Errorf("could not generate mocks: %w", err)
+	}
+
+	src, err := format.Source(buf.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("could not format mocks: %w\n%s", err, buf.Bytes())
+	}
+
+	return src, nil
+}
+
+var mocksTemplate = template.Must(template.New("mocks").Parse(`// Code generated by internal/mockgen. DO NOT EDIT.
+
+package payrexmock
+
+import (
+{{- if .UsesIter}}
+	"iter"
+{{end}}
+	"github.com/angelofallars/payrex-go"
+)
+
+// Client is a mock of [payrex.API]. Its services are mocks recording their calls
+// both on their own and on the client.
+type Client struct {
+{{- range .Services}}
+	{{.Name}} *{{.Interface}}
+{{- end}}
+
+	calls calls
+}
+
+var _ payrex.API = (*Client)(nil)
+
+// NewClient creates a new [Client] with unstubbed services.
+func NewClient() *Client {
+	c := &Client{}
+{{- range .Services}}
+	c.{{.Name}} = &{{.Interface}}{calls: calls{parent: &c.calls}}
+{{- end}}
+	return c
+}
+
+// Calls returns the calls of the methods of all services so far, from oldest to newest.
+func (c *Client) Calls() []Call {
+	return c.calls.all()
+}
+{{range .Services}}
+// {{.Interface}} returns the [Client.{{.Name}}] mock.
+func (c *Client) {{.Interface}}() payrex.{{.Interface}} {
+	return c.{{.Name}}
+}
+{{end}}
+{{- range $s := .Services}}
+// {{$s.Interface}} is a mock of [payrex.{{$s.Interface}}]. Set the Func fields to stub its methods.
+// Methods without a stub return [ErrNotStubbed].
+type {{$s.Interface}} struct {
+{{- range $s.Methods}}
+	{{.Name}}Func func{{.Signature}}
+{{- end}}
+
+	calls calls
+}
+
+var _ payrex.{{$s.Interface}} = (*{{$s.Interface}})(nil)
+
+// Calls returns the calls of the mock's methods so far, from oldest to newest.
+func (m *{{$s.Interface}}) Calls() []Call {
+	return m.calls.all()
+}
+{{range $s.Methods}}
+// {{.Name}} records the call and calls {{.Name}}Func.
+func (m *{{$s.Interface}}) {{.Name}}{{.Signature}} {
+	m.calls.record("{{$s.Name}}.{{.Name}}"{{if .Params}}, {{.Args}}{{end}})
+	if m.{{.Name}}Func == nil {
+		err := notStubbed("{{$s.Name}}.{{.Name}}")
+		return {{.NotStubbed}}
+	}
+	return m.{{.Name}}Func({{.Args}})
+}
+{{end}}
+{{- end}}`))
@@ -0,0 +1,31 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"testing"
+)
+
+// TestMocksUpToDate checks that the committed mocks were generated from
+// the current service interfaces.
+func TestMocksUpToDate(t *testing.T) {
+	services, err := parseServices("../../service_interfaces.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, err := generate(services)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := os.ReadFile("../../payrexmock/services.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Files may be checked out with CRLF line endings
+	got = bytes.ReplaceAll(got, []byte("\r\n"), []byte("\n"))
+
+	if !bytes.Equal(got, want) {
+		t.Error("got payrexmock/services.go out of date with service_interfaces.go, run 'go generate ./payrexmock'")
+	}
+}
@@ -0,0 +1,413 @@
+package payrex
+
+import (
+	"encoding"
+	"errors"
+	"fmt"
+	"iter"
+	"maps"
+	"reflect"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// Limits on the size of a [Metadata] value enforced by PayRex.
+const (
+	// MetadataMaxKeys is the maximum number of keys a [Metadata] value can contain.
+	MetadataMaxKeys = 50
+	// MetadataMaxKeyLength is the maximum length of a [Metadata] key, in characters.
+	MetadataMaxKeyLength = 40
+	// MetadataMaxValueLength is the maximum length of a [Metadata] value, in characters.
+	MetadataMaxValueLength = 500
+)
+
+var (
+	ErrMetadataEmptyKey      = errors.New("metadata key must not be empty")
+	ErrMetadataKeyTooLong    = fmt.Errorf("metadata key must be at most %d characters", MetadataMaxKeyLength)
+	ErrMetadataValueTooLong  = fmt.Errorf("metadata value must be at most %d characters", MetadataMaxValueLength)
+	ErrMetadataTooManyKeys   = fmt.Errorf("metadata must have at most %d keys", MetadataMaxKeys)
+	ErrMetadataInvalidTarget = errors.New("metadata can only be unmarshaled into a non-nil struct pointer")
+)
+
+// Metadata is a set of key-value pairs that can be attached to a resource.
+//
+// Useful for storing additional information about a resource in a structured format,
+// such as the ID of the order a [PaymentIntent] was created for.
+//
+// A nil Metadata is valid and behaves like an empty one. In Params structs, a nil
+// Metadata is not sent to PayRex, while an empty non-nil Metadata such as Metadata{}
+// is sent as an empty value, removing all metadata of the resource when updating it.
+// Metadata in Params structs is checked with [Metadata.Validate] before being sent.
+//
+// Use [MarshalMetadata] and [Metadata.Unmarshal] to convert between Metadata and
+// your own struct types.
+type Metadata map[string]string
+
+// Get returns the value stored under the given key,
+// and whether the key exists in the metadata.
+func (m Metadata) Get(key string) (string, bool) {
+	value, ok := m[key]
+	return value, ok
+}
+
+// Set stores the value under the given key, replacing any existing value.
+//
+// Returns an error and leaves the metadata unchanged if the key or value
+// exceed the PayRex metadata limits.
+func (m *Metadata) Set(key, value string) error {
+	if err := validateMetadataPair(key, value); err != nil {
+		return err
+	}
+
+	if _, ok := (*m)[key]; !ok && len(*m) >= MetadataMaxKeys {
+		return ErrMetadataTooManyKeys
+	}
+
+	if *m == nil {
+		*m = Metadata{}
+	}
+	(*m)[key] = value
+
+	return nil
+}
+
+// Delete removes the given key from the metadata.
+func (m Metadata) Delete(key string) {
+	delete(m, key)
+}
+
+// Merge copies all key-value pairs of other into the metadata,
+// replacing the values of keys that exist in both.
+//
+// Returns an error and leaves the metadata unchanged if the merged
+// metadata would exceed the PayRex metadata limits.
+func (m *Metadata) Merge(other Metadata) error {
+	merged := maps.Clone(*m)
+	if merged == nil {
+		merged = Metadata{}
+	}
+	maps.Copy(merged, other)
+
+	if err := merged.Validate(); err != nil {
+		return err
+	}
+
+	*m = merged
+	return nil
+}
+
+// Clone returns a copy of the metadata.
+func (m Metadata) Clone() Metadata {
+	return maps.Clone(m)
+}
+
+// Validate checks that the metadata is within the PayRex metadata limits.
+func (m Metadata) Validate() error {
+	if len(m) > MetadataMaxKeys {
+		return ErrMetadataTooManyKeys
+	}
+
+	for key, value := range m {
+		if err := validateMetadataPair(key, value); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+var metadataType = reflect.TypeFor[Metadata]()
+
+// validateParamsMetadata checks that every [Metadata] value in the params
+// is within the PayRex metadata limits.
+func validateParamsMetadata(params any) error {
+	return validateMetadataIn(reflect.ValueOf(params), "params")
+}
+
+func validateMetadataIn(value reflect.Value, key string) error {
+	if !value.IsValid() {
+		return nil
+	}
+
+	if value.Type() == metadataType {
+		if err := value.Interface().(Metadata).Validate(); err != nil {
+			return fmt.Errorf("invalid %s: %w", key, err)
+		}
+		return nil
+	}
+
+	switch value.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		if value.IsNil() {
+			return nil
+		}
+		return validateMetadataIn(value.Elem(), key)
+	case reflect.Struct:
+		valueType := value.Type()
+		for i := range valueType.NumField() {
+			field := valueType.Field(i)
+			if !field.IsExported() {
+				continue
+			}
+
+			fieldKey, _, _ := strings.Cut(field.Tag.Get("form"), ",")
+			if fieldKey == "" {
+				fieldKey = field.Name
+			}
+			if err := validateMetadataIn(value.Field(i), fieldKey); err != nil {
+				return err
+			}
+		}
+	case reflect.Slice, reflect.Array:
+		for i := range value.Len() {
+			if err := validateMetadataIn(value.Index(i), key); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func validateMetadataPair(key, value string) error {
+	if key == "" {
+		return ErrMetadataEmptyKey
+	}
+	if utf8.RuneCountInString(key) > MetadataMaxKeyLength {
+		return fmt.Errorf("%w: '%s'", ErrMetadataKeyTooLong, key)
+	}
+	if utf8.RuneCountInString(value) > MetadataMaxValueLength {
+		return fmt.Errorf("%w: value of key '%s'", ErrMetadataValueTooLong, key)
+	}
+	return nil
+}
+
+// MarshalMetadata converts a struct into [Metadata] using `metadata:"<key>"` struct tags.
+//
+// Supported field types are strings, booleans, integers, floats and types implementing
+// [encoding.TextMarshaler], along with pointers to them. Nil pointers are skipped.
+// Fields without a tag use the field name as the key, and fields tagged with `metadata:"-"` are skipped.
+// Adding the 'omitempty' option skips the field if it has a zero value, e.g. `metadata:"tenant_id,omitempty"`.
+//
+// Example:
+//
+//	type OrderRef struct {
+//		OrderID  string `metadata:"order_id"`
+//		TenantID int    `metadata:"tenant_id,omitempty"`
+//	}
+//
+//	metadata, err := payrex.MarshalMetadata(OrderRef{OrderID: "ord_123", TenantID: 7})
+func MarshalMetadata(v any) (Metadata, error) {
+	value := reflect.ValueOf(v)
+	for value.Kind() == reflect.Pointer {
+		if value.IsNil() {
+			return nil, nil
+		}
+		value = value.Elem()
+	}
+
+	if value.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("cannot marshal '%s' into metadata: expected a struct", value.Type())
+	}
+
+	m := Metadata{}
+	for field, fieldValue := range metadataFields(value, false) {
+		if field.omitEmpty && fieldValue.IsZero() {
+			continue
+		}
+
+		if fieldValue.Kind() == reflect.Pointer {
+			if fieldValue.IsNil() {
+				continue
+			}
+			fieldValue = fieldValue.Elem()
+		}
+
+		s, err := formatMetadataValue(fieldValue)
+		if err != nil {
+			return nil, fmt.Errorf("cannot marshal field '%s' into metadata: %w", field.name, err)
+		}
+
+		if err := m.Set(field.key, s); err != nil {
+			return nil, err
+		}
+	}
+
+	return m, nil
+}
+
+// Unmarshal parses the metadata into the struct pointed to by v,
+// using the same `metadata:"<key>"` struct tags as [MarshalMetadata].
+//
+// Keys in the metadata without a matching struct field are ignored,
+// and struct fields without a matching key are left untouched.
+func (m Metadata) Unmarshal(v any) error {
+	value := reflect.ValueOf(v)
+	if value.Kind() != reflect.Pointer || value.IsNil() || value.Elem().Kind() != reflect.Struct {
+		return ErrMetadataInvalidTarget
+	}
+
+	for field, fieldValue := range metadataFields(value.Elem(), true) {
+		s, ok := m[field.key]
+		if !ok {
+			continue
+		}
+
+		if fieldValue.Kind() == reflect.Pointer {
+			if fieldValue.IsNil() {
+				fieldValue.Set(reflect.New(fieldValue.Type().Elem()))
+			}
+			fieldValue = fieldValue.Elem()
+		}
+
+		if err := parseMetadataValue(s, fieldValue); err != nil {
+			return fmt.Errorf("cannot unmarshal metadata key '%s' into field '%s': %w", field.key, field.name, err)
+		}
+	}
+
+	return nil
+}
+
+// metadataField holds the parsed `metadata` struct tag of a struct field.
+type metadataField struct {
+	name      string
+	key       string
+	omitEmpty bool
+}
+
+// metadataFields iterates over the exported fields of a struct value that map to a metadata key,
+// including the fields of embedded structs without a `metadata` tag.
+//
+// Nil embedded struct pointers are skipped, unless 'allocate' is set and the struct value
+// is settable, in which case they're allocated. Only unmarshalling allocates them,
+// so marshalling never changes the value.
+func metadataFields(value reflect.Value, allocate bool) iter.Seq2[metadataField, reflect.Value] {
+	return func(yield func(metadataField, reflect.Value) bool) {
+		valueType := value.Type()
+		for i := range valueType.NumField() {
+			structField := valueType.Field(i)
+
+			tag := structField.Tag.Get("metadata")
+			if tag == "-" {
+				continue
+			}
+
+			if structField.Anonymous && tag == "" {
+				if embedded, ok := embeddedStruct(value.Field(i), allocate); ok {
+					for field, fieldValue := range metadataFields(embedded, allocate) {
+						if !yield(field, fieldValue) {
+							return
+						}
+					}
+					continue
+				}
+			}
+
+			if !structField.IsExported() {
+				continue
+			}
+
+			key, options, _ := strings.Cut(tag, ",")
+			if key == "" {
+				key = structField.Name
+			}
+
+			field := metadataField{
+				name:      structField.Name,
+				key:       key,
+				omitEmpty: options == "omitempty",
+			}
+
+			if !yield(field, value.Field(i)) {
+				return
+			}
+		}
+	}
+}
+
+// embeddedStruct returns the struct value of an embedded struct field,
+// allocating nil struct pointers if 'allocate' is set and the field is settable.
+func embeddedStruct(value reflect.Value, allocate bool) (reflect.Value, bool) {
+	if value.Kind() == reflect.Pointer {
+		if value.Type().Elem().Kind() != reflect.Struct {
+			return reflect.Value{}, false
+		}
+		if value.IsNil() {
+			if !allocate || !value.CanSet() {
+				return reflect.Value{}, false
+			}
+			value.Set(reflect.New(value.Type().Elem()))
+		}
+		value = value.Elem()
+	}
+
+	return value, value.Kind() == reflect.Struct
+}
+
+var textMarshalerType = reflect.TypeFor[encoding.TextMarshaler]()
+var textUnmarshalerType = reflect.TypeFor[encoding.TextUnmarshaler]()
+
+func formatMetadataValue(value reflect.Value) (string, error) {
+	if value.Type().Implements(textMarshalerType) {
+		text, err := value.Interface().(encoding.TextMarshaler).MarshalText()
+		if err != nil {
+			return "", err
+		}
+		return string(text), nil
+	}
+
+	switch value.Kind() {
+	case reflect.String:
+		return value.String(), nil
+	case reflect.Bool:
+		return strconv.FormatBool(value.Bool()), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(value.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(value.Uint(), 10), nil
+	case reflect.Float32, reflect.Float64:
+		return strconv.FormatFloat(value.Float(), 'f', -1, value.Type().Bits()), nil
+	default:
+		return "", fmt.Errorf("unsupported type '%s'", value.Type())
+	}
+}
+
+func parseMetadataValue(s string, value reflect.Value) error {
+	if reflect.PointerTo(value.Type()).Implements(textUnmarshalerType) {
+		return value.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(s))
+	}
+
+	switch value.Kind() {
+	case reflect.String:
+		value.SetString(s)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return err
+		}
+		value.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(s, 10, value.Type().Bits())
+		if err != nil {
+			return err
+		}
+		value.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(s, 10, value.Type().Bits())
+		if err != nil {
+			return err
+		}
+		value.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(s, value.Type().Bits())
+		if err != nil {
+			return err
+		}
+		value.SetFloat(f)
+	default:
+		return fmt.Errorf("unsupported type '%s'", value.Type())
+	}
+
+	return nil
+}
@@ -0,0 +1,190 @@
+package payrex
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/angelofallars/payrex-go/internal/form"
+)
+
+func TestMetadataLimits(t *testing.T) {
+	tests := map[string]struct {
+		metadata Metadata
+		want     error
+	}{
+		"valid":           {metadata: Metadata{"order_id": "ord_123"}},
+		"empty key":       {metadata: Metadata{"": "ord_123"}, want: ErrMetadataEmptyKey},
+		"key too long":    {metadata: Metadata{strings.Repeat("k", MetadataMaxKeyLength+1): "v"}, want: ErrMetadataKeyTooLong},
+		"value too long":  {metadata: Metadata{"k": strings.Repeat("v", MetadataMaxValueLength+1)}, want: ErrMetadataValueTooLong},
+		"too many keys":   {metadata: metadataWithKeys(MetadataMaxKeys + 1), want: ErrMetadataTooManyKeys},
+		"maximum keys":    {metadata: metadataWithKeys(MetadataMaxKeys)},
+		"multibyte value": {metadata: Metadata{"k": strings.Repeat("₱", MetadataMaxValueLength)}},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			if err := test.metadata.Validate(); !errors.Is(err, test.want) {
+				t.Errorf("got error %v from Validate, want %v", err, test.want)
+			}
+
+			var set Metadata
+			var err error
+			for key, value := range test.metadata {
+				if err = set.Set(key, value); err != nil {
+					break
+				}
+			}
+			if !errors.Is(err, test.want) {
+				t.Errorf("got error %v from Set, want %v", err, test.want)
+			}
+
+			merged := Metadata{}
+			if err := merged.Merge(test.metadata); !errors.Is(err, test.want) {
+				t.Errorf("got error %v from Merge, want %v", err, test.want)
+			}
+		})
+	}
+}
+
+func metadataWithKeys(n int) Metadata {
+	m := Metadata{}
+	for i := range n {
+		m[fmt.Sprintf("key_%d", i)] = "v"
+	}
+	return m
+}
+
+type testOrderRef struct {
+	OrderID string `metadata:"order_id"`
+}
+
+type testTenantRef struct {
+	TenantID int `metadata:"tenant_id"`
+}
+
+type testPaymentRef struct {
+	testOrderRef
+	*testTenantRef
+	Retried  bool    `metadata:"retried,omitempty"`
+	Discount *string `metadata:"discount"`
+	Ignored  string  `metadata:"-"`
+}
+
+func TestMetadataMarshalEmbeddedStructs(t *testing.T) {
+	ref := testPaymentRef{
+		testOrderRef:  testOrderRef{OrderID: "ord_123"},
+		testTenantRef: &testTenantRef{TenantID: 7},
+		Ignored:       "ignored",
+	}
+
+	metadata, err := MarshalMetadata(ref)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := Metadata{"order_id": "ord_123", "tenant_id": "7"}
+	if !reflect.DeepEqual(metadata, want) {
+		t.Errorf("got metadata %v, want %v", metadata, want)
+	}
+
+	// Nil pointers to unexported embedded structs can't be allocated
+	got := testPaymentRef{testTenantRef: &testTenantRef{}}
+	if err := metadata.Unmarshal(&got); err != nil {
+		t.Fatal(err)
+	}
+	ref.Ignored = ""
+	if !reflect.DeepEqual(got, ref) {
+		t.Errorf("got %+v after unmarshaling, want %+v", got, ref)
+	}
+}
+
+// TestShippingRef is exported so that nil pointers to it can be allocated when embedded.
+type TestShippingRef struct {
+	Carrier string `metadata:"carrier"`
+}
+
+type testShipmentRef struct {
+	*TestShippingRef
+	ShipmentID string `metadata:"shipment_id"`
+}
+
+func TestMetadataEmbeddedStructPointers(t *testing.T) {
+	ref := testShipmentRef{ShipmentID: "shp_123"}
+
+	metadata, err := MarshalMetadata(&ref)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := (Metadata{"shipment_id": "shp_123"}); !reflect.DeepEqual(metadata, want) {
+		t.Errorf("got metadata %v, want %v", metadata, want)
+	}
+	if ref.TestShippingRef != nil {
+		t.Errorf("got embedded struct %+v allocated by marshaling, want nil", ref.TestShippingRef)
+	}
+
+	var got testShipmentRef
+	if err := (Metadata{"carrier": "lbc", "shipment_id": "shp_123"}).Unmarshal(&got); err != nil {
+		t.Fatal(err)
+	}
+	if got.TestShippingRef == nil || got.Carrier != "lbc" {
+		t.Errorf("got %+v after unmarshaling, want the embedded struct allocated", got)
+	}
+}
+
+func TestRequestValidatesParamsMetadata(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("got request %s %s, want no request", r.Method, r.URL.Path)
+	}))
+	defer server.Close()
+
+	client := NewClient("sk_test_123").WithBaseURL(server.URL)
+
+	_, err := client.Customers.Update("cus_123", &CustomerUpdateParams{
+		Metadata: Metadata{strings.Repeat("k", MetadataMaxKeyLength+1): "v"},
+	})
+	if !errors.Is(err, ErrMetadataKeyTooLong) {
+		t.Errorf("got error %v, want %v", err, ErrMetadataKeyTooLong)
+	}
+
+	_, err = client.BillingStatements.Create(&BillingStatementCreateParams{
+		CustomerID: "cus_123",
+		Currency:   CurrencyPHP,
+		Metadata:   metadataWithKeys(MetadataMaxKeys + 1),
+	})
+	if !errors.Is(err, ErrMetadataTooManyKeys) {
+		t.Errorf("got error %v, want %v", err, ErrMetadataTooManyKeys)
+	}
+}
+
+func TestEmptyMetadataIsSent(t *testing.T) {
+	tests := map[string]struct {
+		params *CustomerUpdateParams
+		want   string
+	}{
+		"nil metadata is left out":  {params: &CustomerUpdateParams{}, want: ""},
+		"empty metadata is cleared": {params: &CustomerUpdateParams{Metadata: Metadata{}}, want: "metadata="},
+		"metadata is sent by key":   {params: &CustomerUpdateParams{Metadata: Metadata{"order_id": "ord_123"}}, want: "metadata%5Border_id%5D=ord_123"},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			encoded := form.Encode(test.params)
+			if encoded != test.want {
+				t.Errorf("got form '%s', want '%s'", encoded, test.want)
+			}
+
+			var decoded CustomerUpdateParams
+			if err := form.Decode(encoded, &decoded); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(decoded.Metadata, test.params.Metadata) {
+				t.Errorf("got metadata %#v after decoding, want %#v", decoded.Metadata, test.params.Metadata)
+			}
+		})
+	}
+}
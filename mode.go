@@ -0,0 +1,50 @@
+package payrex
+
+import (
+	"errors"
+	"strings"
+)
+
+// Mode enumerates the modes of a PayRex account: test mode or live mode.
+//
+// Test mode resources and signatures are kept separate from live mode ones,
+// and use separate API keys and webhook signatures.
+type Mode string
+
+const (
+	ModeTest Mode = "test"
+	ModeLive Mode = "live"
+)
+
+var (
+	ErrAPIKeyModeMismatch = errors.New("API key mode does not match the required mode")
+	ErrEventModeMismatch  = errors.New("event mode does not match the expected mode")
+)
+
+// ModeFromAPIKey returns the mode of a PayRex API key from its prefix,
+// e.g. [ModeLive] for "sk_live_..." and [ModeTest] for "sk_test_...".
+//
+// Returns an empty string if the mode of the API key is not known.
+func ModeFromAPIKey(apiKey string) Mode {
+	_, rest, ok := strings.Cut(apiKey, "_")
+	if !ok {
+		return ""
+	}
+
+	switch {
+	case strings.HasPrefix(rest, "live_"):
+		return ModeLive
+	case strings.HasPrefix(rest, "test_"):
+		return ModeTest
+	default:
+		return ""
+	}
+}
+
+// ModeFromLivemode returns the mode of a resource from its Resource.Livemode value.
+func ModeFromLivemode(livemode bool) Mode {
+	if livemode {
+		return ModeLive
+	}
+	return ModeTest
+}
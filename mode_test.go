@@ -0,0 +1,88 @@
+package payrex
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestModeFromAPIKey(t *testing.T) {
+	tests := map[string]struct {
+		apiKey string
+		want   Mode
+	}{
+		"live secret key":    {apiKey: "sk_live_123", want: ModeLive},
+		"test secret key":    {apiKey: "sk_test_123", want: ModeTest},
+		"live public key":    {apiKey: "pk_live_123", want: ModeLive},
+		"test public key":    {apiKey: "pk_test_123", want: ModeTest},
+		"empty":              {apiKey: ""},
+		"no prefix":          {apiKey: "live_123"},
+		"unknown mode":       {apiKey: "sk_prod_123"},
+		"mode without value": {apiKey: "sk_live"},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			if got := ModeFromAPIKey(tt.apiKey); got != tt.want {
+				t.Errorf("got mode '%s', want '%s'", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestModeFromLivemode(t *testing.T) {
+	if got := ModeFromLivemode(true); got != ModeLive {
+		t.Errorf("got mode '%s' for livemode true, want '%s'", got, ModeLive)
+	}
+	if got := ModeFromLivemode(false); got != ModeTest {
+		t.Errorf("got mode '%s' for livemode false, want '%s'", got, ModeTest)
+	}
+}
+
+func TestClientWithRequiredMode(t *testing.T) {
+	tests := map[string]struct {
+		apiKey       string
+		requiredMode Mode
+		wantErr      bool
+	}{
+		"live key in live mode":   {apiKey: "sk_live_123", requiredMode: ModeLive},
+		"test key in test mode":   {apiKey: "sk_test_123", requiredMode: ModeTest},
+		"any key without a mode":  {apiKey: "sk_live_123"},
+		"test key in live mode":   {apiKey: "sk_test_123", requiredMode: ModeLive, wantErr: true},
+		"live key in test mode":   {apiKey: "sk_live_123", requiredMode: ModeTest, wantErr: true},
+		"malformed key in a mode": {apiKey: "secret", requiredMode: ModeTest, wantErr: true},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			requests := 0
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				requests++
+				w.Header().Set("Content-Type", "application/json")
+				_, _ = w.Write([]byte(`{"id": "cus_123", "resource": "customer"}`))
+			}))
+			defer server.Close()
+
+			client := NewClient(tt.apiKey).WithBaseURL(server.URL).WithRequiredMode(tt.requiredMode)
+
+			_, err := client.Customers.Retrieve("cus_123")
+			if tt.wantErr {
+				if !errors.Is(err, ErrAPIKeyModeMismatch) {
+					t.Errorf("got error %v, want %v", err, ErrAPIKeyModeMismatch)
+				}
+				if requests != 0 {
+					t.Errorf("got %d requests to the API, want the request refused before sending it", requests)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+			if requests != 1 {
+				t.Errorf("got %d requests to the API, want 1", requests)
+			}
+		})
+	}
+}
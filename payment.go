@@ -1,5 +1,7 @@
 package payrex
 
+import "net/url"
+
 // Payment represents an individual attempt to move money to your PayRex merchant account balance.
 //
 // Service: [ServicePayments]
@@ -7,19 +9,21 @@ package payrex
 // API reference: https://docs.payrexhq.com/docs/api/payments
 type Payment struct {
 	Resource
-	Amount          int                `json:"amount"`
-	AmountRefunded  int                `json:"amount_refunded"`
-	Billing         Billing            `json:"billing"`
-	Currency        Currency           `json:"currency"`
-	Description     *string            `json:"description"`
-	Fee             int                `json:"fee"`
-	Metadata        *map[string]string `json:"metadata"`
-	NetAmount       int                `json:"net_amount"`
-	PaymentIntentID string             `json:"payment_intent_id"`
-	Status          PaymentStatus      `json:"payment_status"`
-	Customer        *Customer          `json:"customer"`
-	PaymentMethod   PaymentMethodType  `json:"payment_method"`
-	Refunded        bool               `json:"refunded"`
+	Amount          int               `json:"amount"`
+	AmountRefunded  int               `json:"amount_refunded"`
+	Billing         Billing           `json:"billing"`
+	Currency        Currency          `json:"currency"`
+	Description     *string           `json:"description"`
+	Fee             int               `json:"fee"`
+	Metadata        Metadata          `json:"metadata"`
+	NetAmount       int               `json:"net_amount"`
+	PaymentIntentID string            `json:"payment_intent_id"`
+	Status          PaymentStatus     `json:"status"`
+	Customer        *Customer         `json:"customer"`
+	PaymentMethod   PaymentMethodType `json:"payment_method"`
+	Refunded        bool              `json:"refunded"`
+
+	Extra ExtraFields `json:"-"`
 }
 
 type Billing struct {
@@ -34,7 +38,7 @@ type Address struct {
 	Line2      string `json:"line2"`
 	City       string `json:"city"`
 	State      string `json:"state"`
-	PostalCode string `json:"PostalCode"`
+	PostalCode string `json:"postal_code"`
 	Country    string `json:"country"`
 }
 
@@ -82,6 +86,8 @@ func (s *ServicePayments) Update(id string, params *PaymentUpdateParams) (*Payme
 //
 // API reference: https://docs.payrexhq.com/docs/api/payments/update
 type PaymentUpdateParams struct {
-	Description *string            `form:"description"`
-	Metadata    *map[string]string `form:"metadata"`
+	Description *string  `form:"description"`
+	Metadata    Metadata `form:"metadata"`
+
+	Extra url.Values `form:",extra"`
 }
@@ -1,5 +1,7 @@
 package payrex
 
+import "net/url"
+
 // PaymentIntent tracks the customer's payment lifecycle, keeping track of
 // any failed payment attempts and ensuring the customer is only charged once.
 //
@@ -14,7 +16,7 @@ type PaymentIntent struct {
 	ClientSecret         string                   `json:"client_secret"`
 	Currency             Currency                 `json:"currency"`
 	Description          *string                  `json:"description"`
-	Metadata             *map[string]string       `json:"metadata"`
+	Metadata             Metadata                 `json:"metadata"`
 	PaymentMethodID      *string                  `json:"payment_method_id"`
 	PaymentMethods       []PaymentMethod          `json:"payment_methods"`
 	PaymentMethodOptions *PaymentMethodOptions    `json:"payment_method_options"`
@@ -22,6 +24,8 @@ type PaymentIntent struct {
 	Status               PaymentIntentStatus      `json:"status"`
 	NextAction           *PaymentIntentNextAction `json:"next_action"`
 	ReturnURL            *string                  `json:"return_url"`
+
+	Extra ExtraFields `json:"-"`
 }
 
 // PaymentIntentStatus enumerates the valid values for the [PaymentIntent].Status field.
@@ -31,7 +35,9 @@ const (
 	PaymentIntentStatusAwaitingPaymentMethod PaymentIntentStatus = "awaiting_payment_method"
 	PaymentIntentStatusAwaitingNextAction    PaymentIntentStatus = "awaiting_next_action"
 	PaymentIntentStatusProcessing            PaymentIntentStatus = "processing"
+	PaymentIntentStatusAwaitingCapture       PaymentIntentStatus = "awaiting_capture"
 	PaymentIntentStatusSucceeded             PaymentIntentStatus = "succeeded"
+	PaymentIntentStatusCanceled              PaymentIntentStatus = "canceled"
 )
 
 type PaymentIntentNextAction struct {
@@ -99,6 +105,8 @@ func (s *ServicePaymentIntents) Retrieve(id string) (*PaymentIntent, error) {
 // API reference: https://docs.payrexhq.com/docs/api/payment_intents/capture
 type PaymentIntentCaptureParams struct {
 	Amount int `form:"amount"`
+
+	Extra url.Values `form:",extra"`
 }
 
 // PaymentIntentCreateParams represents the available [ServicePaymentIntents.Create] parameters.
@@ -110,4 +118,7 @@ type PaymentIntentCreateParams struct {
 	Currency             Currency              `form:"currency"`
 	Description          *string               `form:"description"`
 	PaymentMethodOptions *PaymentMethodOptions `form:"payment_method_options"`
+	Metadata             Metadata              `form:"metadata"`
+
+	Extra url.Values `form:",extra"`
 }
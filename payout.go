@@ -1,6 +1,9 @@
 package payrex
 
-import "net/http"
+import (
+	"net/http"
+	"net/url"
+)
 
 // Payout resources are created when you are scheduled to receive money from PayRex.
 //
@@ -15,6 +18,8 @@ type Payout struct {
 	Destination PayoutDestination `json:"destination"`
 	NetAmount   int               `json:"net_amount"`
 	Status      PayoutStatus      `json:"status"`
+
+	Extra ExtraFields `json:"-"`
 }
 
 // PayoutStatus enumerates the valid values for the [Payout].Status field.
@@ -51,6 +56,8 @@ type PayoutTransaction struct {
 	CreatedAt int `json:"created_at"`
 	// The time the resource was updated, measured in seconds since the Unix epoch.
 	UpdatedAt int `json:"updated_at"`
+
+	Extra ExtraFields `json:"-"`
 }
 
 // PayoutTransactionType enumerates the valid values for the [PayoutTransaction].TransactionType field.
@@ -92,4 +99,6 @@ type PayoutTransactionListParams struct {
 	Limit  *int    `form:"limit"`
 	Before *string `form:"before"`
 	After  *string `form:"after"`
+
+	Extra url.Values `form:",extra"`
 }
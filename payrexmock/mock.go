@@ -0,0 +1,76 @@
+// Package payrexmock provides mocks of the services of the PayRex client, for unit testing
+// code that depends on [payrex.API] or on the service interfaces without HTTP.
+//
+// Methods are stubbed by setting the Func fields of the mocks, and every call is recorded:
+//
+//	client := payrexmock.NewClient()
+//	client.PaymentIntents.CreateFunc = func(params *payrex.PaymentIntentCreateParams) (*payrex.PaymentIntent, error) {
+//		return &payrex.PaymentIntent{Resource: payrex.Resource{ID: "pi_123"}, Amount: params.Amount}, nil
+//	}
+//
+//	checkout := NewCheckout(client)
+//	// ...
+//
+//	calls := client.PaymentIntents.Calls() // [{PaymentIntents.Create [0xc000...]}]
+//
+// The mocks are generated from the service interfaces by internal/mockgen.
+package payrexmock
+
+//go:generate go run ../internal/mockgen -in ../service_interfaces.go -out services.go
+
+import (
+	"errors"
+	"fmt"
+	"iter"
+	"slices"
+	"sync"
+)
+
+// ErrNotStubbed is returned by the methods of mocks that have no stub.
+var ErrNotStubbed = errors.New("payrexmock: method is not stubbed")
+
+// Call is a recorded call of a method of a mock.
+type Call struct {
+	// The called method, prefixed with the name of its service, e.g. "PaymentIntents.Create".
+	Method string
+	// The arguments of the call.
+	Args []any
+}
+
+// calls records the calls of a mock, and of its parent client mock if it has one.
+type calls struct {
+	mu     sync.Mutex
+	list   []Call
+	parent *calls
+}
+
+func (c *calls) record(method string, args ...any) {
+	call := Call{Method: method, Args: args}
+
+	c.mu.Lock()
+	c.list = append(c.list, call)
+	c.mu.Unlock()
+
+	if c.parent != nil {
+		c.parent.record(method, args...)
+	}
+}
+
+func (c *calls) all() []Call {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return slices.Clone(c.list)
+}
+
+func notStubbed(method string) error {
+	return fmt.Errorf("%w: %s", ErrNotStubbed, method)
+}
+
+// errSeq2 returns an iterator yielding only the error.
+func errSeq2[T any](err error) iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		var zero T
+		yield(zero, err)
+	}
+}
@@ -0,0 +1,73 @@
+package payrexmock
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/angelofallars/payrex-go"
+)
+
+// captureOrder is code under test depending on [payrex.API].
+func captureOrder(client payrex.API, paymentIntentID string) (*payrex.PaymentIntent, error) {
+	paymentIntent, err := client.PaymentIntentsService().Retrieve(paymentIntentID)
+	if err != nil {
+		return nil, err
+	}
+	return client.PaymentIntentsService().Capture(paymentIntent.ID, &payrex.PaymentIntentCaptureParams{
+		Amount: paymentIntent.Amount,
+	})
+}
+
+func TestClient(t *testing.T) {
+	client := NewClient()
+	client.PaymentIntents.RetrieveFunc = func(id string) (*payrex.PaymentIntent, error) {
+		return &payrex.PaymentIntent{Resource: payrex.Resource{ID: id}, Amount: 100_00}, nil
+	}
+
+	// Capture is not stubbed yet
+	if _, err := captureOrder(client, "pi_123"); !errors.Is(err, ErrNotStubbed) {
+		t.Errorf("got error %v, want %v", err, ErrNotStubbed)
+	}
+
+	client.PaymentIntents.CaptureFunc = func(id string, params *payrex.PaymentIntentCaptureParams) (*payrex.PaymentIntent, error) {
+		return &payrex.PaymentIntent{
+			Resource: payrex.Resource{ID: id},
+			Amount:   params.Amount,
+			Status:   payrex.PaymentIntentStatusSucceeded,
+		}, nil
+	}
+
+	paymentIntent, err := captureOrder(client, "pi_123")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if paymentIntent.Status != payrex.PaymentIntentStatusSucceeded {
+		t.Errorf("got status '%s', want '%s'", paymentIntent.Status, payrex.PaymentIntentStatusSucceeded)
+	}
+
+	wantMethods := []string{
+		"PaymentIntents.Retrieve",
+		"PaymentIntents.Capture",
+		"PaymentIntents.Retrieve",
+		"PaymentIntents.Capture",
+	}
+	calls := client.Calls()
+	if len(calls) != len(wantMethods) {
+		t.Fatalf("got %d calls, want %d", len(calls), len(wantMethods))
+	}
+	for i, call := range calls {
+		if call.Method != wantMethods[i] {
+			t.Errorf("got call %d to '%s', want '%s'", i, call.Method, wantMethods[i])
+		}
+	}
+	if got := calls[3].Args[1].(*payrex.PaymentIntentCaptureParams).Amount; got != 100_00 {
+		t.Errorf("got capture amount %d, want %d", got, 100_00)
+	}
+
+	if got := len(client.PaymentIntents.Calls()); got != 4 {
+		t.Errorf("got %d calls on the service mock, want 4", got)
+	}
+	if got := len(client.Customers.Calls()); got != 0 {
+		t.Errorf("got %d calls on the unused service mock, want 0", got)
+	}
+}
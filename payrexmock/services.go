@@ -0,0 +1,701 @@
+// Code generated by internal/mockgen. DO NOT EDIT.
+
+package payrexmock
+
+import (
+	"iter"
+
+	"github.com/angelofallars/payrex-go"
+)
+
+// Client is a mock of [payrex.API]. Its services are mocks recording their calls
+// both on their own and on the client.
+type Client struct {
+	BillingStatementLineItems *BillingStatementLineItemsService
+	BillingStatements         *BillingStatementsService
+	CheckoutSessions          *CheckoutSessionsService
+	CustomerSessions          *CustomerSessionsService
+	Customers                 *CustomersService
+	PaymentIntents            *PaymentIntentsService
+	Payments                  *PaymentsService
+	Payouts                   *PayoutsService
+	Refunds                   *RefundsService
+	Webhooks                  *WebhooksService
+
+	calls calls
+}
+
+var _ payrex.API = (*Client)(nil)
+
+// NewClient creates a new [Client] with unstubbed services.
+func NewClient() *Client {
+	c := &Client{}
+	c.BillingStatementLineItems = &BillingStatementLineItemsService{calls: calls{parent: &c.calls}}
+	c.BillingStatements = &BillingStatementsService{calls: calls{parent: &c.calls}}
+	c.CheckoutSessions = &CheckoutSessionsService{calls: calls{parent: &c.calls}}
+	c.CustomerSessions = &CustomerSessionsService{calls: calls{parent: &c.calls}}
+	c.Customers = &CustomersService{calls: calls{parent: &c.calls}}
+	c.PaymentIntents = &PaymentIntentsService{calls: calls{parent: &c.calls}}
+	c.Payments = &PaymentsService{calls: calls{parent: &c.calls}}
+	c.Payouts = &PayoutsService{calls: calls{parent: &c.calls}}
+	c.Refunds = &RefundsService{calls: calls{parent: &c.calls}}
+	c.Webhooks = &WebhooksService{calls: calls{parent: &c.calls}}
+	return c
+}
+
+// Calls returns the calls of the methods of all services so far, from oldest to newest.
+func (c *Client) Calls() []Call {
+	return c.calls.all()
+}
+
+// BillingStatementLineItemsService returns the [Client.BillingStatementLineItems] mock.
+func (c *Client) BillingStatementLineItemsService() payrex.BillingStatementLineItemsService {
+	return c.BillingStatementLineItems
+}
+
+// BillingStatementsService returns the [Client.BillingStatements] mock.
+func (c *Client) BillingStatementsService() payrex.BillingStatementsService {
+	return c.BillingStatements
+}
+
+// CheckoutSessionsService returns the [Client.CheckoutSessions] mock.
+func (c *Client) CheckoutSessionsService() payrex.CheckoutSessionsService {
+	return c.CheckoutSessions
+}
+
+// CustomerSessionsService returns the [Client.CustomerSessions] mock.
+func (c *Client) CustomerSessionsService() payrex.CustomerSessionsService {
+	return c.CustomerSessions
+}
+
+// CustomersService returns the [Client.Customers] mock.
+func (c *Client) CustomersService() payrex.CustomersService {
+	return c.Customers
+}
+
+// PaymentIntentsService returns the [Client.PaymentIntents] mock.
+func (c *Client) PaymentIntentsService() payrex.PaymentIntentsService {
+	return c.PaymentIntents
+}
+
+// PaymentsService returns the [Client.Payments] mock.
+func (c *Client) PaymentsService() payrex.PaymentsService {
+	return c.Payments
+}
+
+// PayoutsService returns the [Client.Payouts] mock.
+func (c *Client) PayoutsService() payrex.PayoutsService {
+	return c.Payouts
+}
+
+// RefundsService returns the [Client.Refunds] mock.
+func (c *Client) RefundsService() payrex.RefundsService {
+	return c.Refunds
+}
+
+// WebhooksService returns the [Client.Webhooks] mock.
+func (c *Client) WebhooksService() payrex.WebhooksService {
+	return c.Webhooks
+}
+
+// BillingStatementLineItemsService is a mock of [payrex.BillingStatementLineItemsService]. Set the Func fields to stub its methods.
+// Methods without a stub return [ErrNotStubbed].
+type BillingStatementLineItemsService struct {
+	CreateFunc func(params *payrex.BillingStatementLineItemCreateParams) (*payrex.BillingStatementLineItem, error)
+	UpdateFunc func(id string, params *payrex.BillingStatementLineItemUpdateParams) (*payrex.BillingStatementLineItem, error)
+	DeleteFunc func(id string) (*payrex.DeletedResource, error)
+
+	calls calls
+}
+
+var _ payrex.BillingStatementLineItemsService = (*BillingStatementLineItemsService)(nil)
+
+// Calls returns the calls of the mock's methods so far, from oldest to newest.
+func (m *BillingStatementLineItemsService) Calls() []Call {
+	return m.calls.all()
+}
+
+// Create records the call and calls CreateFunc.
+func (m *BillingStatementLineItemsService) Create(params *payrex.BillingStatementLineItemCreateParams) (*payrex.BillingStatementLineItem, error) {
+	m.calls.record("BillingStatementLineItems.Create", params)
+	if m.CreateFunc == nil {
+		err := notStubbed("BillingStatementLineItems.Create")
+		return nil, err
+	}
+	return m.CreateFunc(params)
+}
+
+// Update records the call and calls UpdateFunc.
+func (m *BillingStatementLineItemsService) Update(id string, params *payrex.BillingStatementLineItemUpdateParams) (*payrex.BillingStatementLineItem, error) {
+	m.calls.record("BillingStatementLineItems.Update", id, params)
+	if m.UpdateFunc == nil {
+		err := notStubbed("BillingStatementLineItems.Update")
+		return nil, err
+	}
+	return m.UpdateFunc(id, params)
+}
+
+// Delete records the call and calls DeleteFunc.
+func (m *BillingStatementLineItemsService) Delete(id string) (*payrex.DeletedResource, error) {
+	m.calls.record("BillingStatementLineItems.Delete", id)
+	if m.DeleteFunc == nil {
+		err := notStubbed("BillingStatementLineItems.Delete")
+		return nil, err
+	}
+	return m.DeleteFunc(id)
+}
+
+// BillingStatementsService is a mock of [payrex.BillingStatementsService]. Set the Func fields to stub its methods.
+// Methods without a stub return [ErrNotStubbed].
+type BillingStatementsService struct {
+	CreateFunc            func(params *payrex.BillingStatementCreateParams) (*payrex.BillingStatement, error)
+	RetrieveFunc          func(id string) (*payrex.BillingStatement, error)
+	ListFunc              func(params *payrex.BillingStatementListParams) iter.Seq2[*payrex.BillingStatement, error]
+	UpdateFunc            func(id string, params *payrex.BillingStatementUpdateParams) (*payrex.BillingStatement, error)
+	DeleteFunc            func(id string) (*payrex.DeletedResource, error)
+	FinalizeFunc          func(id string) (*payrex.BillingStatement, error)
+	MarkUncollectibleFunc func(id string) (*payrex.BillingStatement, error)
+	SendFunc              func(id string) (*payrex.BillingStatement, error)
+	VoidFunc              func(id string) (*payrex.BillingStatement, error)
+
+	calls calls
+}
+
+var _ payrex.BillingStatementsService = (*BillingStatementsService)(nil)
+
+// Calls returns the calls of the mock's methods so far, from oldest to newest.
+func (m *BillingStatementsService) Calls() []Call {
+	return m.calls.all()
+}
+
+// Create records the call and calls CreateFunc.
+func (m *BillingStatementsService) Create(params *payrex.BillingStatementCreateParams) (*payrex.BillingStatement, error) {
+	m.calls.record("BillingStatements.Create", params)
+	if m.CreateFunc == nil {
+		err := notStubbed("BillingStatements.Create")
+		return nil, err
+	}
+	return m.CreateFunc(params)
+}
+
+// Retrieve records the call and calls RetrieveFunc.
+func (m *BillingStatementsService) Retrieve(id string) (*payrex.BillingStatement, error) {
+	m.calls.record("BillingStatements.Retrieve", id)
+	if m.RetrieveFunc == nil {
+		err := notStubbed("BillingStatements.Retrieve")
+		return nil, err
+	}
+	return m.RetrieveFunc(id)
+}
+
+// List records the call and calls ListFunc.
+func (m *BillingStatementsService) List(params *payrex.BillingStatementListParams) iter.Seq2[*payrex.BillingStatement, error] {
+	m.calls.record("BillingStatements.List", params)
+	if m.ListFunc == nil {
+		err := notStubbed("BillingStatements.List")
+		return errSeq2[*payrex.BillingStatement](err)
+	}
+	return m.ListFunc(params)
+}
+
+// Update records the call and calls UpdateFunc.
+func (m *BillingStatementsService) Update(id string, params *payrex.BillingStatementUpdateParams) (*payrex.BillingStatement, error) {
+	m.calls.record("BillingStatements.Update", id, params)
+	if m.UpdateFunc == nil {
+		err := notStubbed("BillingStatements.Update")
+		return nil, err
+	}
+	return m.UpdateFunc(id, params)
+}
+
+// Delete records the call and calls DeleteFunc.
+func (m *BillingStatementsService) Delete(id string) (*payrex.DeletedResource, error) {
+	m.calls.record("BillingStatements.Delete", id)
+	if m.DeleteFunc == nil {
+		err := notStubbed("BillingStatements.Delete")
+		return nil, err
+	}
+	return m.DeleteFunc(id)
+}
+
+// Finalize records the call and calls FinalizeFunc.
+func (m *BillingStatementsService) Finalize(id string) (*payrex.BillingStatement, error) {
+	m.calls.record("BillingStatements.Finalize", id)
+	if m.FinalizeFunc == nil {
+		err := notStubbed("BillingStatements.Finalize")
+		return nil, err
+	}
+	return m.FinalizeFunc(id)
+}
+
+// MarkUncollectible records the call and calls MarkUncollectibleFunc.
+func (m *BillingStatementsService) MarkUncollectible(id string) (*payrex.BillingStatement, error) {
+	m.calls.record("BillingStatements.MarkUncollectible", id)
+	if m.MarkUncollectibleFunc == nil {
+		err := notStubbed("BillingStatements.MarkUncollectible")
+		return nil, err
+	}
+	return m.MarkUncollectibleFunc(id)
+}
+
+// Send records the call and calls SendFunc.
+func (m *BillingStatementsService) Send(id string) (*payrex.BillingStatement, error) {
+	m.calls.record("BillingStatements.Send", id)
+	if m.SendFunc == nil {
+		err := notStubbed("BillingStatements.Send")
+		return nil, err
+	}
+	return m.SendFunc(id)
+}
+
+// Void records the call and calls VoidFunc.
+func (m *BillingStatementsService) Void(id string) (*payrex.BillingStatement, error) {
+	m.calls.record("BillingStatements.Void", id)
+	if m.VoidFunc == nil {
+		err := notStubbed("BillingStatements.Void")
+		return nil, err
+	}
+	return m.VoidFunc(id)
+}
+
+// CheckoutSessionsService is a mock of [payrex.CheckoutSessionsService]. Set the Func fields to stub its methods.
+// Methods without a stub return [ErrNotStubbed].
+type CheckoutSessionsService struct {
+	CreateFunc   func(params *payrex.CheckoutSessionCreateParams) (*payrex.CheckoutSession, error)
+	ListFunc     func(params *payrex.ListCheckoutSessionsParams) iter.Seq2[*payrex.CheckoutSession, error]
+	RetrieveFunc func(id string) (*payrex.CheckoutSession, error)
+	ExpireFunc   func(id string) (*payrex.CheckoutSession, error)
+
+	calls calls
+}
+
+var _ payrex.CheckoutSessionsService = (*CheckoutSessionsService)(nil)
+
+// Calls returns the calls of the mock's methods so far, from oldest to newest.
+func (m *CheckoutSessionsService) Calls() []Call {
+	return m.calls.all()
+}
+
+// Create records the call and calls CreateFunc.
+func (m *CheckoutSessionsService) Create(params *payrex.CheckoutSessionCreateParams) (*payrex.CheckoutSession, error) {
+	m.calls.record("CheckoutSessions.Create", params)
+	if m.CreateFunc == nil {
+		err := notStubbed("CheckoutSessions.Create")
+		return nil, err
+	}
+	return m.CreateFunc(params)
+}
+
+// List records the call and calls ListFunc.
+func (m *CheckoutSessionsService) List(params *payrex.ListCheckoutSessionsParams) iter.Seq2[*payrex.CheckoutSession, error] {
+	m.calls.record("CheckoutSessions.List", params)
+	if m.ListFunc == nil {
+		err := notStubbed("CheckoutSessions.List")
+		return errSeq2[*payrex.CheckoutSession](err)
+	}
+	return m.ListFunc(params)
+}
+
+// Retrieve records the call and calls RetrieveFunc.
+func (m *CheckoutSessionsService) Retrieve(id string) (*payrex.CheckoutSession, error) {
+	m.calls.record("CheckoutSessions.Retrieve", id)
+	if m.RetrieveFunc == nil {
+		err := notStubbed("CheckoutSessions.Retrieve")
+		return nil, err
+	}
+	return m.RetrieveFunc(id)
+}
+
+// Expire records the call and calls ExpireFunc.
+func (m *CheckoutSessionsService) Expire(id string) (*payrex.CheckoutSession, error) {
+	m.calls.record("CheckoutSessions.Expire", id)
+	if m.ExpireFunc == nil {
+		err := notStubbed("CheckoutSessions.Expire")
+		return nil, err
+	}
+	return m.ExpireFunc(id)
+}
+
+// CustomerSessionsService is a mock of [payrex.CustomerSessionsService]. Set the Func fields to stub its methods.
+// Methods without a stub return [ErrNotStubbed].
+type CustomerSessionsService struct {
+	CreateFunc   func(params *payrex.CustomerSessionCreateParams) (*payrex.CustomerSession, error)
+	RetrieveFunc func(id string) (*payrex.CustomerSession, error)
+
+	calls calls
+}
+
+var _ payrex.CustomerSessionsService = (*CustomerSessionsService)(nil)
+
+// Calls returns the calls of the mock's methods so far, from oldest to newest.
+func (m *CustomerSessionsService) Calls() []Call {
+	return m.calls.all()
+}
+
+// Create records the call and calls CreateFunc.
+func (m *CustomerSessionsService) Create(params *payrex.CustomerSessionCreateParams) (*payrex.CustomerSession, error) {
+	m.calls.record("CustomerSessions.Create", params)
+	if m.CreateFunc == nil {
+		err := notStubbed("CustomerSessions.Create")
+		return nil, err
+	}
+	return m.CreateFunc(params)
+}
+
+// Retrieve records the call and calls RetrieveFunc.
+func (m *CustomerSessionsService) Retrieve(id string) (*payrex.CustomerSession, error) {
+	m.calls.record("CustomerSessions.Retrieve", id)
+	if m.RetrieveFunc == nil {
+		err := notStubbed("CustomerSessions.Retrieve")
+		return nil, err
+	}
+	return m.RetrieveFunc(id)
+}
+
+// CustomersService is a mock of [payrex.CustomersService]. Set the Func fields to stub its methods.
+// Methods without a stub return [ErrNotStubbed].
+type CustomersService struct {
+	CreateFunc   func(params *payrex.CustomerCreateParams) (*payrex.Customer, error)
+	RetrieveFunc func(id string) (*payrex.Customer, error)
+	ListFunc     func(params *payrex.CustomerListParams) iter.Seq2[*payrex.Customer, error]
+	UpdateFunc   func(id string, params *payrex.CustomerUpdateParams) (*payrex.Customer, error)
+	DeleteFunc   func(id string) (*payrex.DeletedResource, error)
+
+	calls calls
+}
+
+var _ payrex.CustomersService = (*CustomersService)(nil)
+
+// Calls returns the calls of the mock's methods so far, from oldest to newest.
+func (m *CustomersService) Calls() []Call {
+	return m.calls.all()
+}
+
+// Create records the call and calls CreateFunc.
+func (m *CustomersService) Create(params *payrex.CustomerCreateParams) (*payrex.Customer, error) {
+	m.calls.record("Customers.Create", params)
+	if m.CreateFunc == nil {
+		err := notStubbed("Customers.Create")
+		return nil, err
+	}
+	return m.CreateFunc(params)
+}
+
+// Retrieve records the call and calls RetrieveFunc.
+func (m *CustomersService) Retrieve(id string) (*payrex.Customer, error) {
+	m.calls.record("Customers.Retrieve", id)
+	if m.RetrieveFunc == nil {
+		err := notStubbed("Customers.Retrieve")
+		return nil, err
+	}
+	return m.RetrieveFunc(id)
+}
+
+// List records the call and calls ListFunc.
+func (m *CustomersService) List(params *payrex.CustomerListParams) iter.Seq2[*payrex.Customer, error] {
+	m.calls.record("Customers.List", params)
+	if m.ListFunc == nil {
+		err := notStubbed("Customers.List")
+		return errSeq2[*payrex.Customer](err)
+	}
+	return m.ListFunc(params)
+}
+
+// Update records the call and calls UpdateFunc.
+func (m *CustomersService) Update(id string, params *payrex.CustomerUpdateParams) (*payrex.Customer, error) {
+	m.calls.record("Customers.Update", id, params)
+	if m.UpdateFunc == nil {
+		err := notStubbed("Customers.Update")
+		return nil, err
+	}
+	return m.UpdateFunc(id, params)
+}
+
+// Delete records the call and calls DeleteFunc.
+func (m *CustomersService) Delete(id string) (*payrex.DeletedResource, error) {
+	m.calls.record("Customers.Delete", id)
+	if m.DeleteFunc == nil {
+		err := notStubbed("Customers.Delete")
+		return nil, err
+	}
+	return m.DeleteFunc(id)
+}
+
+// PaymentIntentsService is a mock of [payrex.PaymentIntentsService]. Set the Func fields to stub its methods.
+// Methods without a stub return [ErrNotStubbed].
+type PaymentIntentsService struct {
+	CancelFunc   func(id string) (*payrex.PaymentIntent, error)
+	CaptureFunc  func(id string, params *payrex.PaymentIntentCaptureParams) (*payrex.PaymentIntent, error)
+	CreateFunc   func(params *payrex.PaymentIntentCreateParams) (*payrex.PaymentIntent, error)
+	RetrieveFunc func(id string) (*payrex.PaymentIntent, error)
+
+	calls calls
+}
+
+var _ payrex.PaymentIntentsService = (*PaymentIntentsService)(nil)
+
+// Calls returns the calls of the mock's methods so far, from oldest to newest.
+func (m *PaymentIntentsService) Calls() []Call {
+	return m.calls.all()
+}
+
+// Cancel records the call and calls CancelFunc.
+func (m *PaymentIntentsService) Cancel(id string) (*payrex.PaymentIntent, error) {
+	m.calls.record("PaymentIntents.Cancel", id)
+	if m.CancelFunc == nil {
+		err := notStubbed("PaymentIntents.Cancel")
+		return nil, err
+	}
+	return m.CancelFunc(id)
+}
+
+// Capture records the call and calls CaptureFunc.
+func (m *PaymentIntentsService) Capture(id string, params *payrex.PaymentIntentCaptureParams) (*payrex.PaymentIntent, error) {
+	m.calls.record("PaymentIntents.Capture", id, params)
+	if m.CaptureFunc == nil {
+		err := notStubbed("PaymentIntents.Capture")
+		return nil, err
+	}
+	return m.CaptureFunc(id, params)
+}
+
+// Create records the call and calls CreateFunc.
+func (m *PaymentIntentsService) Create(params *payrex.PaymentIntentCreateParams) (*payrex.PaymentIntent, error) {
+	m.calls.record("PaymentIntents.Create", params)
+	if m.CreateFunc == nil {
+		err := notStubbed("PaymentIntents.Create")
+		return nil, err
+	}
+	return m.CreateFunc(params)
+}
+
+// Retrieve records the call and calls RetrieveFunc.
+func (m *PaymentIntentsService) Retrieve(id string) (*payrex.PaymentIntent, error) {
+	m.calls.record("PaymentIntents.Retrieve", id)
+	if m.RetrieveFunc == nil {
+		err := notStubbed("PaymentIntents.Retrieve")
+		return nil, err
+	}
+	return m.RetrieveFunc(id)
+}
+
+// PaymentsService is a mock of [payrex.PaymentsService]. Set the Func fields to stub its methods.
+// Methods without a stub return [ErrNotStubbed].
+type PaymentsService struct {
+	RetrieveFunc func(id string) (*payrex.Payment, error)
+	UpdateFunc   func(id string, params *payrex.PaymentUpdateParams) (*payrex.Payment, error)
+
+	calls calls
+}
+
+var _ payrex.PaymentsService = (*PaymentsService)(nil)
+
+// Calls returns the calls of the mock's methods so far, from oldest to newest.
+func (m *PaymentsService) Calls() []Call {
+	return m.calls.all()
+}
+
+// Retrieve records the call and calls RetrieveFunc.
+func (m *PaymentsService) Retrieve(id string) (*payrex.Payment, error) {
+	m.calls.record("Payments.Retrieve", id)
+	if m.RetrieveFunc == nil {
+		err := notStubbed("Payments.Retrieve")
+		return nil, err
+	}
+	return m.RetrieveFunc(id)
+}
+
+// Update records the call and calls UpdateFunc.
+func (m *PaymentsService) Update(id string, params *payrex.PaymentUpdateParams) (*payrex.Payment, error) {
+	m.calls.record("Payments.Update", id, params)
+	if m.UpdateFunc == nil {
+		err := notStubbed("Payments.Update")
+		return nil, err
+	}
+	return m.UpdateFunc(id, params)
+}
+
+// PayoutsService is a mock of [payrex.PayoutsService]. Set the Func fields to stub its methods.
+// Methods without a stub return [ErrNotStubbed].
+type PayoutsService struct {
+	ListTransactionsFunc func(id string, params *payrex.PayoutTransactionListParams) (*payrex.List[payrex.PayoutTransaction], error)
+
+	calls calls
+}
+
+var _ payrex.PayoutsService = (*PayoutsService)(nil)
+
+// Calls returns the calls of the mock's methods so far, from oldest to newest.
+func (m *PayoutsService) Calls() []Call {
+	return m.calls.all()
+}
+
+// ListTransactions records the call and calls ListTransactionsFunc.
+func (m *PayoutsService) ListTransactions(id string, params *payrex.PayoutTransactionListParams) (*payrex.List[payrex.PayoutTransaction], error) {
+	m.calls.record("Payouts.ListTransactions", id, params)
+	if m.ListTransactionsFunc == nil {
+		err := notStubbed("Payouts.ListTransactions")
+		return nil, err
+	}
+	return m.ListTransactionsFunc(id, params)
+}
+
+// RefundsService is a mock of [payrex.RefundsService]. Set the Func fields to stub its methods.
+// Methods without a stub return [ErrNotStubbed].
+type RefundsService struct {
+	CreateFunc func(params *payrex.RefundCreateParams) (*payrex.Refund, error)
+	UpdateFunc func(id string, params *payrex.RefundUpdateParams) (*payrex.Refund, error)
+
+	calls calls
+}
+
+var _ payrex.RefundsService = (*RefundsService)(nil)
+
+// Calls returns the calls of the mock's methods so far, from oldest to newest.
+func (m *RefundsService) Calls() []Call {
+	return m.calls.all()
+}
+
+// Create records the call and calls CreateFunc.
+func (m *RefundsService) Create(params *payrex.RefundCreateParams) (*payrex.Refund, error) {
+	m.calls.record("Refunds.Create", params)
+	if m.CreateFunc == nil {
+		err := notStubbed("Refunds.Create")
+		return nil, err
+	}
+	return m.CreateFunc(params)
+}
+
+// Update records the call and calls UpdateFunc.
+func (m *RefundsService) Update(id string, params *payrex.RefundUpdateParams) (*payrex.Refund, error) {
+	m.calls.record("Refunds.Update", id, params)
+	if m.UpdateFunc == nil {
+		err := notStubbed("Refunds.Update")
+		return nil, err
+	}
+	return m.UpdateFunc(id, params)
+}
+
+// WebhooksService is a mock of [payrex.WebhooksService]. Set the Func fields to stub its methods.
+// Methods without a stub return [ErrNotStubbed].
+type WebhooksService struct {
+	CreateFunc    func(params *payrex.WebhookCreateParams) (*payrex.Webhook, error)
+	RetrieveFunc  func(id string) (*payrex.Webhook, error)
+	ListFunc      func(params *payrex.WebhookListParams) iter.Seq2[*payrex.Webhook, error]
+	UpdateFunc    func(id string, params *payrex.WebhookUpdateParams) (*payrex.Webhook, error)
+	EnableFunc    func(id string) (*payrex.Webhook, error)
+	DisableFunc   func(id string) (*payrex.Webhook, error)
+	DeleteFunc    func(id string) (*payrex.DeletedResource, error)
+	PlanFunc      func(params *payrex.WebhookReconcileParams) (*payrex.WebhookPlan, error)
+	ApplyFunc     func(plan *payrex.WebhookPlan) error
+	ReconcileFunc func(params *payrex.WebhookReconcileParams) (*payrex.WebhookPlan, error)
+
+	calls calls
+}
+
+var _ payrex.WebhooksService = (*WebhooksService)(nil)
+
+// Calls returns the calls of the mock's methods so far, from oldest to newest.
+func (m *WebhooksService) Calls() []Call {
+	return m.calls.all()
+}
+
+// Create records the call and calls CreateFunc.
+func (m *WebhooksService) Create(params *payrex.WebhookCreateParams) (*payrex.Webhook, error) {
+	m.calls.record("Webhooks.Create", params)
+	if m.CreateFunc == nil {
+		err := notStubbed("Webhooks.Create")
+		return nil, err
+	}
+	return m.CreateFunc(params)
+}
+
+// Retrieve records the call and calls RetrieveFunc.
+func (m *WebhooksService) Retrieve(id string) (*payrex.Webhook, error) {
+	m.calls.record("Webhooks.Retrieve", id)
+	if m.RetrieveFunc == nil {
+		err := notStubbed("Webhooks.Retrieve")
+		return nil, err
+	}
+	return m.RetrieveFunc(id)
+}
+
+// List records the call and calls ListFunc.
+func (m *WebhooksService) List(params *payrex.WebhookListParams) iter.Seq2[*payrex.Webhook, error] {
+	m.calls.record("Webhooks.List", params)
+	if m.ListFunc == nil {
+		err := notStubbed("Webhooks.List")
+		return errSeq2[*payrex.Webhook](err)
+	}
+	return m.ListFunc(params)
+}
+
+// Update records the call and calls UpdateFunc.
+func (m *WebhooksService) Update(id string, params *payrex.WebhookUpdateParams) (*payrex.Webhook, error) {
+	m.calls.record("Webhooks.Update", id, params)
+	if m.UpdateFunc == nil {
+		err := notStubbed("Webhooks.Update")
+		return nil, err
+	}
+	return m.UpdateFunc(id, params)
+}
+
+// Enable records the call and calls EnableFunc.
+func (m *WebhooksService) Enable(id string) (*payrex.Webhook, error) {
+	m.calls.record("Webhooks.Enable", id)
+	if m.EnableFunc == nil {
+		err := notStubbed("Webhooks.Enable")
+		return nil, err
+	}
+	return m.EnableFunc(id)
+}
+
+// Disable records the call and calls DisableFunc.
+func (m *WebhooksService) Disable(id string) (*payrex.Webhook, error) {
+	m.calls.record("Webhooks.Disable", id)
+	if m.DisableFunc == nil {
+		err := notStubbed("Webhooks.Disable")
+		return nil, err
+	}
+	return m.DisableFunc(id)
+}
+
+// Delete records the call and calls DeleteFunc.
+func (m *WebhooksService) Delete(id string) (*payrex.DeletedResource, error) {
+	m.calls.record("Webhooks.Delete", id)
+	if m.DeleteFunc == nil {
+		err := notStubbed("Webhooks.Delete")
+		return nil, err
+	}
+	return m.DeleteFunc(id)
+}
+
+// Plan records the call and calls PlanFunc.
+func (m *WebhooksService) Plan(params *payrex.WebhookReconcileParams) (*payrex.WebhookPlan, error) {
+	m.calls.record("Webhooks.Plan", params)
+	if m.PlanFunc == nil {
+		err := notStubbed("Webhooks.Plan")
+		return nil, err
+	}
+	return m.PlanFunc(params)
+}
+
+// Apply records the call and calls ApplyFunc.
+func (m *WebhooksService) Apply(plan *payrex.WebhookPlan) error {
+	m.calls.record("Webhooks.Apply", plan)
+	if m.ApplyFunc == nil {
+		err := notStubbed("Webhooks.Apply")
+		return err
+	}
+	return m.ApplyFunc(plan)
+}
+
+// Reconcile records the call and calls ReconcileFunc.
+func (m *WebhooksService) Reconcile(params *payrex.WebhookReconcileParams) (*payrex.WebhookPlan, error) {
+	m.calls.record("Webhooks.Reconcile", params)
+	if m.ReconcileFunc == nil {
+		err := notStubbed("Webhooks.Reconcile")
+		return nil, err
+	}
+	return m.ReconcileFunc(params)
+}
@@ -0,0 +1,223 @@
+// Package payrextest provides utilities for testing code that uses payrex-go,
+// such as building signed webhook events.
+//
+// # Resource factories
+//
+// Functions such as [NewPaymentIntent] and [NewBillingStatement] build realistic resources
+// without a [Server]. Resources get random IDs with the same prefixes as PayRex, are created
+// at the current time in test mode, and have sensible defaults for everything else.
+// Use [SetFactoryClock] to create resources at a fixed time.
+// Overrides are applied in order after the defaults:
+//
+//	paymentIntent := payrextest.NewPaymentIntent(func(pi *payrex.PaymentIntent) {
+//		pi.Amount = 500_00
+//		pi.Description = payrex.NotNil("Order #123")
+//	})
+//
+// The NewXxxFor functions build resources related to another resource, copying its ID,
+// amount and mode, so the resources are consistent with each other:
+//
+//	payment := payrextest.NewPaymentFor(paymentIntent)
+//	refund := payrextest.NewRefundFor(payment)
+//
+// Related resources are built before the overrides are applied, so overriding the amount
+// of a resource doesn't update the resources related to it. Metadata is copied,
+// so changing the metadata of one resource doesn't change the other.
+package payrextest
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"time"
+
+	"github.com/angelofallars/payrex-go"
+)
+
+// EventResource is the set of resource types that can be the data of an [payrex.Event].
+type EventResource interface {
+	payrex.BillingStatement |
+		payrex.BillingStatementLineItem |
+		payrex.CheckoutSession |
+		payrex.PaymentIntent |
+		payrex.Payout |
+		payrex.Refund
+}
+
+// Event is a webhook event that can be encoded into the payload of a PayRex webhook request.
+//
+// Create one using [NewEvent].
+type Event struct {
+	// Unique identifier of the event.
+	ID string
+	// The type of the event.
+	Type payrex.EventType
+	// Whether the event happened in live mode. Also decides which signature
+	// of the 'Payrex-Signature' header is set when signing the event.
+	Livemode bool
+	// The number of webhooks that have yet to receive the event.
+	PendingWebhooks int
+	// The values of the resource's attributes before the event, for update events.
+	PreviousAttributes map[string]any
+	// The time the event was created.
+	CreatedAt time.Time
+	// The resource of the event. Events loaded using [Server.LoadState]
+	// have the raw JSON of the resource as a [json.RawMessage].
+	Data any
+
+	resourceType payrex.EventResourceType
+}
+
+// NewEvent creates a new [Event] of the given type with the resource as its data.
+//
+// The event ID is randomly generated, and the event's Livemode is copied from the resource.
+//
+// NewEvent panics if the resource type doesn't match the event type, e.g. a [payrex.Refund]
+// for a 'payment_intent.succeeded' event, as that is a mistake in the test itself.
+func NewEvent[T EventResource](eventType payrex.EventType, resource *T) *Event {
+	resourceType, resourceBase := eventResourceInfo(resource)
+
+	if !strings.HasPrefix(string(eventType), string(resourceType)+".") {
+		panic(fmt.Sprintf("payrextest: event type '%s' does not match resource type '%s'", eventType, resourceType))
+	}
+
+	return &Event{
+		ID:           NewID("evt"),
+		Type:         eventType,
+		Livemode:     resourceBase.Livemode,
+		CreatedAt:    factoryNow(),
+		Data:         resource,
+		resourceType: resourceType,
+	}
+}
+
+// eventResourceInfo returns the event resource type name and the common fields of a resource.
+func eventResourceInfo(resource any) (payrex.EventResourceType, payrex.Resource) {
+	switch r := resource.(type) {
+	case *payrex.BillingStatement:
+		return "billing_statement", r.Resource
+	case *payrex.BillingStatementLineItem:
+		return "billing_statement_line_item", r.Resource
+	case *payrex.CheckoutSession:
+		return "checkout_session", r.Resource
+	case *payrex.PaymentIntent:
+		return "payment_intent", r.Resource
+	case *payrex.Payout:
+		return "payout", r.Resource
+	case *payrex.Refund:
+		return "refund", r.Resource
+	default:
+		panic(fmt.Sprintf("payrextest: unsupported event resource type '%T'", resource))
+	}
+}
+
+// Payload returns the JSON payload of a webhook request delivering the event,
+// in the same shape as the payloads sent by PayRex.
+func (e *Event) Payload() ([]byte, error) {
+	data, err := json.Marshal(e.Data)
+	if err != nil {
+		return nil, fmt.Errorf("could not encode event data: %w", err)
+	}
+
+	var dataFields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &dataFields); err != nil {
+		return nil, fmt.Errorf("could not encode event data: %w", err)
+	}
+	// Data is nil for events built without a resource
+	if dataFields == nil {
+		dataFields = map[string]json.RawMessage{}
+	}
+	dataFields["resource"], _ = json.Marshal(e.resourceType)
+
+	previousAttributes := e.PreviousAttributes
+	if previousAttributes == nil {
+		previousAttributes = map[string]any{}
+	}
+
+	createdAt := e.CreatedAt.Unix()
+
+	payload, err := json.Marshal(map[string]any{
+		"id":                  e.ID,
+		"resource":            "event",
+		"type":                e.Type,
+		"livemode":            e.Livemode,
+		"pending_webhooks":    e.PendingWebhooks,
+		"previous_attributes": previousAttributes,
+		"data":                dataFields,
+		"created_at":          createdAt,
+		"updated_at":          createdAt,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("could not encode event: %w", err)
+	}
+
+	return payload, nil
+}
+
+// MustPayload returns the JSON payload of the event, or panics if it can't be encoded.
+func (e *Event) MustPayload() []byte {
+	payload, err := e.Payload()
+	if err != nil {
+		panic(err)
+	}
+	return payload
+}
+
+// mode returns the mode the event is signed in.
+func (e *Event) mode() payrex.Mode {
+	if e.Livemode {
+		return payrex.ModeLive
+	}
+	return payrex.ModeTest
+}
+
+// SignatureHeader returns the 'Payrex-Signature' header value for the event,
+// signed with the webhook secret key at the given time.
+func (e *Event) SignatureHeader(webhookSecretKey string, timestamp time.Time) string {
+	return SignatureHeader(e.MustPayload(), webhookSecretKey, e.mode(), timestamp)
+}
+
+// Request returns a webhook request delivering the event to the target URL,
+// signed with the webhook secret key at the current time.
+//
+// The request is meant to be passed to an [http.Handler], like requests from [httptest.NewRequest].
+func (e *Event) Request(target, webhookSecretKey string) *http.Request {
+	return e.RequestAt(target, webhookSecretKey, time.Now())
+}
+
+// RequestAt returns a webhook request delivering the event to the target URL,
+// signed with the webhook secret key at the given time.
+func (e *Event) RequestAt(target, webhookSecretKey string, timestamp time.Time) *http.Request {
+	return NewWebhookRequest(target, e.MustPayload(), webhookSecretKey, e.mode(), timestamp)
+}
+
+// SignatureHeader returns a 'Payrex-Signature' header value for the payload, signed with the
+// webhook secret key at the given time, in the same format as the headers sent by PayRex.
+//
+// Only the signature of the given mode is set; the other signature is left empty.
+func SignatureHeader(payload []byte, webhookSecretKey string, mode payrex.Mode, timestamp time.Time) string {
+	signature := payrex.WebhookSignature{Timestamp: timestamp.Unix()}
+
+	sig := payrex.ComputeWebhookSignature(payload, signature.Timestamp, webhookSecretKey)
+	if mode == payrex.ModeLive {
+		signature.LiveModeSignature = sig
+	} else {
+		signature.TestModeSignature = sig
+	}
+
+	return signature.String()
+}
+
+// NewWebhookRequest returns a webhook request delivering the payload to the target URL,
+// signed with the webhook secret key at the given time.
+//
+// The request is meant to be passed to an [http.Handler], like requests from [httptest.NewRequest].
+func NewWebhookRequest(target string, payload []byte, webhookSecretKey string, mode payrex.Mode, timestamp time.Time) *http.Request {
+	r := httptest.NewRequest(http.MethodPost, target, bytes.NewReader(payload))
+	r.Header.Set("Content-Type", "application/json")
+	r.Header.Set(payrex.WebhookSignatureHeader, SignatureHeader(payload, webhookSecretKey, mode, timestamp))
+	return r
+}
@@ -0,0 +1,38 @@
+package payrextest
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/angelofallars/payrex-go"
+)
+
+func TestEventPayloadWithoutData(t *testing.T) {
+	event := NewEvent(payrex.EventTypePaymentIntentSucceeded, NewPaymentIntent())
+	event.Data = nil
+
+	payload, err := event.Payload()
+	if err != nil {
+		t.Fatalf("got error %v, want nil", err)
+	}
+
+	var decoded struct {
+		Data map[string]any `json:"data"`
+	}
+	if err := json.Unmarshal(payload, &decoded); err != nil {
+		t.Fatalf("could not decode payload: %v", err)
+	}
+	if decoded.Data["resource"] != "payment_intent" {
+		t.Errorf("got data %v, want only the resource type", decoded.Data)
+	}
+}
+
+func TestNewEventResourceMismatch(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("got no panic for a refund in a 'payment_intent.succeeded' event, want a panic")
+		}
+	}()
+
+	NewEvent(payrex.EventTypePaymentIntentSucceeded, NewRefund())
+}
@@ -0,0 +1,348 @@
+package payrextest
+
+import (
+	"maps"
+	"slices"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/angelofallars/payrex-go"
+)
+
+// Default values of the resources created by the factory functions.
+const (
+	defaultAmount   = 100_00
+	defaultCurrency = payrex.CurrencyPHP
+)
+
+// defaultPaymentMethods are the payment methods of the resources created by the factory functions.
+var defaultPaymentMethods = []payrex.PaymentMethod{payrex.PaymentMethodCard, payrex.PaymentMethodGCash}
+
+// defaultBilling is the billing details of the payments created by the factory functions.
+var defaultBilling = payrex.Billing{
+	Name:  "Juan Dela Cruz",
+	Email: "juan@example.com",
+	Address: payrex.Address{
+		Line1:      "1 Ayala Avenue",
+		City:       "Makati",
+		State:      "Metro Manila",
+		PostalCode: "1226",
+		Country:    "PH",
+	},
+}
+
+// factoryClock is the clock of the factory functions set using [SetFactoryClock], if any.
+var factoryClock atomic.Pointer[func() time.Time]
+
+// SetFactoryClock makes the factory functions, such as [NewPaymentIntent] and [NewEvent],
+// create resources at the times returned by now instead of the current time,
+// until the test finishes.
+//
+// The clock is shared by the whole package, so tests calling SetFactoryClock must not
+// run in parallel with other tests using the factory functions.
+func SetFactoryClock(tb testing.TB, now func() time.Time) {
+	factoryClock.Store(&now)
+	tb.Cleanup(func() { factoryClock.Store(nil) })
+}
+
+// factoryNow returns the current time of the clock of the factory functions.
+func factoryNow() time.Time {
+	if now := factoryClock.Load(); now != nil {
+		return (*now)()
+	}
+	return time.Now()
+}
+
+// NewPaymentIntent returns a new payment intent awaiting a payment method,
+// for ₱100.00 paid with a card or GCash.
+func NewPaymentIntent(overrides ...func(*payrex.PaymentIntent)) *payrex.PaymentIntent {
+	resource := newTestResource("pi")
+
+	return apply(&payrex.PaymentIntent{
+		Resource:       resource,
+		Amount:         defaultAmount,
+		ClientSecret:   newClientSecret(resource.ID),
+		Currency:       defaultCurrency,
+		PaymentMethods: slices.Clone(defaultPaymentMethods),
+		Status:         payrex.PaymentIntentStatusAwaitingPaymentMethod,
+	}, overrides)
+}
+
+// NewPayment returns a new paid payment of a new succeeded payment intent.
+func NewPayment(overrides ...func(*payrex.Payment)) *payrex.Payment {
+	return NewPaymentFor(NewPaymentIntent(succeeded), overrides...)
+}
+
+// NewPaymentFor returns a new paid payment of the full amount of the payment intent,
+// paid with its first payment method.
+func NewPaymentFor(paymentIntent *payrex.PaymentIntent, overrides ...func(*payrex.Payment)) *payrex.Payment {
+	payment := &payrex.Payment{
+		Resource:        newTestResourceFor(paymentIntent.Resource, "pay"),
+		Amount:          paymentIntent.Amount,
+		Billing:         defaultBilling,
+		Currency:        paymentIntent.Currency,
+		Description:     paymentIntent.Description,
+		Metadata:        maps.Clone(paymentIntent.Metadata),
+		NetAmount:       paymentIntent.Amount,
+		PaymentIntentID: paymentIntent.ID,
+		Status:          payrex.PaymentStatusPaid,
+	}
+	if len(paymentIntent.PaymentMethods) > 0 {
+		payment.PaymentMethod = payrex.PaymentMethodType{Type: paymentIntent.PaymentMethods[0]}
+	}
+
+	return apply(payment, overrides)
+}
+
+// NewRefund returns a new succeeded refund of a new payment.
+func NewRefund(overrides ...func(*payrex.Refund)) *payrex.Refund {
+	return NewRefundFor(NewPayment(), overrides...)
+}
+
+// NewRefundFor returns a new succeeded refund of the full amount of the payment,
+// requested by the customer.
+func NewRefundFor(payment *payrex.Payment, overrides ...func(*payrex.Refund)) *payrex.Refund {
+	return apply(&payrex.Refund{
+		Resource:  newTestResourceFor(payment.Resource, "re"),
+		Amount:    payment.Amount,
+		Currency:  payment.Currency,
+		Status:    payrex.RefundStatusSucceeded,
+		Reason:    payrex.RefundReasonRequestedByCustomer,
+		PaymentID: payment.ID,
+	}, overrides)
+}
+
+// NewCheckoutSession returns a new active checkout session for one ₱100.00 item,
+// with a payment intent for its amount. The payment intent follows the mode of the
+// checkout session if it's overridden.
+func NewCheckoutSession(overrides ...func(*payrex.CheckoutSession)) *payrex.CheckoutSession {
+	resource := newTestResource("cs")
+	clientSecret := newClientSecret(resource.ID)
+
+	lineItems := []payrex.CheckoutSessionLineItem{{
+		ID:       NewID("cs_li"),
+		Name:     "Item",
+		Amount:   defaultAmount,
+		Quantity: 1,
+	}}
+
+	paymentIntent := NewPaymentIntent()
+
+	checkoutSession := apply(&payrex.CheckoutSession{
+		Resource:                 resource,
+		URL:                      "https://checkout.payrexhq.com/c/" + clientSecret,
+		BillingDetailsCollection: "always",
+		ClientSecret:             clientSecret,
+		Status:                   payrex.CheckoutSessionStatusActive,
+		Currency:                 paymentIntent.Currency,
+		LineItems:                lineItems,
+		PaymentIntent:            paymentIntent,
+		SuccessURL:               "https://example.com/success",
+		CancelURL:                "https://example.com/cancel",
+		PaymentMethods:           slices.Clone(paymentIntent.PaymentMethods),
+		SubmitType:               "pay",
+		ExpiresAt:                int(factoryNow().Add(defaultCheckoutSessionLifetime).Unix()),
+	}, overrides)
+
+	followMode(paymentIntent, checkoutSession.PaymentIntent, checkoutSession.Livemode)
+	return checkoutSession
+}
+
+// NewCustomer returns a new customer billed in pesos.
+func NewCustomer(overrides ...func(*payrex.Customer)) *payrex.Customer {
+	return apply(&payrex.Customer{
+		Resource:                           newTestResource("cus"),
+		BillingStatementPrefix:             strings.ToUpper(randomString(8)),
+		Currency:                           defaultCurrency,
+		Email:                              defaultBilling.Email,
+		Name:                               defaultBilling.Name,
+		NextBillingStatementSequenceNumber: "1",
+	}, overrides)
+}
+
+// NewCustomerSession returns a new customer session of a new customer.
+func NewCustomerSession(overrides ...func(*payrex.CustomerSession)) *payrex.CustomerSession {
+	return NewCustomerSessionFor(NewCustomer(), overrides...)
+}
+
+// NewCustomerSessionFor returns a new customer session of the customer, valid for an hour.
+func NewCustomerSessionFor(customer *payrex.Customer, overrides ...func(*payrex.CustomerSession)) *payrex.CustomerSession {
+	resource := newTestResourceFor(customer.Resource, "cuss")
+
+	return apply(&payrex.CustomerSession{
+		Resource:     resource,
+		CustomerID:   customer.ID,
+		ClientSecret: newClientSecret(resource.ID),
+		Components:   []payrex.CustomerSessionComponent{},
+		ExpiredAt:    int(factoryNow().Add(customerSessionLifetime).Unix()),
+	}, overrides)
+}
+
+// NewBillingStatement returns a new open billing statement of a new customer,
+// with one ₱100.00 line item and a payment intent for its amount.
+func NewBillingStatement(overrides ...func(*payrex.BillingStatement)) *payrex.BillingStatement {
+	billingStatement := NewBillingStatementFor(NewCustomer())
+	NewBillingStatementLineItemFor(billingStatement)
+
+	paymentIntent := billingStatement.PaymentIntent
+	billingStatement = apply(billingStatement, overrides)
+
+	followMode(paymentIntent, billingStatement.PaymentIntent, billingStatement.Livemode)
+	return billingStatement
+}
+
+// NewBillingStatementFor returns a new open billing statement of the customer without line items.
+// Add line items to it using [NewBillingStatementLineItemFor]. The payment intent of the
+// billing statement follows its mode if it's overridden.
+func NewBillingStatementFor(customer *payrex.Customer, overrides ...func(*payrex.BillingStatement)) *payrex.BillingStatement {
+	resource := newTestResourceFor(customer.Resource, "bstm")
+
+	paymentIntent := NewPaymentIntent(func(pi *payrex.PaymentIntent) {
+		pi.Livemode = resource.Livemode
+		pi.Amount = 0
+		pi.Currency = customer.Currency
+	})
+
+	billingStatement := apply(&payrex.BillingStatement{
+		Resource:                 resource,
+		Status:                   payrex.BillingStatementStatusOpen,
+		Currency:                 customer.Currency,
+		LineItems:                []billingStatementLineItemSummary{},
+		PaymentIntent:            paymentIntent,
+		BillingDetailsCollection: "always",
+		CustomerID:               customer.ID,
+		URL:                      payrex.NotNil("https://bill.payrexhq.com/b/" + resource.ID),
+		PaymentSettings:          payrex.PaymentSettings{PaymentMethods: slices.Clone(paymentIntent.PaymentMethods)},
+	}, overrides)
+
+	followMode(paymentIntent, billingStatement.PaymentIntent, billingStatement.Livemode)
+	return billingStatement
+}
+
+// NewBillingStatementLineItem returns a new line item of a new billing statement.
+func NewBillingStatementLineItem(overrides ...func(*payrex.BillingStatementLineItem)) *payrex.BillingStatementLineItem {
+	return NewBillingStatementLineItemFor(NewBillingStatementFor(NewCustomer()), overrides...)
+}
+
+// NewBillingStatementLineItemFor returns a new ₱100.00 line item of the billing statement.
+//
+// The line item is also added to the line items of the billing statement, and its price
+// is added to the amount of the billing statement and its payment intent.
+func NewBillingStatementLineItemFor(billingStatement *payrex.BillingStatement, overrides ...func(*payrex.BillingStatementLineItem)) *payrex.BillingStatementLineItem {
+	resource := newTestResourceFor(billingStatement.Resource, "bstm_li")
+
+	lineItem := apply(&payrex.BillingStatementLineItem{
+		Resource:           resource,
+		SecretKey:          newClientSecret(resource.ID),
+		BillingStatementID: billingStatement.ID,
+		Description:        "Item",
+		UnitPrice:          defaultAmount,
+		Quantity:           1,
+	}, overrides)
+
+	billingStatement.LineItems = append(billingStatement.LineItems, billingStatementLineItemSummary{
+		ID:                 lineItem.ID,
+		BillingStatementID: lineItem.BillingStatementID,
+		Description:        lineItem.Description,
+		UnitPrice:          lineItem.UnitPrice,
+		Quantity:           lineItem.Quantity,
+	})
+	billingStatement.Amount += lineItem.UnitPrice * lineItem.Quantity
+	if billingStatement.PaymentIntent != nil {
+		billingStatement.PaymentIntent.Amount = billingStatement.Amount
+	}
+
+	return lineItem
+}
+
+// NewPayout returns a new successful payout of ₱100.00.
+func NewPayout(overrides ...func(*payrex.Payout)) *payrex.Payout {
+	return apply(&payrex.Payout{
+		Resource:    newTestResource("po"),
+		Amount:      defaultAmount,
+		Destination: payoutDestination,
+		NetAmount:   defaultAmount,
+		Status:      payrex.PayoutStatusSuccessful,
+	}, overrides)
+}
+
+// NewPayoutTransaction returns a new payout transaction of a new payment.
+func NewPayoutTransaction(overrides ...func(*payrex.PayoutTransaction)) *payrex.PayoutTransaction {
+	return NewPayoutTransactionFor(NewPayment(), overrides...)
+}
+
+// NewPayoutTransactionFor returns a new payout transaction paying out the payment.
+func NewPayoutTransactionFor(payment *payrex.Payment, overrides ...func(*payrex.PayoutTransaction)) *payrex.PayoutTransaction {
+	now := int(factoryNow().Unix())
+
+	return apply(&payrex.PayoutTransaction{
+		ID:              NewID("po_txn"),
+		Amount:          payment.Amount,
+		NetAmount:       payment.NetAmount,
+		TransactionType: payrex.PayoutTransactionTypePayment,
+		TransactionID:   payment.ID,
+		CreatedAt:       now,
+		UpdatedAt:       now,
+	}, overrides)
+}
+
+// NewWebhook returns a new enabled webhook listening to succeeded payment intents.
+func NewWebhook(overrides ...func(*payrex.Webhook)) *payrex.Webhook {
+	return apply(&payrex.Webhook{
+		Resource:  newTestResource("wh"),
+		SecretKey: NewID("whsk"),
+		Status:    payrex.WebhookStatusEnabled,
+		URL:       "https://example.com/webhooks/payrex",
+		Events:    []payrex.EventType{payrex.EventTypePaymentIntentSucceeded},
+	}, overrides)
+}
+
+// followMode sets the mode of a payment intent built by a factory function to the mode
+// of the resource it belongs to, unless an override replaced the payment intent.
+func followMode(built, current *payrex.PaymentIntent, livemode bool) {
+	if built != nil && current == built {
+		built.Livemode = livemode
+	}
+}
+
+// succeeded makes a payment intent succeeded, with its full amount received.
+func succeeded(paymentIntent *payrex.PaymentIntent) {
+	paymentIntent.Status = payrex.PaymentIntentStatusSucceeded
+	paymentIntent.AmountReceived = paymentIntent.Amount
+	paymentIntent.PaymentMethodID = payrex.NotNil(NewID("pm"))
+}
+
+// newTestResource returns the common fields of a new test mode resource
+// created at the current time of the factory clock.
+func newTestResource(idPrefix string) payrex.Resource {
+	now := int(factoryNow().Unix())
+
+	return payrex.Resource{
+		ID:        NewID(idPrefix),
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+}
+
+// newTestResourceFor returns the common fields of a new resource
+// created now, in the same mode as the related resource.
+func newTestResourceFor(related payrex.Resource, idPrefix string) payrex.Resource {
+	resource := newTestResource(idPrefix)
+	resource.Livemode = related.Livemode
+	return resource
+}
+
+// newClientSecret returns a new client secret of the resource with the ID.
+func newClientSecret(id string) string {
+	return id + "_secret_" + randomString(idLength)
+}
+
+// apply applies the overrides to the value in order, and returns it.
+func apply[T any](v *T, overrides []func(*T)) *T {
+	for _, override := range overrides {
+		override(v)
+	}
+	return v
+}
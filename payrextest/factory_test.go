@@ -0,0 +1,108 @@
+package payrextest
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/angelofallars/payrex-go"
+)
+
+func TestFactoryRelatedResources(t *testing.T) {
+	paymentIntent := NewPaymentIntent(succeeded, func(pi *payrex.PaymentIntent) {
+		pi.Livemode = true
+		pi.Amount = 500_00
+	})
+	payment := NewPaymentFor(paymentIntent)
+	refund := NewRefundFor(payment)
+
+	if payment.PaymentIntentID != paymentIntent.ID || payment.Amount != 500_00 || !payment.Livemode {
+		t.Errorf("got payment of %s for %d in live mode: %t, want a payment of %s for 50000 in live mode",
+			payment.PaymentIntentID, payment.Amount, payment.Livemode, paymentIntent.ID)
+	}
+	if refund.PaymentID != payment.ID || refund.Amount != 500_00 || !refund.Livemode {
+		t.Errorf("got refund of %s for %d in live mode: %t, want a refund of %s for 50000 in live mode",
+			refund.PaymentID, refund.Amount, refund.Livemode, payment.ID)
+	}
+	if !strings.HasPrefix(payment.ID, "pay_") || !strings.HasPrefix(refund.ID, "re_") {
+		t.Errorf("got IDs '%s' and '%s', want IDs prefixed with 'pay_' and 're_'", payment.ID, refund.ID)
+	}
+}
+
+func TestNewPaymentForCopiesMetadata(t *testing.T) {
+	paymentIntent := NewPaymentIntent(func(pi *payrex.PaymentIntent) {
+		pi.Metadata = payrex.Metadata{"order_id": "ord_123"}
+	})
+	payment := NewPaymentFor(paymentIntent)
+
+	payment.Metadata["order_id"] = "ord_456"
+	if paymentIntent.Metadata["order_id"] != "ord_123" {
+		t.Errorf("got payment intent metadata %v after changing the payment's, want it unchanged", paymentIntent.Metadata)
+	}
+}
+
+func TestFactoryPaymentIntentFollowsMode(t *testing.T) {
+	live := func(r *payrex.Resource) { r.Livemode = true }
+
+	checkoutSession := NewCheckoutSession(func(cs *payrex.CheckoutSession) { live(&cs.Resource) })
+	if !checkoutSession.PaymentIntent.Livemode {
+		t.Error("got a test mode payment intent for a live mode checkout session")
+	}
+
+	billingStatement := NewBillingStatement(func(bs *payrex.BillingStatement) { live(&bs.Resource) })
+	if !billingStatement.PaymentIntent.Livemode {
+		t.Error("got a test mode payment intent for a live mode billing statement")
+	}
+
+	billingStatement = NewBillingStatementFor(NewCustomer(), func(bs *payrex.BillingStatement) { live(&bs.Resource) })
+	if !billingStatement.PaymentIntent.Livemode {
+		t.Error("got a test mode payment intent for a live mode billing statement of a customer")
+	}
+
+	replaced := NewPaymentIntent()
+	checkoutSession = NewCheckoutSession(func(cs *payrex.CheckoutSession) {
+		live(&cs.Resource)
+		cs.PaymentIntent = replaced
+	})
+	if checkoutSession.PaymentIntent.Livemode {
+		t.Error("got the mode of a replaced payment intent changed, want it kept")
+	}
+}
+
+func TestNewBillingStatementLineItemFor(t *testing.T) {
+	billingStatement := NewBillingStatementFor(NewCustomer())
+	NewBillingStatementLineItemFor(billingStatement)
+	NewBillingStatementLineItemFor(billingStatement, func(li *payrex.BillingStatementLineItem) {
+		li.UnitPrice = 50_00
+		li.Quantity = 2
+	})
+
+	if len(billingStatement.LineItems) != 2 {
+		t.Fatalf("got %d line items, want 2", len(billingStatement.LineItems))
+	}
+	if billingStatement.Amount != 200_00 || billingStatement.PaymentIntent.Amount != 200_00 {
+		t.Errorf("got amount %d with a payment intent for %d, want 20000 for both",
+			billingStatement.Amount, billingStatement.PaymentIntent.Amount)
+	}
+}
+
+func TestSetFactoryClock(t *testing.T) {
+	now := time.Date(2025, time.January, 1, 0, 0, 0, 0, time.UTC)
+	SetFactoryClock(t, func() time.Time { return now })
+
+	paymentIntent := NewPaymentIntent()
+	if paymentIntent.CreatedAt != int(now.Unix()) || paymentIntent.UpdatedAt != int(now.Unix()) {
+		t.Errorf("got payment intent created at %d and updated at %d, want %d",
+			paymentIntent.CreatedAt, paymentIntent.UpdatedAt, now.Unix())
+	}
+
+	checkoutSession := NewCheckoutSession()
+	if want := int(now.Add(defaultCheckoutSessionLifetime).Unix()); checkoutSession.ExpiresAt != want {
+		t.Errorf("got checkout session expiring at %d, want %d", checkoutSession.ExpiresAt, want)
+	}
+
+	event := NewEvent(payrex.EventTypePaymentIntentSucceeded, paymentIntent)
+	if !event.CreatedAt.Equal(now) {
+		t.Errorf("got event created at %s, want %s", event.CreatedAt, now)
+	}
+}
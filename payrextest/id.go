@@ -0,0 +1,31 @@
+package payrextest
+
+import (
+	"crypto/rand"
+	"math/big"
+)
+
+const idAlphabet = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
+
+// idLength is the length of the random part of a resource ID.
+const idLength = 32
+
+// NewID returns a random resource ID with the given prefix,
+// in the same format as PayRex resource IDs, e.g. NewID("pi") returns "pi_<random>".
+func NewID(prefix string) string {
+	return prefix + "_" + randomString(idLength)
+}
+
+// randomString returns a random alphanumeric string of the given length.
+func randomString(length int) string {
+	b := make([]byte, length)
+	for i := range b {
+		n, err := rand.Int(rand.Reader, big.NewInt(int64(len(idAlphabet))))
+		if err != nil {
+			panic(err)
+		}
+		b[i] = idAlphabet[n.Int64()]
+	}
+
+	return string(b)
+}
@@ -0,0 +1,402 @@
+package payrextest
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/angelofallars/payrex-go"
+)
+
+// ErrNoRecordedInteraction is returned by a replaying [Recorder] for requests that don't match
+// any unused interaction of its cassette.
+var ErrNoRecordedInteraction = errors.New("no recorded interaction matches the request")
+
+// RecordEnv is the environment variable that makes [NewRecorderClient] record cassettes
+// from the real PayRex API instead of replaying them, when set to a non-empty value.
+const RecordEnv = "PAYREX_RECORD"
+
+// redacted replaces the secrets in recorded cassettes.
+const redacted = "REDACTED"
+
+// Keys of the JSON fields of responses that are redacted in recorded cassettes.
+var redactedFields = []string{"client_secret", "secret_key"}
+
+// RecorderMode decides whether a [Recorder] records or replays requests.
+type RecorderMode int
+
+const (
+	// RecorderModeReplay replays the responses recorded in the cassette without using the network.
+	RecorderModeReplay RecorderMode = iota
+	// RecorderModeRecord sends requests to the PayRex API, and records them in the cassette.
+	RecorderModeRecord
+)
+
+// Cassette is a list of recorded request and response pairs, stored as JSON.
+type Cassette struct {
+	Interactions []*Interaction `json:"interactions"`
+}
+
+// Interaction is a recorded request and its response.
+type Interaction struct {
+	Request  RecordedRequest  `json:"request"`
+	Response RecordedResponse `json:"response"`
+}
+
+// RecordedRequest is a recorded request. Requests are matched by method, path and form values.
+type RecordedRequest struct {
+	Method string `json:"method"`
+	Path   string `json:"path"`
+	// The form values of the request, from both its query parameters and body.
+	Form url.Values `json:"form,omitempty"`
+}
+
+// RecordedResponse is a recorded response.
+type RecordedResponse struct {
+	StatusCode  int    `json:"status_code"`
+	ContentType string `json:"content_type,omitempty"`
+	// The body of the response. Bodies that aren't valid JSON are stored as JSON strings.
+	Body json.RawMessage `json:"body"`
+}
+
+// Recorder is an [http.RoundTripper] that records requests to the PayRex API and their
+// responses in a [Cassette], and replays them later in tests without using the network.
+//
+// API keys, client secrets and webhook secret keys are redacted from recorded cassettes,
+// since they are meant to be committed. Replayed requests match the first unused interaction
+// with the same method, path and form values. Requests without a match fail with
+// [ErrNoRecordedInteraction].
+//
+// Use it with [payrex.Client.WithHTTPClient], or use [NewRecorderClient] in tests.
+type Recorder struct {
+	mode      RecorderMode
+	path      string
+	transport http.RoundTripper
+
+	mu        sync.Mutex
+	cassette  Cassette
+	used      []bool
+	unmatched []string
+}
+
+// NewRecorder creates a new [Recorder] for the cassette file at the path.
+//
+// In [RecorderModeReplay], the cassette file is read and must exist. In [RecorderModeRecord],
+// the cassette starts empty and replaces the file when [Recorder.Save] is called.
+func NewRecorder(cassettePath string, mode RecorderMode) (*Recorder, error) {
+	r := &Recorder{
+		mode:      mode,
+		path:      cassettePath,
+		transport: http.DefaultTransport,
+	}
+
+	if mode == RecorderModeReplay {
+		data, err := os.ReadFile(cassettePath)
+		if err != nil {
+			return nil, fmt.Errorf("could not read cassette: %w", err)
+		}
+		if err := json.Unmarshal(data, &r.cassette); err != nil {
+			return nil, fmt.Errorf("could not decode cassette '%s': %w", cassettePath, err)
+		}
+		r.used = make([]bool, len(r.cassette.Interactions))
+	}
+
+	return r, nil
+}
+
+// NewRecorderClient returns a [payrex.Client] that replays the cassette at the path,
+// failing the test if a request doesn't match any recorded interaction.
+//
+// When the [RecordEnv] environment variable is set, requests are sent to the PayRex API
+// using the API key instead, and recorded to the cassette when the test finishes.
+// The API key is not used when replaying, so it can be empty in CI.
+//
+//	func TestCheckout(t *testing.T) {
+//		client := payrextest.NewRecorderClient(t, "testdata/checkout.json", os.Getenv("PAYREX_API_KEY"))
+//		// ...
+//	}
+func NewRecorderClient(tb testing.TB, cassettePath, apiKey string) *payrex.Client {
+	tb.Helper()
+
+	mode := RecorderModeReplay
+	if os.Getenv(RecordEnv) != "" {
+		mode = RecorderModeRecord
+	}
+
+	r, err := NewRecorder(cassettePath, mode)
+	if err != nil {
+		tb.Fatalf("payrextest: %v (set %s=1 to record it)", err, RecordEnv)
+	}
+
+	tb.Cleanup(func() {
+		if err := r.Save(); err != nil {
+			tb.Errorf("payrextest: %v", err)
+		}
+		for _, request := range r.Unmatched() {
+			tb.Errorf("payrextest: %v: %s", ErrNoRecordedInteraction, request)
+		}
+	})
+
+	if mode == RecorderModeReplay {
+		apiKey = NewID("sk_test")
+	}
+
+	return payrex.NewClient(apiKey).WithHTTPClient(&http.Client{Transport: r})
+}
+
+// WithTransport replaces the transport used to send requests when recording.
+// Defaults to [http.DefaultTransport].
+func (r *Recorder) WithTransport(transport http.RoundTripper) *Recorder {
+	r.transport = transport
+	return r
+}
+
+// Cassette returns the interactions recorded or loaded so far.
+func (r *Recorder) Cassette() *Cassette {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return &Cassette{Interactions: slices.Clone(r.cassette.Interactions)}
+}
+
+// Unmatched returns the requests replayed so far that didn't match any recorded interaction,
+// formatted as "<method> <path> <form>".
+func (r *Recorder) Unmatched() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return slices.Clone(r.unmatched)
+}
+
+// Save writes the recorded cassette to its file. Does nothing when replaying.
+func (r *Recorder) Save() error {
+	if r.mode != RecorderModeRecord {
+		return nil
+	}
+
+	r.mu.Lock()
+	data, err := json.MarshalIndent(&r.cassette, "", "  ")
+	r.mu.Unlock()
+	if err != nil {
+		return fmt.Errorf("could not encode cassette: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(r.path), 0o755); err != nil {
+		return fmt.Errorf("could not create cassette directory: %w", err)
+	}
+	if err := os.WriteFile(r.path, append(data, '\n'), 0o644); err != nil {
+		return fmt.Errorf("could not write cassette: %w", err)
+	}
+
+	return nil
+}
+
+// RoundTrip implements [http.RoundTripper].
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	recorded, err := recordRequest(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if r.mode == RecorderModeRecord {
+		return r.record(req, recorded)
+	}
+	return r.replay(req, recorded)
+}
+
+func (r *Recorder) record(req *http.Request, recorded *RecordedRequest) (*http.Response, error) {
+	res, err := r.transport.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := io.ReadAll(res.Body)
+	_ = res.Body.Close()
+	if err != nil {
+		return nil, fmt.Errorf("could not read response body: %w", err)
+	}
+	res.Body = io.NopCloser(bytes.NewReader(body))
+
+	recordedBody, err := redactBody(body)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	r.cassette.Interactions = append(r.cassette.Interactions, &Interaction{
+		Request: *recorded,
+		Response: RecordedResponse{
+			StatusCode:  res.StatusCode,
+			ContentType: res.Header.Get("Content-Type"),
+			Body:        recordedBody,
+		},
+	})
+	r.mu.Unlock()
+
+	return res, nil
+}
+
+func (r *Recorder) replay(req *http.Request, recorded *RecordedRequest) (*http.Response, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i, interaction := range r.cassette.Interactions {
+		if r.used[i] || !interaction.Request.matches(recorded) {
+			continue
+		}
+		r.used[i] = true
+
+		return interaction.Response.httpResponse(req)
+	}
+
+	description := recorded.String()
+	r.unmatched = append(r.unmatched, description)
+
+	return nil, fmt.Errorf("%w: %s", ErrNoRecordedInteraction, description)
+}
+
+// recordRequest returns the recorded form of a request, keeping its body readable.
+func recordRequest(req *http.Request) (*RecordedRequest, error) {
+	form := req.URL.Query()
+
+	if req.Body != nil && req.Body != http.NoBody {
+		body, err := io.ReadAll(req.Body)
+		_ = req.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("could not read request body: %w", err)
+		}
+		req.Body = io.NopCloser(bytes.NewReader(body))
+
+		bodyForm, err := url.ParseQuery(string(body))
+		if err != nil {
+			return nil, fmt.Errorf("could not decode request body: %w", err)
+		}
+		for key, values := range bodyForm {
+			form[key] = append(form[key], values...)
+		}
+	}
+
+	for key, values := range form {
+		for i, value := range values {
+			values[i] = redactString(value)
+		}
+		form[key] = values
+	}
+	if len(form) == 0 {
+		form = nil
+	}
+
+	return &RecordedRequest{
+		Method: req.Method,
+		Path:   req.URL.Path,
+		Form:   form,
+	}, nil
+}
+
+func (rr *RecordedRequest) matches(other *RecordedRequest) bool {
+	return rr.Method == other.Method && rr.Path == other.Path &&
+		maps.EqualFunc(rr.Form, other.Form, slices.Equal)
+}
+
+func (rr *RecordedRequest) String() string {
+	s := rr.Method + " " + rr.Path
+	if len(rr.Form) > 0 {
+		s += " " + rr.Form.Encode()
+	}
+	return s
+}
+
+// httpResponse returns the recorded response as a response to the request.
+func (rr *RecordedResponse) httpResponse(req *http.Request) (*http.Response, error) {
+	body := []byte(rr.Body)
+
+	var s string
+	if len(body) > 0 && body[0] == '"' {
+		if err := json.Unmarshal(body, &s); err != nil {
+			return nil, fmt.Errorf("could not decode recorded response body: %w", err)
+		}
+		body = []byte(s)
+	}
+
+	header := http.Header{}
+	if rr.ContentType != "" {
+		header.Set("Content-Type", rr.ContentType)
+	}
+
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", rr.StatusCode, http.StatusText(rr.StatusCode)),
+		StatusCode:    rr.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}, nil
+}
+
+// redactBody returns the body of a response to record, with its secrets redacted.
+func redactBody(body []byte) (json.RawMessage, error) {
+	if !json.Valid(body) {
+		return json.Marshal(redactString(string(body)))
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+
+	var v any
+	if err := decoder.Decode(&v); err != nil {
+		return nil, fmt.Errorf("could not decode response body: %w", err)
+	}
+
+	redacted, err := json.Marshal(redactValue(v))
+	if err != nil {
+		return nil, fmt.Errorf("could not encode response body: %w", err)
+	}
+
+	return redacted, nil
+}
+
+// redactValue redacts the secret fields and API keys in a decoded JSON value.
+func redactValue(v any) any {
+	switch v := v.(type) {
+	case map[string]any:
+		for key, value := range v {
+			if s, ok := value.(string); ok && s != "" && slices.Contains(redactedFields, key) {
+				v[key] = redacted
+				continue
+			}
+			v[key] = redactValue(value)
+		}
+		return v
+	case []any:
+		for i, value := range v {
+			v[i] = redactValue(value)
+		}
+		return v
+	case string:
+		return redactString(v)
+	default:
+		return v
+	}
+}
+
+// redactString redacts the string if it's an API key.
+func redactString(s string) string {
+	if strings.HasPrefix(s, "sk_") || strings.HasPrefix(s, "pk_") {
+		return redacted
+	}
+	return s
+}
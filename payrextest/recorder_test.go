@@ -0,0 +1,172 @@
+package payrextest
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/angelofallars/payrex-go"
+)
+
+// recordCassette records creating a webhook and a payment intent on a fake server
+// to a cassette, and returns the cassette path and the created resources.
+func recordCassette(t *testing.T) (string, *payrex.Webhook, *payrex.PaymentIntent) {
+	t.Helper()
+
+	server := NewServer()
+	t.Cleanup(server.Close)
+
+	path := filepath.Join(t.TempDir(), "cassette.json")
+	recorder, err := NewRecorder(path, RecorderModeRecord)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := payrex.NewClient(NewID("sk_test")).
+		WithBaseURL(server.URL).
+		WithHTTPClient(&http.Client{Transport: recorder})
+
+	webhook, err := client.Webhooks.Create(&payrex.WebhookCreateParams{
+		URL:    "https://example.com/webhooks",
+		Events: []payrex.EventType{payrex.EventTypePaymentIntentSucceeded},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	paymentIntent, err := client.PaymentIntents.Create(newRecordedPaymentIntentParams(100_00))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := recorder.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	return path, webhook, paymentIntent
+}
+
+func newRecordedPaymentIntentParams(amount int) *payrex.PaymentIntentCreateParams {
+	return &payrex.PaymentIntentCreateParams{
+		Amount:         amount,
+		Currency:       payrex.CurrencyPHP,
+		PaymentMethods: payrex.Slice(payrex.PaymentMethodCard, payrex.PaymentMethodGCash),
+		Metadata:       payrex.Metadata{"order_id": "ord_123", "leaked_key": "pk_test_leaked"},
+	}
+}
+
+func TestRecorderRedactsSecrets(t *testing.T) {
+	path, webhook, paymentIntent := recordCassette(t)
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cassette := string(data)
+
+	for name, secret := range map[string]string{
+		"webhook secret key":           webhook.SecretKey,
+		"payment intent client secret": paymentIntent.ClientSecret,
+		"API key in a request":         "pk_test_leaked",
+	} {
+		if secret == "" {
+			t.Fatalf("got an empty %s, want one to redact", name)
+		}
+		if strings.Contains(cassette, secret) {
+			t.Errorf("got %s '%s' in the cassette, want it redacted", name, secret)
+		}
+	}
+	if !strings.Contains(cassette, redacted) {
+		t.Errorf("got cassette without redacted values:\n%s", cassette)
+	}
+	if !strings.Contains(cassette, paymentIntent.ID) {
+		t.Errorf("got cassette without the payment intent ID '%s', want only secrets redacted", paymentIntent.ID)
+	}
+}
+
+func TestRecorderReplay(t *testing.T) {
+	path, _, recordedPaymentIntent := recordCassette(t)
+
+	recorder, err := NewRecorder(path, RecorderModeReplay)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := payrex.NewClient(NewID("sk_test")).WithHTTPClient(&http.Client{Transport: recorder})
+
+	// Requests match on the decoded form, so the order of form values doesn't matter
+	req, err := http.NewRequest(http.MethodPost, "https://api.payrexhq.com/webhooks",
+		strings.NewReader("events[]=payment_intent.succeeded&url=https%3A%2F%2Fexample.com%2Fwebhooks"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := recorder.RoundTrip(req); err != nil {
+		t.Errorf("got error %v replaying the webhook request with reordered form values, want a match", err)
+	}
+
+	paymentIntent, err := client.PaymentIntents.Create(newRecordedPaymentIntentParams(100_00))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if paymentIntent.ID != recordedPaymentIntent.ID {
+		t.Errorf("got payment intent '%s', want the recorded '%s'", paymentIntent.ID, recordedPaymentIntent.ID)
+	}
+
+	// Each interaction is only replayed once
+	if _, err := client.PaymentIntents.Create(newRecordedPaymentIntentParams(100_00)); !errors.Is(err, ErrNoRecordedInteraction) {
+		t.Errorf("got error %v replaying an interaction twice, want %v", err, ErrNoRecordedInteraction)
+	}
+	if _, err := client.PaymentIntents.Create(newRecordedPaymentIntentParams(200_00)); !errors.Is(err, ErrNoRecordedInteraction) {
+		t.Errorf("got error %v for a request with another form, want %v", err, ErrNoRecordedInteraction)
+	}
+	if _, err := client.PaymentIntents.Retrieve(recordedPaymentIntent.ID); !errors.Is(err, ErrNoRecordedInteraction) {
+		t.Errorf("got error %v for a request with another method and path, want %v", err, ErrNoRecordedInteraction)
+	}
+
+	unmatched := recorder.Unmatched()
+	if len(unmatched) != 3 {
+		t.Fatalf("got %d unmatched requests, want 3", len(unmatched))
+	}
+	if want := "GET /payment_intents/" + recordedPaymentIntent.ID; !strings.HasPrefix(unmatched[2], want) {
+		t.Errorf("got unmatched request '%s', want '%s'", unmatched[2], want)
+	}
+}
+
+// reportingTB is a [testing.TB] that collects the errors and cleanups of a test.
+type reportingTB struct {
+	testing.TB
+	errors   []string
+	cleanups []func()
+}
+
+func (tb *reportingTB) Errorf(format string, args ...any) {
+	tb.errors = append(tb.errors, fmt.Sprintf(format, args...))
+}
+
+func (tb *reportingTB) Fatalf(format string, args ...any) {
+	tb.TB.Fatalf(format, args...)
+}
+
+func (tb *reportingTB) Cleanup(f func()) {
+	tb.cleanups = append(tb.cleanups, f)
+}
+
+func TestNewRecorderClientReportsUnmatchedRequests(t *testing.T) {
+	path, _, _ := recordCassette(t)
+	t.Setenv(RecordEnv, "")
+
+	tb := &reportingTB{TB: t}
+	client := NewRecorderClient(tb, path, "")
+
+	if _, err := client.Customers.Retrieve("cus_missing"); !errors.Is(err, ErrNoRecordedInteraction) {
+		t.Errorf("got error %v, want %v", err, ErrNoRecordedInteraction)
+	}
+
+	for _, cleanup := range tb.cleanups {
+		cleanup()
+	}
+	if len(tb.errors) != 1 || !strings.Contains(tb.errors[0], "/customers/cus_missing") {
+		t.Errorf("got reported errors %q, want the unmatched request reported", tb.errors)
+	}
+}
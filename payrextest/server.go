@@ -0,0 +1,474 @@
+package payrextest
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/angelofallars/payrex-go"
+	"github.com/angelofallars/payrex-go/internal/form"
+)
+
+// ErrNotFound is matched by the errors of the [Server]'s methods when a resource doesn't exist.
+var ErrNotFound = errors.New("resource not found")
+
+// Pagination limits of list endpoints of the [Server].
+const (
+	defaultListLimit = 10
+	maxListLimit     = 100
+)
+
+// Server is a fake PayRex API server for integration tests, keeping all resources in memory.
+//
+// It implements every endpoint called by the services of a [payrex.Client],
+// validates parameters like PayRex does, and responds with errors in the shape of a
+// [payrex.Error]. Requests must be authenticated with an API key, whose prefix
+// decides whether created resources are in live mode or test mode.
+//
+// Changes that PayRex makes on its own, such as payments and payouts, are simulated by
+// methods like [Server.PayPaymentIntent] and [Server.CreatePayout]. The server has its own
+// clock, which can be moved with [Server.Advance] to expire checkout sessions and make
+// billing statements overdue once past the time set by [Server.SetBillingStatementDueTime].
+// [Server.Events] lists the events of all changes.
+//
+// Events are also delivered to the webhooks created through the API, signed with their
+// secret keys like PayRex does. See [Server.WithWebhookRetries] and [Server.WebhookDeliveries].
+//
+// Create one using [NewServer], or [NewClient] to also get a client pointed at it:
+//
+//	func TestCheckout(t *testing.T) {
+//		client, server := payrextest.NewClient(t)
+//
+//		paymentIntent, err := client.PaymentIntents.Create(&payrex.PaymentIntentCreateParams{
+//			Amount:         100_00,
+//			Currency:       payrex.CurrencyPHP,
+//			PaymentMethods: payrex.Slice(payrex.PaymentMethodGCash),
+//		})
+//		// ...
+//	}
+type Server struct {
+	// The base URL of the server, e.g. "http://127.0.0.1:12345".
+	URL string
+
+	server *httptest.Server
+	mux    *http.ServeMux
+
+	mu sync.Mutex
+	// The time the clock of the server is frozen at by [Server.SetTime],
+	// or nil if it follows the real time.
+	frozenTime *time.Time
+	// The events of the changes to resources, from oldest to newest.
+	events []*Event
+
+	webhookHTTPClient  *http.Client
+	webhookMaxAttempts int
+	webhookBackoff     time.Duration
+	webhookDeliveries  []*WebhookDelivery
+	pendingDeliveries  sync.WaitGroup
+	closed             chan struct{}
+	closeOnce          sync.Once
+
+	attachedPaymentMethods    map[string]payrex.PaymentMethod
+	billingStatementDueTimes  map[string]time.Time
+	billingStatementLineItems *collection[payrex.BillingStatementLineItem]
+	billingStatements         *collection[payrex.BillingStatement]
+	checkoutSessions          *collection[payrex.CheckoutSession]
+	customerSessions          *collection[payrex.CustomerSession]
+	customers                 *collection[payrex.Customer]
+	deletedCustomers          map[string]bool
+	overdueBillingStatements  map[string]bool
+	paymentIntents            *collection[payrex.PaymentIntent]
+	payments                  *collection[payrex.Payment]
+	payouts                   *collection[payrex.Payout]
+	payoutTransactions        map[string][]payrex.PayoutTransaction
+	paidOut                   map[string]bool
+	refunds                   *collection[payrex.Refund]
+	webhooks                  *collection[payrex.Webhook]
+}
+
+// NewServer starts a new [Server] with no resources.
+//
+// The server must be closed using [Server.Close] when done.
+func NewServer() *Server {
+	s := NewUnstartedServer()
+
+	s.server = httptest.NewServer(s.mux)
+	s.URL = s.server.URL
+
+	return s
+}
+
+// NewUnstartedServer returns a new [Server] with no resources that isn't listening on a port,
+// e.g. to serve the fake API on a fixed address with an [http.Server].
+// Its URL is empty, and requests are handled by [Server.ServeHTTP].
+func NewUnstartedServer() *Server {
+	s := &Server{
+		mux: http.NewServeMux(),
+
+		webhookHTTPClient:  &http.Client{Timeout: defaultWebhookTimeout},
+		webhookMaxAttempts: defaultWebhookMaxAttempts,
+		webhookBackoff:     defaultWebhookBackoff,
+		closed:             make(chan struct{}),
+
+		attachedPaymentMethods:    map[string]payrex.PaymentMethod{},
+		billingStatementDueTimes:  map[string]time.Time{},
+		billingStatementLineItems: newCollection[payrex.BillingStatementLineItem]("billing_statement_line_item"),
+		billingStatements:         newCollection[payrex.BillingStatement]("billing_statement"),
+		checkoutSessions:          newCollection[payrex.CheckoutSession]("checkout_session"),
+		customerSessions:          newCollection[payrex.CustomerSession]("customer_session"),
+		customers:                 newCollection[payrex.Customer]("customer"),
+		deletedCustomers:          map[string]bool{},
+		overdueBillingStatements:  map[string]bool{},
+		paymentIntents:            newCollection[payrex.PaymentIntent]("payment_intent"),
+		payments:                  newCollection[payrex.Payment]("payment"),
+		payouts:                   newCollection[payrex.Payout]("payout"),
+		payoutTransactions:        map[string][]payrex.PayoutTransaction{},
+		paidOut:                   map[string]bool{},
+		refunds:                   newCollection[payrex.Refund]("refund"),
+		webhooks:                  newCollection[payrex.Webhook]("webhook"),
+	}
+
+	s.routeBilling()
+	s.routePayments()
+	s.routeWebhooks()
+
+	return s
+}
+
+// NewClient starts a new [Server] that is closed when the test finishes,
+// and returns a [payrex.Client] with a test mode API key pointed at it.
+func NewClient(tb testing.TB) (*payrex.Client, *Server) {
+	tb.Helper()
+
+	s := NewServer()
+	tb.Cleanup(s.Close)
+
+	return s.Client(), s
+}
+
+// Client returns a new [payrex.Client] with a test mode API key pointed at the server.
+func (s *Server) Client() *payrex.Client {
+	return payrex.NewClient(NewID("sk_test")).WithBaseURL(s.URL)
+}
+
+// Close shuts down the server, and stops delivering webhooks. Events created
+// after Close are recorded, but not delivered.
+func (s *Server) Close() {
+	s.closeOnce.Do(func() {
+		// Closed while locked, so no delivery is started after waiting for the pending ones
+		s.mu.Lock()
+		close(s.closed)
+		s.mu.Unlock()
+
+		s.pendingDeliveries.Wait()
+		if s.server != nil {
+			s.server.Close()
+		}
+	})
+}
+
+// ServeHTTP implements [http.Handler], so the fake API can also be mounted on another server.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
+
+// handle registers an API endpoint. The handler runs with the server locked,
+// after applying the changes due by the current time of the clock,
+// and returns the resource to respond with, or an error.
+func (s *Server) handle(pattern string, handler func(r *http.Request) (any, error)) {
+	s.mux.HandleFunc(pattern, func(w http.ResponseWriter, r *http.Request) {
+		apiKey, _, ok := r.BasicAuth()
+		if !ok || apiKey == "" {
+			writeError(w, &apiError{
+				statusCode: http.StatusUnauthorized,
+				message:    payrex.ErrorMessage{Code: "unauthorized", Detail: "No valid API key provided."},
+			})
+			return
+		}
+
+		query, err := readForm(r)
+		if err != nil {
+			writeError(w, invalidParameter("", "The request body could not be parsed."))
+			return
+		}
+		r = r.WithContext(context.WithValue(r.Context(), formContextKey{}, query))
+
+		body, err := s.call(r, handler)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+
+		writeJSON(w, http.StatusOK, body)
+	})
+}
+
+// call runs the handler with the server locked, and encodes the resource it responds with
+// before unlocking, so the response isn't changed by requests and simulations made meanwhile.
+func (s *Server) call(r *http.Request, handler func(r *http.Request) (any, error)) (json.RawMessage, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.tick()
+
+	resource, err := handler(r)
+	if err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(resource)
+}
+
+// newResource returns the common fields of a new resource with a random ID with the given prefix.
+func (s *Server) newResource(livemode bool, idPrefix string) payrex.Resource {
+	now := int(s.now().Unix())
+
+	return payrex.Resource{
+		ID:        NewID(idPrefix),
+		Livemode:  livemode,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+}
+
+// touch sets the update time of a resource to the current time.
+func (s *Server) touch(resource *payrex.Resource) {
+	resource.UpdatedAt = int(s.now().Unix())
+}
+
+// isLivemode reports whether the request was made with a live mode API key.
+func isLivemode(r *http.Request) bool {
+	apiKey, _, _ := r.BasicAuth()
+	return payrex.ModeFromAPIKey(apiKey) == payrex.ModeLive
+}
+
+// formContextKey is the context key of the URL-encoded form of a request to the [Server].
+type formContextKey struct{}
+
+// readForm returns the URL-encoded form of the request, made of its query string and body.
+// Unlike [http.Request.ParseForm], the order of the form is kept, which [form.Decode] needs
+// to split slices of structs into elements.
+func readForm(r *http.Request) (string, error) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		return "", err
+	}
+
+	query := r.URL.RawQuery
+	if len(body) > 0 {
+		if query != "" {
+			query += "&"
+		}
+		query += string(body)
+	}
+
+	if _, err := url.ParseQuery(query); err != nil {
+		return "", err
+	}
+
+	return query, nil
+}
+
+// decodeParams decodes the form of the request into the params.
+func decodeParams(r *http.Request, params any) error {
+	query, _ := r.Context().Value(formContextKey{}).(string)
+	if err := form.Decode(query, params); err != nil {
+		var decodeErr *form.DecodeError
+		if errors.As(err, &decodeErr) {
+			return invalidParameter(decodeErr.Key, fmt.Sprintf("The %s is invalid.", decodeErr.Key))
+		}
+		return err
+	}
+	return nil
+}
+
+// apiError is an error response of the [Server], in the shape of a [payrex.Error].
+type apiError struct {
+	statusCode int
+	message    payrex.ErrorMessage
+}
+
+func (e *apiError) Error() string {
+	return e.message.Detail
+}
+
+func (e *apiError) Is(target error) bool {
+	return target == ErrNotFound && e.statusCode == http.StatusNotFound
+}
+
+func notFound(resourceName, id string) error {
+	return &apiError{
+		statusCode: http.StatusNotFound,
+		message: payrex.ErrorMessage{
+			Code:   "resource_not_found",
+			Detail: fmt.Sprintf("No such %s: '%s'.", resourceName, id),
+		},
+	}
+}
+
+func requiredParameter(parameter string) error {
+	return &apiError{
+		statusCode: http.StatusBadRequest,
+		message: payrex.ErrorMessage{
+			Code:      "parameter_required",
+			Detail:    fmt.Sprintf("The %s is required.", parameter),
+			Parameter: parameter,
+		},
+	}
+}
+
+func invalidParameter(parameter, detail string) error {
+	return &apiError{
+		statusCode: http.StatusBadRequest,
+		message: payrex.ErrorMessage{
+			Code:      "parameter_invalid",
+			Detail:    detail,
+			Parameter: parameter,
+		},
+	}
+}
+
+// unexpectedState is returned when an action is not allowed in the current state of a resource.
+func unexpectedState(detail string) error {
+	return &apiError{
+		statusCode: http.StatusBadRequest,
+		message: payrex.ErrorMessage{
+			Code:   "resource_unexpected_state",
+			Detail: detail,
+		},
+	}
+}
+
+func writeError(w http.ResponseWriter, err error) {
+	var apiErr *apiError
+	if !errors.As(err, &apiErr) {
+		apiErr = &apiError{
+			statusCode: http.StatusInternalServerError,
+			message:    payrex.ErrorMessage{Code: "internal_error", Detail: err.Error()},
+		}
+	}
+
+	writeJSON(w, apiErr.statusCode, payrex.Error{Errors: []payrex.ErrorMessage{apiErr.message}})
+}
+
+func writeJSON(w http.ResponseWriter, statusCode int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+// collection is an ordered set of resources of the same type keyed by ID.
+type collection[T any] struct {
+	// The name of the resource type, e.g. "payment_intent", used in error messages.
+	name  string
+	ids   []string
+	items map[string]*T
+}
+
+func newCollection[T any](name string) *collection[T] {
+	return &collection[T]{name: name, items: map[string]*T{}}
+}
+
+// get returns the resource with the given ID, or a not found error.
+func (c *collection[T]) get(id string) (*T, error) {
+	item, ok := c.items[id]
+	if !ok {
+		return nil, notFound(c.name, id)
+	}
+	return item, nil
+}
+
+// put adds or replaces the resource with the given ID.
+func (c *collection[T]) put(id string, item *T) {
+	if _, ok := c.items[id]; !ok {
+		c.ids = append(c.ids, id)
+	}
+	c.items[id] = item
+}
+
+// remove removes the resource with the given ID.
+func (c *collection[T]) remove(id string) {
+	delete(c.items, id)
+	c.ids = slices.DeleteFunc(c.ids, func(itemID string) bool { return itemID == id })
+}
+
+// all returns all resources, from newest to oldest.
+func (c *collection[T]) all() []*T {
+	items := make([]*T, 0, len(c.ids))
+	for i := len(c.ids) - 1; i >= 0; i-- {
+		items = append(items, c.items[c.ids[i]])
+	}
+	return items
+}
+
+// pageParams are the pagination parameters of list endpoints.
+type pageParams struct {
+	Limit  *int    `form:"limit"`
+	Before *string `form:"before"`
+	After  *string `form:"after"`
+}
+
+// paginate returns the page of resources selected by the pagination parameters of the request.
+//
+// Resources are listed from newest to oldest. 'after' selects the resources listed after
+// the resource with the given ID, and 'before' the resources listed before it.
+func paginate[T any](r *http.Request, items []*T, id func(*T) string) (*payrex.List[T], error) {
+	var params pageParams
+	if err := decodeParams(r, &params); err != nil {
+		return nil, err
+	}
+
+	limit := defaultListLimit
+	if params.Limit != nil {
+		if *params.Limit < 1 || *params.Limit > maxListLimit {
+			return nil, invalidParameter("limit", fmt.Sprintf("The limit must be between 1 and %d.", maxListLimit))
+		}
+		limit = *params.Limit
+	}
+
+	indexOf := func(cursor string) int {
+		return slices.IndexFunc(items, func(item *T) bool { return id(item) == cursor })
+	}
+
+	start, end := 0, len(items)
+	if params.After != nil {
+		i := indexOf(*params.After)
+		if i < 0 {
+			return nil, invalidParameter("after", "The after cursor does not exist.")
+		}
+		start = i + 1
+	}
+	if params.Before != nil {
+		i := indexOf(*params.Before)
+		if i < 0 {
+			return nil, invalidParameter("before", "The before cursor does not exist.")
+		}
+		end = i
+		// Pages before a cursor are the ones closest to it
+		if params.After == nil {
+			start = max(end-limit, 0)
+		}
+	}
+
+	start = min(start, end)
+	pageEnd := min(start+limit, end)
+
+	list := &payrex.List[T]{
+		Data:    make([]T, 0, pageEnd-start),
+		HasMore: pageEnd < end || (params.Before != nil && params.After == nil && start > 0),
+	}
+	for _, item := range items[start:pageEnd] {
+		list.Data = append(list.Data, *item)
+	}
+
+	return list, nil
+}
@@ -0,0 +1,532 @@
+package payrextest
+
+import (
+	"fmt"
+	"maps"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/angelofallars/payrex-go"
+)
+
+// customerSessionLifetime is how long a customer session stays valid.
+const customerSessionLifetime = time.Hour
+
+// billingStatementLineItemSummary is the type of the elements of BillingStatement.LineItems.
+type billingStatementLineItemSummary = struct {
+	ID                 string `json:"id"`
+	BillingStatementID string `json:"billing_statement_id"`
+	Description        string `json:"description"`
+	UnitPrice          int    `json:"unit_price"`
+	Quantity           int    `json:"quantity"`
+}
+
+func (s *Server) routeBilling() {
+	s.handle("POST /customers", s.handleCreateCustomer)
+	s.handle("GET /customers", s.handleListCustomers)
+	s.handle("GET /customers/{id}", func(r *http.Request) (any, error) {
+		return s.customers.get(r.PathValue("id"))
+	})
+	s.handle("PUT /customers/{id}", s.handleUpdateCustomer)
+	s.handle("DELETE /customers/{id}", s.handleDeleteCustomer)
+
+	s.handle("POST /customer_sessions", s.handleCreateCustomerSession)
+	s.handle("GET /customer_sessions/{id}", func(r *http.Request) (any, error) {
+		return s.customerSessions.get(r.PathValue("id"))
+	})
+
+	s.handle("POST /billing_statements", s.handleCreateBillingStatement)
+	s.handle("GET /billing_statements", func(r *http.Request) (any, error) {
+		return paginate(r, s.billingStatements.all(), func(b *payrex.BillingStatement) string { return b.ID })
+	})
+	s.handle("GET /billing_statements/{id}", func(r *http.Request) (any, error) {
+		return s.billingStatements.get(r.PathValue("id"))
+	})
+	s.handle("PUT /billing_statements/{id}", s.handleUpdateBillingStatement)
+	s.handle("DELETE /billing_statements/{id}", s.handleDeleteBillingStatement)
+	s.handle("POST /billing_statements/{id}/finalize", s.handleFinalizeBillingStatement)
+	s.handle("POST /billing_statements/{id}/send", s.handleSendBillingStatement)
+	s.handle("POST /billing_statements/{id}/void", s.billingStatementTransition(
+		payrex.BillingStatementStatusOpen, payrex.BillingStatementStatusVoid, payrex.EventTypeBillingStatementVoided))
+	s.handle("POST /billing_statements/{id}/mark_uncollectible", s.billingStatementTransition(
+		payrex.BillingStatementStatusOpen, payrex.BillingStatementStatusUncollectible, payrex.EventTypeBillingStatementMarkedUncollectible))
+
+	s.handle("POST /billing_statement_line_items", s.handleCreateBillingStatementLineItem)
+	s.handle("PUT /billing_statement_line_items/{id}", s.handleUpdateBillingStatementLineItem)
+	s.handle("DELETE /billing_statement_line_items/{id}", s.handleDeleteBillingStatementLineItem)
+}
+
+func (s *Server) handleCreateCustomer(r *http.Request) (any, error) {
+	var params payrex.CustomerCreateParams
+	if err := decodeParams(r, &params); err != nil {
+		return nil, err
+	}
+
+	if err := validateCurrency("currency", params.Currency); err != nil {
+		return nil, err
+	}
+	if params.Name == "" {
+		return nil, requiredParameter("name")
+	}
+	if err := validateEmail("email", params.Email); err != nil {
+		return nil, err
+	}
+
+	customer := &payrex.Customer{
+		Resource:                           s.newResource(isLivemode(r), "cus"),
+		BillingStatementPrefix:             valueOr(params.BillingStatementPrefix, strings.ToUpper(randomString(8))),
+		Currency:                           params.Currency,
+		Email:                              params.Email,
+		Name:                               params.Name,
+		Metadata:                           maps.Clone(params.Metadata),
+		NextBillingStatementSequenceNumber: valueOr(params.NextBillingStatementSequenceNumber, "1"),
+	}
+	s.customers.put(customer.ID, customer)
+
+	return customer, nil
+}
+
+func (s *Server) handleListCustomers(r *http.Request) (any, error) {
+	var params payrex.CustomerListParams
+	if err := decodeParams(r, &params); err != nil {
+		return nil, err
+	}
+
+	var customers []*payrex.Customer
+	for _, customer := range s.customers.all() {
+		switch {
+		case s.deletedCustomers[customer.ID]:
+		case params.Email != nil && customer.Email != *params.Email:
+		case params.Name != nil && customer.Name != *params.Name:
+		case !hasMetadata(customer.Metadata, params.Metadata):
+		default:
+			customers = append(customers, customer)
+		}
+	}
+
+	return paginate(r, customers, func(c *payrex.Customer) string { return c.ID })
+}
+
+func (s *Server) handleUpdateCustomer(r *http.Request) (any, error) {
+	customer, err := s.activeCustomer(r.PathValue("id"))
+	if err != nil {
+		return nil, err
+	}
+
+	var params payrex.CustomerUpdateParams
+	if err := decodeParams(r, &params); err != nil {
+		return nil, err
+	}
+
+	if params.Currency != nil {
+		if err := validateCurrency("currency", *params.Currency); err != nil {
+			return nil, err
+		}
+		customer.Currency = *params.Currency
+	}
+	if params.Name != nil {
+		if *params.Name == "" {
+			return nil, requiredParameter("name")
+		}
+		customer.Name = *params.Name
+	}
+	if params.Email != nil {
+		if err := validateEmail("email", *params.Email); err != nil {
+			return nil, err
+		}
+		customer.Email = *params.Email
+	}
+	if params.BillingStatementPrefix != nil {
+		customer.BillingStatementPrefix = *params.BillingStatementPrefix
+	}
+	if params.NextBillingStatementSequenceNumber != nil {
+		customer.NextBillingStatementSequenceNumber = *params.NextBillingStatementSequenceNumber
+	}
+	if params.Metadata != nil {
+		customer.Metadata = maps.Clone(params.Metadata)
+	}
+	s.touch(&customer.Resource)
+
+	return customer, nil
+}
+
+func (s *Server) handleDeleteCustomer(r *http.Request) (any, error) {
+	customer, err := s.activeCustomer(r.PathValue("id"))
+	if err != nil {
+		return nil, err
+	}
+
+	// Deleted customers can still be retrieved, so they are only marked as deleted
+	s.deletedCustomers[customer.ID] = true
+
+	return &payrex.DeletedResource{ID: customer.ID, Deleted: true}, nil
+}
+
+// activeCustomer returns the customer with the given ID if it's not deleted.
+func (s *Server) activeCustomer(id string) (*payrex.Customer, error) {
+	if s.deletedCustomers[id] {
+		return nil, notFound(s.customers.name, id)
+	}
+	return s.customers.get(id)
+}
+
+func (s *Server) handleCreateCustomerSession(r *http.Request) (any, error) {
+	var params payrex.CustomerSessionCreateParams
+	if err := decodeParams(r, &params); err != nil {
+		return nil, err
+	}
+
+	if params.CustomerID == "" {
+		return nil, requiredParameter("customer_id")
+	}
+	if _, err := s.activeCustomer(params.CustomerID); err != nil {
+		return nil, invalidParameter("customer_id", fmt.Sprintf("No such customer: '%s'.", params.CustomerID))
+	}
+
+	resource := s.newResource(isLivemode(r), "cuss")
+
+	customerSession := &payrex.CustomerSession{
+		Resource:     resource,
+		CustomerID:   params.CustomerID,
+		ClientSecret: newClientSecret(resource.ID),
+		Components:   []payrex.CustomerSessionComponent{},
+		ExpiredAt:    int(s.now().Add(customerSessionLifetime).Unix()),
+	}
+	s.customerSessions.put(customerSession.ID, customerSession)
+
+	return customerSession, nil
+}
+
+func (s *Server) handleCreateBillingStatement(r *http.Request) (any, error) {
+	var params payrex.BillingStatementCreateParams
+	if err := decodeParams(r, &params); err != nil {
+		return nil, err
+	}
+
+	if params.CustomerID == "" {
+		return nil, requiredParameter("customer_id")
+	}
+	if _, err := s.activeCustomer(params.CustomerID); err != nil {
+		return nil, invalidParameter("customer_id", fmt.Sprintf("No such customer: '%s'.", params.CustomerID))
+	}
+	if err := validateCurrency("currency", params.Currency); err != nil {
+		return nil, err
+	}
+	if err := validatePaymentMethods("payment_settings[payment_methods]", params.PaymentSettings.PaymentMethods); err != nil {
+		return nil, err
+	}
+	billingStatement := &payrex.BillingStatement{
+		Resource:                 s.newResource(isLivemode(r), "bstm"),
+		Status:                   payrex.BillingStatementStatusDraft,
+		Currency:                 params.Currency,
+		LineItems:                []billingStatementLineItemSummary{},
+		BillingDetailsCollection: valueOr(params.BillingDetailsCollection, "always"),
+		CustomerID:               params.CustomerID,
+		Description:              params.Description,
+		PaymentSettings:          params.PaymentSettings,
+		Metadata:                 maps.Clone(params.Metadata),
+	}
+	s.billingStatements.put(billingStatement.ID, billingStatement)
+
+	recordEvent(s, payrex.EventTypeBillingStatementCreated, billingStatement)
+
+	return billingStatement, nil
+}
+
+func (s *Server) handleUpdateBillingStatement(r *http.Request) (any, error) {
+	billingStatement, err := s.billingStatements.get(r.PathValue("id"))
+	if err != nil {
+		return nil, err
+	}
+
+	var params payrex.BillingStatementUpdateParams
+	if err := decodeParams(r, &params); err != nil {
+		return nil, err
+	}
+
+	if billingStatement.Status != payrex.BillingStatementStatusDraft &&
+		billingStatement.Status != payrex.BillingStatementStatusOpen {
+		return nil, unexpectedState(fmt.Sprintf("The billing statement cannot be updated because it has a status of '%s'.",
+			billingStatement.Status))
+	}
+
+	if params.CustomerID != nil {
+		if billingStatement.Status != payrex.BillingStatementStatusDraft {
+			return nil, invalidParameter("customer_id", "The customer of a finalized billing statement cannot be changed.")
+		}
+		if _, err := s.activeCustomer(*params.CustomerID); err != nil {
+			return nil, invalidParameter("customer_id", fmt.Sprintf("No such customer: '%s'.", *params.CustomerID))
+		}
+		billingStatement.CustomerID = *params.CustomerID
+	}
+	if params.Description != nil {
+		billingStatement.Description = params.Description
+	}
+	if params.BillingDetailsCollection != nil {
+		billingStatement.BillingDetailsCollection = *params.BillingDetailsCollection
+	}
+	if params.PaymentSettings != nil {
+		if err := validatePaymentMethods("payment_settings[payment_methods]", params.PaymentSettings.PaymentMethods); err != nil {
+			return nil, err
+		}
+		billingStatement.PaymentSettings = *params.PaymentSettings
+	}
+	if params.Metadata != nil {
+		billingStatement.Metadata = maps.Clone(params.Metadata)
+	}
+	s.touch(&billingStatement.Resource)
+
+	recordEvent(s, payrex.EventTypeBillingStatementUpdated, billingStatement)
+
+	return billingStatement, nil
+}
+
+func (s *Server) handleDeleteBillingStatement(r *http.Request) (any, error) {
+	billingStatement, err := s.draftBillingStatement(r.PathValue("id"))
+	if err != nil {
+		return nil, err
+	}
+
+	for _, lineItem := range billingStatement.LineItems {
+		s.billingStatementLineItems.remove(lineItem.ID)
+	}
+	s.billingStatements.remove(billingStatement.ID)
+
+	recordEvent(s, payrex.EventTypeBillingStatementDeleted, billingStatement)
+
+	return &payrex.DeletedResource{ID: billingStatement.ID, Deleted: true}, nil
+}
+
+func (s *Server) handleFinalizeBillingStatement(r *http.Request) (any, error) {
+	billingStatement, err := s.draftBillingStatement(r.PathValue("id"))
+	if err != nil {
+		return nil, err
+	}
+
+	if len(billingStatement.LineItems) == 0 {
+		return nil, unexpectedState("The billing statement cannot be finalized because it has no line items.")
+	}
+	if billingStatement.Amount < minPaymentIntentAmount {
+		return nil, unexpectedState(fmt.Sprintf("The billing statement cannot be finalized because its amount is less than %d.",
+			minPaymentIntentAmount))
+	}
+
+	paymentIntent := s.newPaymentIntent(billingStatement.Livemode, billingStatement.Amount, billingStatement.Currency,
+		billingStatement.PaymentSettings.PaymentMethods)
+	paymentIntent.Description = billingStatement.Description
+
+	billingStatement.Status = payrex.BillingStatementStatusOpen
+	billingStatement.PaymentIntent = paymentIntent
+	billingStatement.URL = payrex.NotNil("https://bill.payrexhq.com/b/" + billingStatement.ID)
+	s.touch(&billingStatement.Resource)
+
+	recordEvent(s, payrex.EventTypeBillingStatementFinalized, billingStatement)
+
+	return billingStatement, nil
+}
+
+func (s *Server) handleSendBillingStatement(r *http.Request) (any, error) {
+	billingStatement, err := s.billingStatements.get(r.PathValue("id"))
+	if err != nil {
+		return nil, err
+	}
+
+	if billingStatement.Status != payrex.BillingStatementStatusOpen {
+		return nil, unexpectedState(fmt.Sprintf("The billing statement cannot be sent because it has a status of '%s'.",
+			billingStatement.Status))
+	}
+
+	recordEvent(s, payrex.EventTypeBillingStatementSent, billingStatement)
+
+	return billingStatement, nil
+}
+
+// billingStatementTransition returns a handler that changes the status
+// of a billing statement with the 'from' status to the 'to' status,
+// creating an event of the given type.
+func (s *Server) billingStatementTransition(from, to payrex.BillingStatementStatus, eventType payrex.EventType) func(r *http.Request) (any, error) {
+	return func(r *http.Request) (any, error) {
+		billingStatement, err := s.billingStatements.get(r.PathValue("id"))
+		if err != nil {
+			return nil, err
+		}
+
+		if billingStatement.Status != from {
+			return nil, unexpectedState(fmt.Sprintf("The billing statement cannot be changed to '%s' because it has a status of '%s'.",
+				to, billingStatement.Status))
+		}
+
+		billingStatement.Status = to
+		s.touch(&billingStatement.Resource)
+
+		recordEvent(s, eventType, billingStatement)
+
+		return billingStatement, nil
+	}
+}
+
+// draftBillingStatement returns the billing statement with the given ID if it's still a draft.
+func (s *Server) draftBillingStatement(id string) (*payrex.BillingStatement, error) {
+	billingStatement, err := s.billingStatements.get(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if billingStatement.Status != payrex.BillingStatementStatusDraft {
+		return nil, unexpectedState(fmt.Sprintf("The billing statement must be a draft, but it has a status of '%s'.",
+			billingStatement.Status))
+	}
+
+	return billingStatement, nil
+}
+
+func (s *Server) handleCreateBillingStatementLineItem(r *http.Request) (any, error) {
+	var params payrex.BillingStatementLineItemCreateParams
+	if err := decodeParams(r, &params); err != nil {
+		return nil, err
+	}
+
+	if params.BillingStatementID == "" {
+		return nil, requiredParameter("billing_statement_id")
+	}
+	billingStatement, err := s.draftBillingStatement(params.BillingStatementID)
+	if err != nil {
+		return nil, err
+	}
+	if params.Description == "" {
+		return nil, requiredParameter("description")
+	}
+	if params.UnitPrice <= 0 {
+		return nil, invalidParameter("unit_price", "The unit_price must be greater than 0.")
+	}
+	if params.Quantity <= 0 {
+		return nil, invalidParameter("quantity", "The quantity must be greater than 0.")
+	}
+
+	lineItem := &payrex.BillingStatementLineItem{
+		Resource:           s.newResource(isLivemode(r), "bstm_li"),
+		BillingStatementID: billingStatement.ID,
+		Description:        params.Description,
+		UnitPrice:          params.UnitPrice,
+		Quantity:           params.Quantity,
+	}
+	s.billingStatementLineItems.put(lineItem.ID, lineItem)
+	s.syncBillingStatementLineItems(billingStatement)
+
+	recordEvent(s, payrex.EventTypeBillingStatementLineItemCreated, lineItem)
+
+	return lineItem, nil
+}
+
+func (s *Server) handleUpdateBillingStatementLineItem(r *http.Request) (any, error) {
+	lineItem, billingStatement, err := s.draftBillingStatementLineItem(r.PathValue("id"))
+	if err != nil {
+		return nil, err
+	}
+
+	var params payrex.BillingStatementLineItemUpdateParams
+	if err := decodeParams(r, &params); err != nil {
+		return nil, err
+	}
+
+	if params.Description != nil {
+		if *params.Description == "" {
+			return nil, requiredParameter("description")
+		}
+		lineItem.Description = *params.Description
+	}
+	if params.UnitPrice != nil {
+		if *params.UnitPrice <= 0 {
+			return nil, invalidParameter("unit_price", "The unit_price must be greater than 0.")
+		}
+		lineItem.UnitPrice = *params.UnitPrice
+	}
+	if params.Quantity != nil {
+		if *params.Quantity <= 0 {
+			return nil, invalidParameter("quantity", "The quantity must be greater than 0.")
+		}
+		lineItem.Quantity = *params.Quantity
+	}
+	s.touch(&lineItem.Resource)
+	s.syncBillingStatementLineItems(billingStatement)
+
+	recordEvent(s, payrex.EventTypeBillingStatementLineItemUpdated, lineItem)
+
+	return lineItem, nil
+}
+
+func (s *Server) handleDeleteBillingStatementLineItem(r *http.Request) (any, error) {
+	lineItem, billingStatement, err := s.draftBillingStatementLineItem(r.PathValue("id"))
+	if err != nil {
+		return nil, err
+	}
+
+	s.billingStatementLineItems.remove(lineItem.ID)
+	s.syncBillingStatementLineItems(billingStatement)
+
+	recordEvent(s, payrex.EventTypeBillingStatementLineItemDeleted, lineItem)
+
+	return &payrex.DeletedResource{ID: lineItem.ID, Deleted: true}, nil
+}
+
+// draftBillingStatementLineItem returns the line item with the given ID
+// and its billing statement, if the billing statement is still a draft.
+func (s *Server) draftBillingStatementLineItem(id string) (*payrex.BillingStatementLineItem, *payrex.BillingStatement, error) {
+	lineItem, err := s.billingStatementLineItems.get(id)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	billingStatement, err := s.draftBillingStatement(lineItem.BillingStatementID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return lineItem, billingStatement, nil
+}
+
+// syncBillingStatementLineItems updates the line items and amount of
+// a billing statement from its stored line items.
+func (s *Server) syncBillingStatementLineItems(billingStatement *payrex.BillingStatement) {
+	billingStatement.LineItems = []billingStatementLineItemSummary{}
+	billingStatement.Amount = 0
+
+	for _, id := range s.billingStatementLineItems.ids {
+		lineItem := s.billingStatementLineItems.items[id]
+		if lineItem.BillingStatementID != billingStatement.ID {
+			continue
+		}
+
+		billingStatement.LineItems = append(billingStatement.LineItems, billingStatementLineItemSummary{
+			ID:                 lineItem.ID,
+			BillingStatementID: lineItem.BillingStatementID,
+			Description:        lineItem.Description,
+			UnitPrice:          lineItem.UnitPrice,
+			Quantity:           lineItem.Quantity,
+		})
+		billingStatement.Amount += lineItem.UnitPrice * lineItem.Quantity
+	}
+
+	s.touch(&billingStatement.Resource)
+}
+
+func validateEmail(parameter, email string) error {
+	if email == "" {
+		return requiredParameter(parameter)
+	}
+	if local, domain, ok := strings.Cut(email, "@"); !ok || local == "" || !strings.Contains(domain, ".") {
+		return invalidParameter(parameter, fmt.Sprintf("The %s is not a valid email address.", parameter))
+	}
+	return nil
+}
+
+// hasMetadata reports whether the metadata has all the keys and values of the filter.
+func hasMetadata(metadata, filter payrex.Metadata) bool {
+	for key, value := range filter {
+		if v, ok := metadata[key]; !ok || v != value {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,407 @@
+package payrextest
+
+import (
+	"fmt"
+	"maps"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/angelofallars/payrex-go"
+)
+
+// Limits of the amount of a payment intent, in cents.
+const (
+	minPaymentIntentAmount = 20_00
+	maxPaymentIntentAmount = 59_999_999_99
+)
+
+// defaultCheckoutSessionLifetime is how long a checkout session stays active
+// when it's created without an expiry time.
+const defaultCheckoutSessionLifetime = 24 * time.Hour
+
+func (s *Server) routePayments() {
+	s.handle("POST /payment_intents", s.handleCreatePaymentIntent)
+	s.handle("GET /payment_intents/{id}", func(r *http.Request) (any, error) {
+		return s.paymentIntents.get(r.PathValue("id"))
+	})
+	s.handle("POST /payment_intents/{id}/cancel", s.handleCancelPaymentIntent)
+	s.handle("POST /payment_intents/{id}/capture", s.handleCapturePaymentIntent)
+
+	s.handle("POST /checkout_sessions", s.handleCreateCheckoutSession)
+	s.handle("GET /checkout_sessions", func(r *http.Request) (any, error) {
+		return paginate(r, s.checkoutSessions.all(), func(c *payrex.CheckoutSession) string { return c.ID })
+	})
+	s.handle("GET /checkout_sessions/{id}", func(r *http.Request) (any, error) {
+		return s.checkoutSessions.get(r.PathValue("id"))
+	})
+	s.handle("POST /checkout_sessions/{id}/expire", s.handleExpireCheckoutSession)
+
+	s.handle("GET /payments/{id}", func(r *http.Request) (any, error) {
+		return s.payments.get(r.PathValue("id"))
+	})
+	s.handle("PUT /payments/{id}", s.handleUpdatePayment)
+
+	s.handle("POST /refunds", s.handleCreateRefund)
+	s.handle("PUT /refunds/{id}", s.handleUpdateRefund)
+
+	s.handle("GET /payouts/{id}/transactions", s.handleListPayoutTransactions)
+}
+
+func (s *Server) handleCreatePaymentIntent(r *http.Request) (any, error) {
+	var params payrex.PaymentIntentCreateParams
+	if err := decodeParams(r, &params); err != nil {
+		return nil, err
+	}
+
+	if params.Amount == 0 {
+		return nil, requiredParameter("amount")
+	}
+	if params.Amount < minPaymentIntentAmount || params.Amount > maxPaymentIntentAmount {
+		return nil, invalidParameter("amount", fmt.Sprintf("The amount must be between %d and %d.",
+			minPaymentIntentAmount, maxPaymentIntentAmount))
+	}
+	if err := validateCurrency("currency", params.Currency); err != nil {
+		return nil, err
+	}
+	if err := validatePaymentMethods("payment_methods", params.PaymentMethods); err != nil {
+		return nil, err
+	}
+
+	paymentIntent := s.newPaymentIntent(isLivemode(r), params.Amount, params.Currency, params.PaymentMethods)
+	paymentIntent.Description = params.Description
+	paymentIntent.Metadata = maps.Clone(params.Metadata)
+	paymentIntent.PaymentMethodOptions = params.PaymentMethodOptions
+
+	return paymentIntent, nil
+}
+
+// newPaymentIntent creates and stores a new payment intent awaiting a payment method.
+func (s *Server) newPaymentIntent(livemode bool, amount int, currency payrex.Currency, paymentMethods []payrex.PaymentMethod) *payrex.PaymentIntent {
+	resource := s.newResource(livemode, "pi")
+
+	paymentIntent := &payrex.PaymentIntent{
+		Resource:       resource,
+		Amount:         amount,
+		ClientSecret:   newClientSecret(resource.ID),
+		Currency:       currency,
+		PaymentMethods: slices.Clone(paymentMethods),
+		Status:         payrex.PaymentIntentStatusAwaitingPaymentMethod,
+	}
+	s.paymentIntents.put(paymentIntent.ID, paymentIntent)
+
+	return paymentIntent
+}
+
+func (s *Server) handleCancelPaymentIntent(r *http.Request) (any, error) {
+	paymentIntent, err := s.paymentIntents.get(r.PathValue("id"))
+	if err != nil {
+		return nil, err
+	}
+
+	if paymentIntent.Status != payrex.PaymentIntentStatusAwaitingPaymentMethod {
+		return nil, unexpectedState(fmt.Sprintf("The payment intent cannot be canceled because it has a status of '%s'.",
+			paymentIntent.Status))
+	}
+
+	paymentIntent.Status = payrex.PaymentIntentStatusCanceled
+	s.touch(&paymentIntent.Resource)
+
+	return paymentIntent, nil
+}
+
+func (s *Server) handleCapturePaymentIntent(r *http.Request) (any, error) {
+	paymentIntent, err := s.paymentIntents.get(r.PathValue("id"))
+	if err != nil {
+		return nil, err
+	}
+
+	var params payrex.PaymentIntentCaptureParams
+	if err := decodeParams(r, &params); err != nil {
+		return nil, err
+	}
+
+	if paymentIntent.Status != payrex.PaymentIntentStatusAwaitingCapture {
+		return nil, unexpectedState(fmt.Sprintf("The payment intent cannot be captured because it has a status of '%s'.",
+			paymentIntent.Status))
+	}
+	if params.Amount == 0 {
+		return nil, requiredParameter("amount")
+	}
+	if params.Amount < 0 || params.Amount > paymentIntent.AmountCapturable {
+		return nil, invalidParameter("amount", fmt.Sprintf("The amount must be between 1 and %d.",
+			paymentIntent.AmountCapturable))
+	}
+
+	s.completePaymentIntent(paymentIntent, params.Amount)
+
+	return paymentIntent, nil
+}
+
+func (s *Server) handleCreateCheckoutSession(r *http.Request) (any, error) {
+	var params payrex.CheckoutSessionCreateParams
+	if err := decodeParams(r, &params); err != nil {
+		return nil, err
+	}
+
+	if err := validateCurrency("currency", params.Currency); err != nil {
+		return nil, err
+	}
+	if len(params.LineItems) == 0 {
+		return nil, requiredParameter("line_items")
+	}
+	if params.SuccessURL == "" {
+		return nil, requiredParameter("success_url")
+	}
+	if params.CancelURL == "" {
+		return nil, requiredParameter("cancel_url")
+	}
+	if err := validatePaymentMethods("payment_methods", params.PaymentMethods); err != nil {
+		return nil, err
+	}
+
+	now := s.now()
+	expiresAt := now.Add(defaultCheckoutSessionLifetime).Unix()
+	if params.ExpiresAt != nil {
+		if int64(*params.ExpiresAt) <= now.Unix() {
+			return nil, invalidParameter("expires_at", "The expires_at must be in the future.")
+		}
+		expiresAt = int64(*params.ExpiresAt)
+	}
+
+	amount := 0
+	lineItems := make([]payrex.CheckoutSessionLineItem, len(params.LineItems))
+	for i, lineItem := range params.LineItems {
+		switch {
+		case lineItem.Name == "":
+			return nil, requiredParameter("line_items[][name]")
+		case lineItem.Amount <= 0:
+			return nil, invalidParameter("line_items[][amount]", "The amount of line items must be greater than 0.")
+		case lineItem.Quantity <= 0:
+			return nil, invalidParameter("line_items[][quantity]", "The quantity of line items must be greater than 0.")
+		}
+
+		lineItems[i] = payrex.CheckoutSessionLineItem{
+			ID:          NewID("cs_li"),
+			Name:        lineItem.Name,
+			Amount:      lineItem.Amount,
+			Quantity:    lineItem.Quantity,
+			Description: lineItem.Description,
+			Image:       lineItem.Image,
+		}
+		amount += lineItem.Amount * lineItem.Quantity
+	}
+
+	paymentIntent := s.newPaymentIntent(isLivemode(r), amount, params.Currency, params.PaymentMethods)
+	paymentIntent.Description = params.Description
+	paymentIntent.PaymentMethodOptions = params.PaymentMethodOptions
+
+	resource := s.newResource(isLivemode(r), "cs")
+	clientSecret := newClientSecret(resource.ID)
+
+	checkoutSession := &payrex.CheckoutSession{
+		Resource:                 resource,
+		URL:                      "https://checkout.payrexhq.com/c/" + clientSecret,
+		BillingDetailsCollection: valueOr(params.BillingDetailsCollection, "always"),
+		CustomerReferenceID:      params.CustomerReferenceID,
+		ClientSecret:             clientSecret,
+		Status:                   payrex.CheckoutSessionStatusActive,
+		Currency:                 params.Currency,
+		LineItems:                lineItems,
+		PaymentIntent:            paymentIntent,
+		Metadata:                 maps.Clone(params.Metadata),
+		SuccessURL:               params.SuccessURL,
+		CancelURL:                params.CancelURL,
+		PaymentMethods:           slices.Clone(params.PaymentMethods),
+		Description:              params.Description,
+		SubmitType:               valueOr(params.SubmitType, "pay"),
+		ExpiresAt:                int(expiresAt),
+	}
+	s.checkoutSessions.put(checkoutSession.ID, checkoutSession)
+
+	return checkoutSession, nil
+}
+
+func (s *Server) handleExpireCheckoutSession(r *http.Request) (any, error) {
+	checkoutSession, err := s.checkoutSessions.get(r.PathValue("id"))
+	if err != nil {
+		return nil, err
+	}
+
+	if checkoutSession.Status != payrex.CheckoutSessionStatusActive {
+		return nil, unexpectedState(fmt.Sprintf("The checkout session cannot be expired because it has a status of '%s'.",
+			checkoutSession.Status))
+	}
+
+	s.expireCheckoutSession(checkoutSession)
+
+	return checkoutSession, nil
+}
+
+func (s *Server) expireCheckoutSession(checkoutSession *payrex.CheckoutSession) {
+	checkoutSession.Status = payrex.CheckoutSessionStatusExpired
+	s.touch(&checkoutSession.Resource)
+
+	recordEvent(s, payrex.EventTypeCheckoutSessionExpired, checkoutSession)
+}
+
+func (s *Server) handleUpdatePayment(r *http.Request) (any, error) {
+	payment, err := s.payments.get(r.PathValue("id"))
+	if err != nil {
+		return nil, err
+	}
+
+	var params payrex.PaymentUpdateParams
+	if err := decodeParams(r, &params); err != nil {
+		return nil, err
+	}
+
+	if params.Description != nil {
+		payment.Description = params.Description
+	}
+	if params.Metadata != nil {
+		payment.Metadata = maps.Clone(params.Metadata)
+	}
+	s.touch(&payment.Resource)
+
+	return payment, nil
+}
+
+func (s *Server) handleCreateRefund(r *http.Request) (any, error) {
+	var params payrex.RefundCreateParams
+	if err := decodeParams(r, &params); err != nil {
+		return nil, err
+	}
+
+	if params.PaymentID == "" {
+		return nil, requiredParameter("payment_id")
+	}
+	payment, err := s.payments.get(params.PaymentID)
+	if err != nil {
+		return nil, invalidParameter("payment_id", fmt.Sprintf("No such payment: '%s'.", params.PaymentID))
+	}
+
+	if err := validateCurrency("currency", params.Currency); err != nil {
+		return nil, err
+	}
+	if params.Amount == 0 {
+		return nil, requiredParameter("amount")
+	}
+	if refundable := payment.Amount - payment.AmountRefunded; params.Amount < 0 || params.Amount > refundable {
+		return nil, invalidParameter("amount", fmt.Sprintf("The amount must be between 1 and %d.", refundable))
+	}
+	if params.Reason == "" {
+		return nil, requiredParameter("reason")
+	}
+	if !slices.Contains(refundReasons, params.Reason) {
+		return nil, invalidParameter("reason", fmt.Sprintf("The reason '%s' is invalid.", params.Reason))
+	}
+	if payment.Status != payrex.PaymentStatusPaid {
+		return nil, unexpectedState("Only paid payments can be refunded.")
+	}
+
+	refund := &payrex.Refund{
+		Resource:    s.newResource(isLivemode(r), "re"),
+		Amount:      params.Amount,
+		Currency:    params.Currency,
+		Status:      payrex.RefundStatusSucceeded,
+		Description: params.Description,
+		Reason:      params.Reason,
+		Remarks:     params.Remarks,
+		PaymentID:   payment.ID,
+		Metadata:    maps.Clone(params.Metadata),
+	}
+	s.refunds.put(refund.ID, refund)
+
+	payment.AmountRefunded += refund.Amount
+	payment.Refunded = payment.AmountRefunded == payment.Amount
+	s.touch(&payment.Resource)
+
+	recordEvent(s, payrex.EventTypeRefundCreated, refund)
+
+	return refund, nil
+}
+
+func (s *Server) handleUpdateRefund(r *http.Request) (any, error) {
+	refund, err := s.refunds.get(r.PathValue("id"))
+	if err != nil {
+		return nil, err
+	}
+
+	var params payrex.RefundUpdateParams
+	if err := decodeParams(r, &params); err != nil {
+		return nil, err
+	}
+
+	if params.Metadata != nil {
+		refund.Metadata = maps.Clone(params.Metadata)
+	}
+	s.touch(&refund.Resource)
+
+	recordEvent(s, payrex.EventTypeRefundUpdated, refund)
+
+	return refund, nil
+}
+
+func (s *Server) handleListPayoutTransactions(r *http.Request) (any, error) {
+	payout, err := s.payouts.get(r.PathValue("id"))
+	if err != nil {
+		return nil, err
+	}
+
+	transactions := s.payoutTransactions[payout.ID]
+	items := make([]*payrex.PayoutTransaction, len(transactions))
+	for i := range transactions {
+		items[i] = &transactions[len(transactions)-1-i]
+	}
+
+	return paginate(r, items, func(t *payrex.PayoutTransaction) string { return t.ID })
+}
+
+var refundReasons = []payrex.RefundReason{
+	payrex.RefundReasonFraudulent,
+	payrex.RefundReasonRequestedByCustomer,
+	payrex.RefundReasonProductOutOfStock,
+	payrex.RefundReasonServiceNotProvided,
+	payrex.RefundReasonProductWasDamaged,
+	payrex.RefundReasonServiceMisaligned,
+	payrex.RefundReasonWrongProductReceived,
+	payrex.RefundReasonOthers,
+}
+
+var paymentMethods = []payrex.PaymentMethod{
+	payrex.PaymentMethodCard,
+	payrex.PaymentMethodGCash,
+	payrex.PaymentMethodMaya,
+	payrex.PaymentMethodQRPh,
+}
+
+func validateCurrency(parameter string, currency payrex.Currency) error {
+	if currency == "" {
+		return requiredParameter(parameter)
+	}
+	if currency != payrex.CurrencyPHP {
+		return invalidParameter(parameter, fmt.Sprintf("The currency '%s' is not supported.", currency))
+	}
+	return nil
+}
+
+func validatePaymentMethods(parameter string, methods []payrex.PaymentMethod) error {
+	if len(methods) == 0 {
+		return requiredParameter(parameter)
+	}
+	for _, method := range methods {
+		if !slices.Contains(paymentMethods, method) {
+			return invalidParameter(parameter, fmt.Sprintf("The payment method '%s' is invalid.", method))
+		}
+	}
+	return nil
+}
+
+// valueOr returns the value pointed to by v, or the fallback if v is nil.
+func valueOr[T any](v *T, fallback T) T {
+	if v == nil {
+		return fallback
+	}
+	return *v
+}
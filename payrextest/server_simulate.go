@@ -0,0 +1,457 @@
+package payrextest
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"time"
+
+	"github.com/angelofallars/payrex-go"
+)
+
+// payoutDestination is the bank account that payouts of the [Server] are sent to.
+var payoutDestination = payrex.PayoutDestination{
+	AccountName:   "Juan Dela Cruz",
+	AccountNumber: "000123456789",
+	BankName:      "BDO Unibank, Inc.",
+}
+
+// Now returns the current time of the server's clock.
+func (s *Server) Now() time.Time {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.now()
+}
+
+// now returns the current time of the server's clock.
+func (s *Server) now() time.Time {
+	if s.frozenTime != nil {
+		return *s.frozenTime
+	}
+	return time.Now()
+}
+
+// SetTime freezes the server's clock at the given time, then applies the changes
+// due by that time: active checkout sessions past their expiry time expire,
+// and open billing statements past their due time go overdue.
+func (s *Server) SetTime(t time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.frozenTime = &t
+	s.tick()
+}
+
+// Advance moves the server's clock forward by the duration, freezing it like [Server.SetTime].
+func (s *Server) Advance(d time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	t := s.now().Add(d)
+	s.frozenTime = &t
+	s.tick()
+}
+
+// tick applies the changes due by the current time of the server's clock.
+func (s *Server) tick() {
+	now := int(s.now().Unix())
+
+	for _, checkoutSession := range slices.Backward(s.checkoutSessions.all()) {
+		if checkoutSession.Status == payrex.CheckoutSessionStatusActive && checkoutSession.ExpiresAt <= now {
+			s.expireCheckoutSession(checkoutSession)
+		}
+	}
+
+	for _, billingStatement := range slices.Backward(s.billingStatements.all()) {
+		dueAt, ok := s.billingStatementDueTimes[billingStatement.ID]
+		if billingStatement.Status != payrex.BillingStatementStatusOpen || !ok ||
+			int(dueAt.Unix()) > now || s.overdueBillingStatements[billingStatement.ID] {
+			continue
+		}
+
+		s.overdueBillingStatements[billingStatement.ID] = true
+		recordEvent(s, payrex.EventTypeBillingStatementOverdue, billingStatement)
+	}
+}
+
+// SetBillingStatementDueTime sets the time a draft or open billing statement is due,
+// so it goes overdue once the server's clock passes that time while it's open.
+//
+// The due time is only kept by the server, and isn't part of the billing statement.
+func (s *Server) SetBillingStatementDueTime(billingStatementID string, dueAt time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	billingStatement, err := s.billingStatements.get(billingStatementID)
+	if err != nil {
+		return err
+	}
+	if billingStatement.Status != payrex.BillingStatementStatusDraft &&
+		billingStatement.Status != payrex.BillingStatementStatusOpen {
+		return unexpectedState(fmt.Sprintf("The billing statement cannot be due because it has a status of '%s'.",
+			billingStatement.Status))
+	}
+
+	s.billingStatementDueTimes[billingStatementID] = dueAt
+	delete(s.overdueBillingStatements, billingStatementID)
+	s.tick()
+
+	return nil
+}
+
+// Events returns the events of the changes to resources so far, from oldest to newest.
+//
+// Events are created for every change that PayRex sends an event for, whether it's
+// made through the API, by the server's clock, or by simulating payments.
+func (s *Server) Events() []*Event {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return slices.Clone(s.events)
+}
+
+// recordEvent records an event with a snapshot of the current state of the resource,
+// and delivers it to webhooks.
+func recordEvent[T EventResource](s *Server, eventType payrex.EventType, resource *T) {
+	event := NewEvent(eventType, clone(resource))
+	event.CreatedAt = s.now()
+
+	s.events = append(s.events, event)
+	s.deliverEvent(event)
+}
+
+// AttachPaymentMethod simulates a customer choosing a payment method for a payment intent
+// awaiting a payment method, which moves it to 'awaiting_next_action' with a redirect
+// as its next action.
+func (s *Server) AttachPaymentMethod(paymentIntentID string, method payrex.PaymentMethod) (*payrex.PaymentIntent, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.tick()
+
+	paymentIntent, err := s.attachPaymentMethod(paymentIntentID, method)
+	if err != nil {
+		return nil, err
+	}
+
+	return clone(paymentIntent), nil
+}
+
+// ProcessPaymentIntent simulates a customer completing the next action of a payment intent,
+// such as authorizing the payment in their e-wallet app, which moves it from
+// 'awaiting_next_action' to 'processing'.
+func (s *Server) ProcessPaymentIntent(paymentIntentID string) (*payrex.PaymentIntent, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.tick()
+
+	paymentIntent, err := s.processPaymentIntent(paymentIntentID)
+	if err != nil {
+		return nil, err
+	}
+
+	return clone(paymentIntent), nil
+}
+
+// SucceedPaymentIntent simulates a successful payment of a 'processing' payment intent.
+//
+// Payment intents paid by card with a manual capture type move to 'awaiting_capture', with the
+// whole amount capturable using [payrex.ServicePaymentIntents.Capture]. Other payment intents
+// move to 'succeeded', like captured payment intents do.
+//
+// When a payment intent succeeds, a paid [payrex.Payment] is created, and the checkout session
+// of the payment intent is completed, or the billing statement of the payment intent is paid.
+func (s *Server) SucceedPaymentIntent(paymentIntentID string) (*payrex.PaymentIntent, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.tick()
+
+	paymentIntent, err := s.succeedPaymentIntent(paymentIntentID)
+	if err != nil {
+		return nil, err
+	}
+
+	return clone(paymentIntent), nil
+}
+
+// FailPaymentIntent simulates a failed payment of a payment intent that is 'awaiting_next_action'
+// or 'processing'. A failed [payrex.Payment] is created, and the payment intent moves back to
+// 'awaiting_payment_method' so the customer can try again.
+func (s *Server) FailPaymentIntent(paymentIntentID string) (*payrex.PaymentIntent, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.tick()
+
+	paymentIntent, err := s.paymentIntentWithStatus(paymentIntentID, "failed",
+		payrex.PaymentIntentStatusAwaitingNextAction, payrex.PaymentIntentStatusProcessing)
+	if err != nil {
+		return nil, err
+	}
+
+	s.newPayment(paymentIntent, paymentIntent.Amount, payrex.PaymentStatusFailed)
+
+	paymentIntent.Status = payrex.PaymentIntentStatusAwaitingPaymentMethod
+	paymentIntent.PaymentMethodID = nil
+	paymentIntent.NextAction = nil
+	s.touch(&paymentIntent.Resource)
+
+	return clone(paymentIntent), nil
+}
+
+// PayPaymentIntent simulates a customer paying a payment intent awaiting a payment method,
+// going through [Server.AttachPaymentMethod], [Server.ProcessPaymentIntent] and
+// [Server.SucceedPaymentIntent].
+func (s *Server) PayPaymentIntent(paymentIntentID string, method payrex.PaymentMethod) (*payrex.PaymentIntent, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.tick()
+
+	if _, err := s.attachPaymentMethod(paymentIntentID, method); err != nil {
+		return nil, err
+	}
+	if _, err := s.processPaymentIntent(paymentIntentID); err != nil {
+		return nil, err
+	}
+	paymentIntent, err := s.succeedPaymentIntent(paymentIntentID)
+	if err != nil {
+		return nil, err
+	}
+
+	return clone(paymentIntent), nil
+}
+
+func (s *Server) attachPaymentMethod(paymentIntentID string, method payrex.PaymentMethod) (*payrex.PaymentIntent, error) {
+	paymentIntent, err := s.paymentIntentWithStatus(paymentIntentID, "paid",
+		payrex.PaymentIntentStatusAwaitingPaymentMethod)
+	if err != nil {
+		return nil, err
+	}
+
+	if !slices.Contains(paymentIntent.PaymentMethods, method) {
+		return nil, invalidParameter("payment_method", fmt.Sprintf("The payment method '%s' is not allowed for the payment intent.", method))
+	}
+
+	s.attachedPaymentMethods[paymentIntent.ID] = method
+
+	paymentIntent.Status = payrex.PaymentIntentStatusAwaitingNextAction
+	paymentIntent.PaymentMethodID = payrex.NotNil(NewID("pm"))
+	paymentIntent.NextAction = &payrex.PaymentIntentNextAction{
+		Type:        "redirect",
+		RedirectURL: "https://checkout.payrexhq.com/redirect/" + paymentIntent.ID,
+	}
+	s.touch(&paymentIntent.Resource)
+
+	return paymentIntent, nil
+}
+
+func (s *Server) processPaymentIntent(paymentIntentID string) (*payrex.PaymentIntent, error) {
+	paymentIntent, err := s.paymentIntentWithStatus(paymentIntentID, "processed",
+		payrex.PaymentIntentStatusAwaitingNextAction)
+	if err != nil {
+		return nil, err
+	}
+
+	paymentIntent.Status = payrex.PaymentIntentStatusProcessing
+	paymentIntent.NextAction = nil
+	s.touch(&paymentIntent.Resource)
+
+	return paymentIntent, nil
+}
+
+func (s *Server) succeedPaymentIntent(paymentIntentID string) (*payrex.PaymentIntent, error) {
+	paymentIntent, err := s.paymentIntentWithStatus(paymentIntentID, "succeeded",
+		payrex.PaymentIntentStatusProcessing)
+	if err != nil {
+		return nil, err
+	}
+
+	if options := paymentIntent.PaymentMethodOptions; options != nil && options.Card.CaptureType == payrex.CaptureTypeManual {
+		paymentIntent.Status = payrex.PaymentIntentStatusAwaitingCapture
+		paymentIntent.AmountCapturable = paymentIntent.Amount
+		s.touch(&paymentIntent.Resource)
+
+		recordEvent(s, payrex.EventTypePaymentIntentAwaitingCapture, paymentIntent)
+
+		return paymentIntent, nil
+	}
+
+	s.completePaymentIntent(paymentIntent, paymentIntent.Amount)
+
+	return paymentIntent, nil
+}
+
+// completePaymentIntent marks a payment intent as succeeded after receiving the amount,
+// and applies the results of the payment.
+func (s *Server) completePaymentIntent(paymentIntent *payrex.PaymentIntent, amount int) {
+	paymentIntent.Status = payrex.PaymentIntentStatusSucceeded
+	paymentIntent.AmountReceived = amount
+	paymentIntent.AmountCapturable = 0
+	s.touch(&paymentIntent.Resource)
+
+	s.newPayment(paymentIntent, amount, payrex.PaymentStatusPaid)
+
+	recordEvent(s, payrex.EventTypePaymentIntentSucceeded, paymentIntent)
+
+	for _, checkoutSession := range s.checkoutSessions.all() {
+		if checkoutSession.PaymentIntent != nil && checkoutSession.PaymentIntent.ID == paymentIntent.ID {
+			checkoutSession.Status = payrex.CheckoutSessionStatusCompleted
+			s.touch(&checkoutSession.Resource)
+		}
+	}
+
+	for _, billingStatement := range s.billingStatements.all() {
+		if billingStatement.PaymentIntent != nil && billingStatement.PaymentIntent.ID == paymentIntent.ID {
+			billingStatement.Status = payrex.BillingStatementStatusPaid
+			s.touch(&billingStatement.Resource)
+
+			recordEvent(s, payrex.EventTypeBillingStatementPaid, billingStatement)
+		}
+	}
+}
+
+// paymentIntentWithStatus returns the payment intent with the given ID if it has one of the statuses.
+// The action is the past tense of what is done with the payment intent, used in error messages.
+func (s *Server) paymentIntentWithStatus(id, action string, statuses ...payrex.PaymentIntentStatus) (*payrex.PaymentIntent, error) {
+	paymentIntent, err := s.paymentIntents.get(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if !slices.Contains(statuses, paymentIntent.Status) {
+		return nil, unexpectedState(fmt.Sprintf("The payment intent cannot be %s because it has a status of '%s'.",
+			action, paymentIntent.Status))
+	}
+
+	return paymentIntent, nil
+}
+
+// newPayment creates and stores a new payment of the amount for a payment intent.
+func (s *Server) newPayment(paymentIntent *payrex.PaymentIntent, amount int, status payrex.PaymentStatus) *payrex.Payment {
+	payment := &payrex.Payment{
+		Resource:        s.newResource(paymentIntent.Livemode, "pay"),
+		Amount:          amount,
+		Currency:        paymentIntent.Currency,
+		Description:     paymentIntent.Description,
+		Metadata:        maps.Clone(paymentIntent.Metadata),
+		NetAmount:       amount,
+		PaymentIntentID: paymentIntent.ID,
+		Status:          status,
+		PaymentMethod:   payrex.PaymentMethodType{Type: s.attachedPaymentMethods[paymentIntent.ID]},
+	}
+	s.payments.put(payment.ID, payment)
+
+	return payment
+}
+
+// Payments returns the payments of a payment intent, from newest to oldest.
+//
+// Payments are created when simulating payments of payment intents,
+// and can't be listed using the API.
+func (s *Server) Payments(paymentIntentID string) []*payrex.Payment {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var payments []*payrex.Payment
+	for _, payment := range s.payments.all() {
+		if payment.PaymentIntentID == paymentIntentID {
+			payments = append(payments, clone(payment))
+		}
+	}
+
+	return payments
+}
+
+// CreatePayout simulates a payout of all paid payments and refunds of the given mode
+// that weren't paid out yet, and returns the 'successful' payout.
+//
+// Every payment and refund becomes a transaction of the payout, listed by
+// [payrex.ServicePayouts.ListTransactions].
+func (s *Server) CreatePayout(mode payrex.Mode) (*payrex.Payout, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.tick()
+
+	livemode := mode == payrex.ModeLive
+	now := int(s.now().Unix())
+
+	var transactions []payrex.PayoutTransaction
+	newTransaction := func(transactionType payrex.PayoutTransactionType, transactionID string, amount, netAmount int) {
+		transactions = append(transactions, payrex.PayoutTransaction{
+			ID:              NewID("po_txn"),
+			Amount:          amount,
+			NetAmount:       netAmount,
+			TransactionType: transactionType,
+			TransactionID:   transactionID,
+			CreatedAt:       now,
+			UpdatedAt:       now,
+		})
+		s.paidOut[transactionID] = true
+	}
+
+	for _, payment := range slices.Backward(s.payments.all()) {
+		if payment.Livemode == livemode && payment.Status == payrex.PaymentStatusPaid && !s.paidOut[payment.ID] {
+			newTransaction(payrex.PayoutTransactionTypePayment, payment.ID, payment.Amount, payment.NetAmount)
+		}
+	}
+	for _, refund := range slices.Backward(s.refunds.all()) {
+		if refund.Livemode == livemode && refund.Status == payrex.RefundStatusSucceeded && !s.paidOut[refund.ID] {
+			newTransaction(payrex.PayoutTransactionTypeRefund, refund.ID, -refund.Amount, -refund.Amount)
+		}
+	}
+
+	if len(transactions) == 0 {
+		return nil, errors.New("payrextest: no payments or refunds to pay out")
+	}
+
+	payout := &payrex.Payout{
+		Resource:    s.newResource(livemode, "po"),
+		Destination: payoutDestination,
+		Status:      payrex.PayoutStatusSuccessful,
+	}
+	for _, transaction := range transactions {
+		payout.Amount += transaction.Amount
+		payout.NetAmount += transaction.NetAmount
+	}
+	s.payouts.put(payout.ID, payout)
+	s.payoutTransactions[payout.ID] = transactions
+
+	recordEvent(s, payrex.EventTypePayoutDeposited, payout)
+
+	return clone(payout), nil
+}
+
+// Payouts returns the payouts created by [Server.CreatePayout], from newest to oldest.
+func (s *Server) Payouts() []*payrex.Payout {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	payouts := s.payouts.all()
+	for i, payout := range payouts {
+		payouts[i] = clone(payout)
+	}
+
+	return payouts
+}
+
+// clone returns a deep copy of a resource, so it's not changed by later changes to the resource.
+func clone[T any](resource *T) *T {
+	data, err := json.Marshal(resource)
+	if err != nil {
+		panic(fmt.Sprintf("payrextest: could not encode resource: %v", err))
+	}
+
+	var c T
+	if err := json.Unmarshal(data, &c); err != nil {
+		panic(fmt.Sprintf("payrextest: could not decode resource: %v", err))
+	}
+
+	return &c
+}
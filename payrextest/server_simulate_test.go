@@ -0,0 +1,206 @@
+package payrextest
+
+import (
+	"testing"
+	"time"
+
+	"github.com/angelofallars/payrex-go"
+)
+
+// lastEvent returns the data of the last event of the server,
+// failing the test if it's not of the given type.
+func lastEvent[T EventResource](t *testing.T, s *Server, eventType payrex.EventType) *T {
+	t.Helper()
+
+	events := s.Events()
+	if len(events) == 0 {
+		t.Fatalf("got no events, want a '%s' event", eventType)
+	}
+
+	event := events[len(events)-1]
+	if event.Type != eventType {
+		t.Fatalf("got last event '%s', want '%s'", event.Type, eventType)
+	}
+	return event.Data.(*T)
+}
+
+func TestServerManualCapture(t *testing.T) {
+	client, server := NewClient(t)
+
+	paymentIntent, err := client.PaymentIntents.Create(&payrex.PaymentIntentCreateParams{
+		Amount:         1000_00,
+		Currency:       payrex.CurrencyPHP,
+		PaymentMethods: payrex.Slice(payrex.PaymentMethodCard),
+		PaymentMethodOptions: &payrex.PaymentMethodOptions{
+			Card: payrex.Card{CaptureType: payrex.CaptureTypeManual},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	paymentIntent, err = server.PayPaymentIntent(paymentIntent.ID, payrex.PaymentMethodCard)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if paymentIntent.Status != payrex.PaymentIntentStatusAwaitingCapture || paymentIntent.AmountCapturable != 1000_00 {
+		t.Errorf("got status '%s' with %d capturable, want '%s' with 100000 capturable",
+			paymentIntent.Status, paymentIntent.AmountCapturable, payrex.PaymentIntentStatusAwaitingCapture)
+	}
+	if data := lastEvent[payrex.PaymentIntent](t, server, payrex.EventTypePaymentIntentAwaitingCapture); data.AmountCapturable != 1000_00 {
+		t.Errorf("got event with %d capturable, want 100000", data.AmountCapturable)
+	}
+
+	paymentIntent, err = client.PaymentIntents.Capture(paymentIntent.ID, &payrex.PaymentIntentCaptureParams{Amount: 600_00})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if paymentIntent.Status != payrex.PaymentIntentStatusSucceeded || paymentIntent.AmountReceived != 600_00 {
+		t.Errorf("got status '%s' with %d received, want '%s' with 60000 received",
+			paymentIntent.Status, paymentIntent.AmountReceived, payrex.PaymentIntentStatusSucceeded)
+	}
+	if data := lastEvent[payrex.PaymentIntent](t, server, payrex.EventTypePaymentIntentSucceeded); data.AmountCapturable != 0 {
+		t.Errorf("got event with %d capturable after capturing, want 0", data.AmountCapturable)
+	}
+}
+
+func TestServerCheckoutSessionExpiry(t *testing.T) {
+	client, server := NewClient(t)
+
+	checkoutSession, err := client.CheckoutSessions.Create(&payrex.CheckoutSessionCreateParams{
+		Currency:       payrex.CurrencyPHP,
+		LineItems:      []payrex.CheckoutSessionLineItemParams{{Name: "Mug", Amount: 100_00, Quantity: 1}},
+		SuccessURL:     "https://example.com/success",
+		CancelURL:      "https://example.com/cancel",
+		PaymentMethods: payrex.Slice(payrex.PaymentMethodCard),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	eventCount := len(server.Events())
+
+	server.Advance(defaultCheckoutSessionLifetime - time.Minute)
+	if got := len(server.Events()); got != eventCount {
+		t.Errorf("got %d new events before the checkout session expires, want none", got-eventCount)
+	}
+
+	server.Advance(time.Minute)
+	checkoutSession, err = client.CheckoutSessions.Retrieve(checkoutSession.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if checkoutSession.Status != payrex.CheckoutSessionStatusExpired {
+		t.Errorf("got status '%s', want '%s'", checkoutSession.Status, payrex.CheckoutSessionStatusExpired)
+	}
+	if data := lastEvent[payrex.CheckoutSession](t, server, payrex.EventTypeCheckoutSessionExpired); data.ID != checkoutSession.ID {
+		t.Errorf("got event for checkout session '%s', want '%s'", data.ID, checkoutSession.ID)
+	}
+}
+
+func TestServerBillingStatementOverdue(t *testing.T) {
+	client, server := NewClient(t)
+
+	customer, err := client.Customers.Create(&payrex.CustomerCreateParams{
+		Currency: payrex.CurrencyPHP,
+		Name:     "Juan Dela Cruz",
+		Email:    "juan@example.com",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	billingStatement, err := client.BillingStatements.Create(&payrex.BillingStatementCreateParams{
+		CustomerID:      customer.ID,
+		Currency:        payrex.CurrencyPHP,
+		PaymentSettings: payrex.PaymentSettings{PaymentMethods: payrex.Slice(payrex.PaymentMethodCard)},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = client.BillingStatementLineItems.Create(&payrex.BillingStatementLineItemCreateParams{
+		BillingStatementID: billingStatement.ID,
+		Description:        "Rent",
+		UnitPrice:          1000_00,
+		Quantity:           1,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.BillingStatements.Finalize(billingStatement.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := server.SetBillingStatementDueTime(billingStatement.ID, server.Now().Add(24*time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	eventCount := len(server.Events())
+
+	server.Advance(23 * time.Hour)
+	if got := len(server.Events()); got != eventCount {
+		t.Errorf("got %d new events before the billing statement is due, want none", got-eventCount)
+	}
+
+	server.Advance(2 * time.Hour)
+	data := lastEvent[payrex.BillingStatement](t, server, payrex.EventTypeBillingStatementOverdue)
+	if data.ID != billingStatement.ID || data.Status != payrex.BillingStatementStatusOpen {
+		t.Errorf("got event for billing statement '%s' with status '%s', want '%s' with status '%s'",
+			data.ID, data.Status, billingStatement.ID, payrex.BillingStatementStatusOpen)
+	}
+
+	// Billing statements only go overdue once
+	server.Advance(24 * time.Hour)
+	if got := len(server.Events()); got != eventCount+1 {
+		t.Errorf("got %d new events, want only one 'billing_statement.overdue' event", got-eventCount)
+	}
+}
+
+func TestServerCreatePayout(t *testing.T) {
+	client, server := NewClient(t)
+
+	var paymentIDs []string
+	for _, amount := range []int{100_00, 250_00} {
+		paymentIntent, err := client.PaymentIntents.Create(&payrex.PaymentIntentCreateParams{
+			Amount:         amount,
+			Currency:       payrex.CurrencyPHP,
+			PaymentMethods: payrex.Slice(payrex.PaymentMethodGCash),
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := server.PayPaymentIntent(paymentIntent.ID, payrex.PaymentMethodGCash); err != nil {
+			t.Fatal(err)
+		}
+		paymentIDs = append(paymentIDs, server.Payments(paymentIntent.ID)[0].ID)
+	}
+
+	payout, err := server.CreatePayout(payrex.ModeTest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if payout.Status != payrex.PayoutStatusSuccessful || payout.Amount != 350_00 {
+		t.Errorf("got payout with status '%s' for %d, want status '%s' for 35000",
+			payout.Status, payout.Amount, payrex.PayoutStatusSuccessful)
+	}
+	if data := lastEvent[payrex.Payout](t, server, payrex.EventTypePayoutDeposited); data.ID != payout.ID {
+		t.Errorf("got event for payout '%s', want '%s'", data.ID, payout.ID)
+	}
+
+	transactions, err := client.Payouts.ListTransactions(payout.ID, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(transactions.Data) != 2 {
+		t.Fatalf("got %d payout transactions, want 2", len(transactions.Data))
+	}
+	// Transactions are listed from newest to oldest
+	for i, transaction := range transactions.Data {
+		want := paymentIDs[len(paymentIDs)-1-i]
+		if transaction.TransactionType != payrex.PayoutTransactionTypePayment || transaction.TransactionID != want {
+			t.Errorf("got transaction of %s '%s', want payment '%s'", transaction.TransactionType, transaction.TransactionID, want)
+		}
+	}
+
+	// Paid out payments aren't paid out again
+	if _, err := server.CreatePayout(payrex.ModeTest); err == nil {
+		t.Error("got a second payout of the same payments, want an error")
+	}
+}
@@ -0,0 +1,241 @@
+package payrextest
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"slices"
+	"time"
+
+	"github.com/angelofallars/payrex-go"
+)
+
+// State is a snapshot of the resources of a [Server], encoded to JSON in the same shape
+// as the responses of the PayRex API. It's used to persist a server between restarts,
+// and to seed a server with fixtures.
+//
+// Resources and events are listed from oldest to newest. Webhook deliveries are not included.
+type State struct {
+	// The time the server's clock is frozen at, or nil if it follows the real time.
+	FrozenTime *time.Time `json:"frozen_time,omitempty"`
+
+	BillingStatementLineItems []*payrex.BillingStatementLineItem    `json:"billing_statement_line_items,omitempty"`
+	BillingStatements         []*payrex.BillingStatement            `json:"billing_statements,omitempty"`
+	CheckoutSessions          []*payrex.CheckoutSession             `json:"checkout_sessions,omitempty"`
+	CustomerSessions          []*payrex.CustomerSession             `json:"customer_sessions,omitempty"`
+	Customers                 []*payrex.Customer                    `json:"customers,omitempty"`
+	PaymentIntents            []*payrex.PaymentIntent               `json:"payment_intents,omitempty"`
+	Payments                  []*payrex.Payment                     `json:"payments,omitempty"`
+	Payouts                   []*payrex.Payout                      `json:"payouts,omitempty"`
+	PayoutTransactions        map[string][]payrex.PayoutTransaction `json:"payout_transactions,omitempty"`
+	Refunds                   []*payrex.Refund                      `json:"refunds,omitempty"`
+	Webhooks                  []*payrex.Webhook                     `json:"webhooks,omitempty"`
+
+	// The IDs of deleted customers.
+	DeletedCustomers []string `json:"deleted_customers,omitempty"`
+	// The due times of billing statements set by [Server.SetBillingStatementDueTime],
+	// keyed by billing statement ID.
+	BillingStatementDueTimes map[string]time.Time `json:"billing_statement_due_times,omitempty"`
+	// The IDs of billing statements that went overdue.
+	OverdueBillingStatements []string `json:"overdue_billing_statements,omitempty"`
+	// The IDs of payments and refunds included in a payout.
+	PaidOut []string `json:"paid_out,omitempty"`
+	// The payment methods chosen for payment intents, keyed by payment intent ID.
+	AttachedPaymentMethods map[string]payrex.PaymentMethod `json:"attached_payment_methods,omitempty"`
+
+	// The events of the changes to resources, so they can be resent after a restart.
+	Events []*payrex.Event `json:"events,omitempty"`
+}
+
+// State returns a snapshot of the resources of the server.
+func (s *Server) State() *State {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	state := &State{
+		BillingStatementLineItems: snapshot(s.billingStatementLineItems),
+		BillingStatements:         snapshot(s.billingStatements),
+		CheckoutSessions:          snapshot(s.checkoutSessions),
+		CustomerSessions:          snapshot(s.customerSessions),
+		Customers:                 snapshot(s.customers),
+		PaymentIntents:            snapshot(s.paymentIntents),
+		Payments:                  snapshot(s.payments),
+		Payouts:                   snapshot(s.payouts),
+		PayoutTransactions:        map[string][]payrex.PayoutTransaction{},
+		Refunds:                   snapshot(s.refunds),
+		Webhooks:                  snapshot(s.webhooks),
+		DeletedCustomers:          sortedKeys(s.deletedCustomers),
+		OverdueBillingStatements:  sortedKeys(s.overdueBillingStatements),
+		PaidOut:                   sortedKeys(s.paidOut),
+		AttachedPaymentMethods:    map[string]payrex.PaymentMethod{},
+		BillingStatementDueTimes:  maps.Clone(s.billingStatementDueTimes),
+	}
+
+	if s.frozenTime != nil {
+		frozenTime := *s.frozenTime
+		state.FrozenTime = &frozenTime
+	}
+	for payoutID, transactions := range s.payoutTransactions {
+		state.PayoutTransactions[payoutID] = slices.Clone(transactions)
+	}
+	for paymentIntentID, method := range s.attachedPaymentMethods {
+		state.AttachedPaymentMethods[paymentIntentID] = method
+	}
+
+	for _, event := range s.events {
+		var parsed payrex.Event
+		if err := json.Unmarshal(event.MustPayload(), &parsed); err != nil {
+			panic(fmt.Sprintf("payrextest: could not decode event: %v", err))
+		}
+		state.Events = append(state.Events, &parsed)
+	}
+
+	return state
+}
+
+// LoadState adds the resources of the state to the server, replacing the resources with the same IDs.
+// The server's clock is frozen if the state has a frozen time.
+//
+// To make writing fixtures easier, resources without an ID get a random one, resources without
+// a creation time are created at the current time, and billing statements without line items
+// get them from the billing statement line items of the state.
+func (s *Server) LoadState(state *State) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if state.FrozenTime != nil {
+		frozenTime := *state.FrozenTime
+		s.frozenTime = &frozenTime
+	}
+
+	loadResources(s, s.billingStatementLineItems, state.BillingStatementLineItems, "bstm_li",
+		func(b *payrex.BillingStatementLineItem) *payrex.Resource { return &b.Resource })
+	loadResources(s, s.billingStatements, state.BillingStatements, "bstm",
+		func(b *payrex.BillingStatement) *payrex.Resource { return &b.Resource })
+	loadResources(s, s.checkoutSessions, state.CheckoutSessions, "cs",
+		func(c *payrex.CheckoutSession) *payrex.Resource { return &c.Resource })
+	loadResources(s, s.customerSessions, state.CustomerSessions, "cuss",
+		func(c *payrex.CustomerSession) *payrex.Resource { return &c.Resource })
+	loadResources(s, s.customers, state.Customers, "cus",
+		func(c *payrex.Customer) *payrex.Resource { return &c.Resource })
+	loadResources(s, s.paymentIntents, state.PaymentIntents, "pi",
+		func(p *payrex.PaymentIntent) *payrex.Resource { return &p.Resource })
+	loadResources(s, s.payments, state.Payments, "pay",
+		func(p *payrex.Payment) *payrex.Resource { return &p.Resource })
+	loadResources(s, s.payouts, state.Payouts, "po",
+		func(p *payrex.Payout) *payrex.Resource { return &p.Resource })
+	loadResources(s, s.refunds, state.Refunds, "re",
+		func(r *payrex.Refund) *payrex.Resource { return &r.Resource })
+	loadResources(s, s.webhooks, state.Webhooks, "wh",
+		func(w *payrex.Webhook) *payrex.Resource { return &w.Resource })
+
+	for payoutID, transactions := range state.PayoutTransactions {
+		s.payoutTransactions[payoutID] = slices.Clone(transactions)
+	}
+	for _, id := range state.DeletedCustomers {
+		s.deletedCustomers[id] = true
+	}
+	for billingStatementID, dueAt := range state.BillingStatementDueTimes {
+		s.billingStatementDueTimes[billingStatementID] = dueAt
+	}
+	for _, id := range state.OverdueBillingStatements {
+		s.overdueBillingStatements[id] = true
+	}
+	for _, id := range state.PaidOut {
+		s.paidOut[id] = true
+	}
+	for paymentIntentID, method := range state.AttachedPaymentMethods {
+		s.attachedPaymentMethods[paymentIntentID] = method
+	}
+	for _, event := range state.Events {
+		s.loadEvent(event)
+	}
+
+	// Checkout sessions and billing statements share their payment intent with the stored one,
+	// so changes to the payment intent show up in them
+	for _, checkoutSession := range s.checkoutSessions.all() {
+		checkoutSession.PaymentIntent = s.linkPaymentIntent(checkoutSession.PaymentIntent)
+	}
+	for _, billingStatement := range s.billingStatements.all() {
+		billingStatement.PaymentIntent = s.linkPaymentIntent(billingStatement.PaymentIntent)
+		if billingStatement.LineItems == nil {
+			s.syncBillingStatementLineItems(billingStatement)
+		}
+	}
+}
+
+// loadEvent stores the event, replacing the event with the same ID.
+// The data of the event is kept as raw JSON.
+func (s *Server) loadEvent(event *payrex.Event) {
+	loaded := &Event{
+		ID:                 event.ID,
+		Type:               event.Type,
+		Livemode:           event.Livemode,
+		PendingWebhooks:    event.PendingWebhooks,
+		PreviousAttributes: maps.Clone(event.PreviousAttributes),
+		CreatedAt:          time.Unix(int64(event.CreatedAt), 0),
+		Data:               slices.Clone(event.Data),
+		resourceType:       event.ResourceType,
+	}
+	if loaded.ID == "" {
+		loaded.ID = NewID("evt")
+	}
+
+	if i := slices.IndexFunc(s.events, func(e *Event) bool { return e.ID == loaded.ID }); i >= 0 {
+		s.events[i] = loaded
+		return
+	}
+	s.events = append(s.events, loaded)
+}
+
+// linkPaymentIntent returns the stored payment intent with the ID of the payment intent,
+// storing the payment intent if there is none.
+func (s *Server) linkPaymentIntent(paymentIntent *payrex.PaymentIntent) *payrex.PaymentIntent {
+	if paymentIntent == nil {
+		return nil
+	}
+
+	if stored, err := s.paymentIntents.get(paymentIntent.ID); err == nil {
+		return stored
+	}
+
+	s.paymentIntents.put(paymentIntent.ID, paymentIntent)
+	return paymentIntent
+}
+
+// loadResources stores copies of the resources in the collection,
+// filling in their ID and timestamps if they're not set.
+func loadResources[T any](s *Server, c *collection[T], items []*T, idPrefix string, resourceOf func(*T) *payrex.Resource) {
+	now := int(s.now().Unix())
+
+	for _, item := range items {
+		item = clone(item)
+
+		resource := resourceOf(item)
+		if resource.ID == "" {
+			resource.ID = NewID(idPrefix)
+		}
+		if resource.CreatedAt == 0 {
+			resource.CreatedAt = now
+		}
+		if resource.UpdatedAt == 0 {
+			resource.UpdatedAt = resource.CreatedAt
+		}
+
+		c.put(resource.ID, item)
+	}
+}
+
+// snapshot returns copies of the resources of the collection, from oldest to newest.
+func snapshot[T any](c *collection[T]) []*T {
+	items := make([]*T, 0, len(c.ids))
+	for _, id := range c.ids {
+		items = append(items, clone(c.items[id]))
+	}
+	return items
+}
+
+// sortedKeys returns the keys of the set in sorted order.
+func sortedKeys(set map[string]bool) []string {
+	return slices.Sorted(maps.Keys(set))
+}
@@ -0,0 +1,266 @@
+package payrextest
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/angelofallars/payrex-go"
+)
+
+func TestServerCheckoutSessionLineItems(t *testing.T) {
+	client, _ := NewClient(t)
+
+	checkoutSession, err := client.CheckoutSessions.Create(&payrex.CheckoutSessionCreateParams{
+		Currency: payrex.CurrencyPHP,
+		LineItems: []payrex.CheckoutSessionLineItemParams{
+			{Name: "Mug", Amount: 100_00, Quantity: 1},
+			{Name: "Shirt", Amount: 200_00, Quantity: 2, Description: payrex.NotNil("Small")},
+		},
+		SuccessURL:     "https://example.com/success",
+		CancelURL:      "https://example.com/cancel",
+		PaymentMethods: payrex.Slice(payrex.PaymentMethodCard),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	lineItems := checkoutSession.LineItems
+	if len(lineItems) != 2 {
+		t.Fatalf("got %d line items, want 2", len(lineItems))
+	}
+	if lineItems[0].Description != nil {
+		t.Errorf("got description '%s' for the first line item, want none", *lineItems[0].Description)
+	}
+	if lineItems[1].Description == nil || *lineItems[1].Description != "Small" {
+		t.Errorf("got description %v for the second line item, want 'Small'", lineItems[1].Description)
+	}
+	if checkoutSession.PaymentIntent.Amount != 500_00 {
+		t.Errorf("got amount %d, want %d", checkoutSession.PaymentIntent.Amount, 500_00)
+	}
+}
+
+func TestServerHandlerPanic(t *testing.T) {
+	s := NewUnstartedServer()
+	s.handle("GET /panic", func(r *http.Request) (any, error) {
+		panic("handler panicked")
+	})
+
+	httpServer := httptest.NewUnstartedServer(s)
+	httpServer.Config.ErrorLog = log.New(io.Discard, "", 0)
+	httpServer.Start()
+	defer httpServer.Close()
+
+	req, err := http.NewRequest(http.MethodGet, httpServer.URL+"/panic", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.SetBasicAuth(NewID("sk_test"), "")
+	if res, err := http.DefaultClient.Do(req); err == nil {
+		res.Body.Close()
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		client := payrex.NewClient(NewID("sk_test")).WithBaseURL(httpServer.URL)
+		_, err := client.Customers.Create(&payrex.CustomerCreateParams{
+			Currency: payrex.CurrencyPHP,
+			Name:     "Juan Dela Cruz",
+			Email:    "juan@example.com",
+		})
+		done <- err
+	}()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("server still locked after a handler panicked")
+	}
+}
+
+func TestServerConcurrentRequests(t *testing.T) {
+	client, server := NewClient(t)
+
+	paymentIntent, err := client.PaymentIntents.Create(&payrex.PaymentIntentCreateParams{
+		Amount:         100_00,
+		Currency:       payrex.CurrencyPHP,
+		PaymentMethods: payrex.Slice(payrex.PaymentMethodGCash),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var wg sync.WaitGroup
+	for range 10 {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			if _, err := client.PaymentIntents.Retrieve(paymentIntent.ID); err != nil {
+				t.Error(err)
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			server.Advance(time.Minute)
+		}()
+	}
+	wg.Wait()
+
+	if _, err := server.PayPaymentIntent(paymentIntent.ID, payrex.PaymentMethodGCash); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestServerPaymentMetadataIsCopied(t *testing.T) {
+	client, server := NewClient(t)
+
+	paymentIntent, err := client.PaymentIntents.Create(&payrex.PaymentIntentCreateParams{
+		Amount:         100_00,
+		Currency:       payrex.CurrencyPHP,
+		PaymentMethods: payrex.Slice(payrex.PaymentMethodGCash),
+		Metadata:       payrex.Metadata{"order_id": "ord_123"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := server.PayPaymentIntent(paymentIntent.ID, payrex.PaymentMethodGCash); err != nil {
+		t.Fatal(err)
+	}
+
+	server.mu.Lock()
+	defer server.mu.Unlock()
+
+	payment := server.payments.all()[0]
+	payment.Metadata["order_id"] = "ord_456"
+
+	stored, err := server.paymentIntents.get(paymentIntent.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stored.Metadata["order_id"] != "ord_123" {
+		t.Errorf("got payment intent order ID '%s' after changing the payment, want 'ord_123'", stored.Metadata["order_id"])
+	}
+}
+
+func TestServerStateKeepsEvents(t *testing.T) {
+	client, server := NewClient(t)
+
+	paymentIntent, err := client.PaymentIntents.Create(&payrex.PaymentIntentCreateParams{
+		Amount:         100_00,
+		Currency:       payrex.CurrencyPHP,
+		PaymentMethods: payrex.Slice(payrex.PaymentMethodCard),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := server.PayPaymentIntent(paymentIntent.ID, payrex.PaymentMethodCard); err != nil {
+		t.Fatal(err)
+	}
+
+	events := server.Events()
+	if len(events) == 0 {
+		t.Fatal("got no events, want the event of the succeeded payment intent")
+	}
+
+	data, err := json.Marshal(server.State())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var state State
+	if err := json.Unmarshal(data, &state); err != nil {
+		t.Fatal(err)
+	}
+
+	restarted := NewServer()
+	t.Cleanup(restarted.Close)
+	restarted.LoadState(&state)
+
+	loaded := restarted.Events()
+	if len(loaded) != len(events) {
+		t.Fatalf("got %d events after loading the state, want %d", len(loaded), len(events))
+	}
+	for i, event := range events {
+		if got, want := string(loaded[i].MustPayload()), string(event.MustPayload()); got != want {
+			t.Errorf("got payload %s for event %d, want %s", got, i, want)
+		}
+	}
+
+	if err := restarted.ResendEvent(events[0].ID); err != nil {
+		t.Errorf("could not resend an event loaded from the state: %v", err)
+	}
+	if err := restarted.ResendEvent("evt_missing"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("got error %v for a missing event, want %v", err, ErrNotFound)
+	}
+}
+
+func TestServerNoWebhookDeliveriesAfterClose(t *testing.T) {
+	var paying sync.WaitGroup
+	var mu sync.Mutex
+	requests := 0
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		requests++
+		mu.Unlock()
+	}))
+	defer receiver.Close()
+
+	client, server := NewClient(t)
+
+	_, err := client.Webhooks.Create(&payrex.WebhookCreateParams{
+		URL:    receiver.URL,
+		Events: []payrex.EventType{payrex.EventTypePaymentIntentSucceeded},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var paymentIntentIDs []string
+	for range 10 {
+		paymentIntent, err := client.PaymentIntents.Create(&payrex.PaymentIntentCreateParams{
+			Amount:         100_00,
+			Currency:       payrex.CurrencyPHP,
+			PaymentMethods: payrex.Slice(payrex.PaymentMethodCard),
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		paymentIntentIDs = append(paymentIntentIDs, paymentIntent.ID)
+	}
+
+	// Events recorded while closing must not start deliveries during Close's wait
+	for _, id := range paymentIntentIDs[:5] {
+		paying.Add(1)
+		go func() {
+			defer paying.Done()
+			_, _ = server.PayPaymentIntent(id, payrex.PaymentMethodCard)
+		}()
+	}
+	server.Close()
+	paying.Wait()
+
+	mu.Lock()
+	before := requests
+	mu.Unlock()
+
+	for _, id := range paymentIntentIDs[5:] {
+		if _, err := server.PayPaymentIntent(id, payrex.PaymentMethodCard); err != nil {
+			t.Fatal(err)
+		}
+	}
+	server.WaitForWebhooks()
+
+	mu.Lock()
+	defer mu.Unlock()
+	if requests != before {
+		t.Errorf("got %d webhook requests after the server was closed, want none", requests-before)
+	}
+}
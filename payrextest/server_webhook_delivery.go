@@ -0,0 +1,199 @@
+package payrextest
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/angelofallars/payrex-go"
+)
+
+// Default webhook delivery settings of the [Server].
+const (
+	defaultWebhookMaxAttempts = 3
+	defaultWebhookBackoff     = 100 * time.Millisecond
+	defaultWebhookTimeout     = 10 * time.Second
+)
+
+// WebhookDelivery is an attempt of the [Server] to deliver an event to a webhook.
+type WebhookDelivery struct {
+	// The delivered event.
+	Event *Event
+	// The ID of the webhook the event was delivered to.
+	WebhookID string
+	// The URL of the webhook.
+	URL string
+	// The number of the attempt, starting from 1.
+	Attempt int
+	// The status code of the response, or 0 if no response was received.
+	StatusCode int
+	// Why the delivery failed, or nil if the webhook responded with a 2xx status code.
+	Err error
+	// The time the request was sent.
+	Time time.Time
+}
+
+// Succeeded reports whether the webhook responded with a 2xx status code.
+func (d *WebhookDelivery) Succeeded() bool {
+	return d.Err == nil
+}
+
+// WithWebhookRetries sets how many times an event is sent to a webhook until it responds
+// with a 2xx status code, and the delay before the first retry, doubled for each later retry.
+//
+// By default, events are sent up to 3 times, retrying after 100ms and 200ms.
+func (s *Server) WithWebhookRetries(maxAttempts int, backoff time.Duration) *Server {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.webhookMaxAttempts = max(maxAttempts, 1)
+	s.webhookBackoff = backoff
+	return s
+}
+
+// WithWebhookHTTPClient sets the HTTP client used to deliver events to webhooks,
+// e.g. to change the timeout of deliveries. The default client times out after 10 seconds.
+func (s *Server) WithWebhookHTTPClient(httpClient *http.Client) *Server {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.webhookHTTPClient = httpClient
+	return s
+}
+
+// WebhookDeliveries returns the attempts to deliver events to webhooks so far, from oldest to newest.
+//
+// Deliveries run in the background, so use [Server.WaitForWebhooks] first to wait for
+// the deliveries of the changes made so far.
+func (s *Server) WebhookDeliveries() []*WebhookDelivery {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return slices.Clone(s.webhookDeliveries)
+}
+
+// WaitForWebhooks waits until every event created so far was delivered to its webhooks,
+// including retries, or until its deliveries failed every attempt.
+func (s *Server) WaitForWebhooks() {
+	s.pendingDeliveries.Wait()
+}
+
+// ResendEvent delivers an event created by the server again to the webhooks listening to its type.
+func (s *Server) ResendEvent(eventID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	i := slices.IndexFunc(s.events, func(event *Event) bool { return event.ID == eventID })
+	if i < 0 {
+		return notFound("event", eventID)
+	}
+
+	event := *s.events[i]
+	s.deliverEvent(&event)
+
+	return nil
+}
+
+// deliverEvent starts delivering an event in the background to the enabled webhooks
+// listening to its type, in the same mode as the event. Events are not delivered
+// once the server is closed.
+//
+// The server must be locked, so deliveries are not started while [Server.Close] waits for them.
+func (s *Server) deliverEvent(event *Event) {
+	var webhooks []payrex.Webhook
+	for _, webhook := range slices.Backward(s.webhooks.all()) {
+		if webhook.Status == payrex.WebhookStatusEnabled && webhook.Livemode == event.Livemode &&
+			slices.Contains(webhook.Events, event.Type) {
+			webhooks = append(webhooks, *webhook)
+		}
+	}
+
+	event.PendingWebhooks = len(webhooks)
+	payload := event.MustPayload()
+
+	if s.isClosed() {
+		return
+	}
+	for _, webhook := range webhooks {
+		s.pendingDeliveries.Add(1)
+		go s.deliverWebhook(event, payload, webhook, s.webhookHTTPClient, s.webhookMaxAttempts, s.webhookBackoff)
+	}
+}
+
+// deliverWebhook sends the payload of an event to a webhook until it succeeds,
+// it fails every attempt, or the server is closed.
+func (s *Server) deliverWebhook(event *Event, payload []byte, webhook payrex.Webhook, httpClient *http.Client, maxAttempts int, backoff time.Duration) {
+	defer s.pendingDeliveries.Done()
+
+	for attempt := 1; ; attempt++ {
+		if s.isClosed() {
+			return
+		}
+
+		delivery := sendWebhook(httpClient, event, payload, webhook)
+		delivery.Attempt = attempt
+
+		s.mu.Lock()
+		s.webhookDeliveries = append(s.webhookDeliveries, delivery)
+		s.mu.Unlock()
+
+		if delivery.Succeeded() || attempt >= maxAttempts {
+			return
+		}
+
+		select {
+		case <-time.After(backoff << (attempt - 1)):
+		case <-s.closed:
+			return
+		}
+	}
+}
+
+// isClosed reports whether [Server.Close] was called.
+func (s *Server) isClosed() bool {
+	select {
+	case <-s.closed:
+		return true
+	default:
+		return false
+	}
+}
+
+// sendWebhook sends the payload of an event to a webhook once, signed with the webhook's secret key.
+//
+// Payloads are signed at the real current time instead of the server's clock,
+// so they can be verified with the default tolerance of [payrex.ParseEvent].
+func sendWebhook(httpClient *http.Client, event *Event, payload []byte, webhook payrex.Webhook) *WebhookDelivery {
+	delivery := &WebhookDelivery{
+		Event:     event,
+		WebhookID: webhook.ID,
+		URL:       webhook.URL,
+		Time:      time.Now(),
+	}
+
+	r, err := http.NewRequest(http.MethodPost, webhook.URL, bytes.NewReader(payload))
+	if err != nil {
+		delivery.Err = fmt.Errorf("could not create webhook request: %w", err)
+		return delivery
+	}
+	r.Header.Set("Content-Type", "application/json")
+	r.Header.Set(payrex.WebhookSignatureHeader, SignatureHeader(payload, webhook.SecretKey, event.mode(), delivery.Time))
+
+	res, err := httpClient.Do(r)
+	if err != nil {
+		delivery.Err = fmt.Errorf("could not send webhook request: %w", err)
+		return delivery
+	}
+	defer res.Body.Close()
+	_, _ = io.Copy(io.Discard, res.Body)
+
+	delivery.StatusCode = res.StatusCode
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		delivery.Err = fmt.Errorf("webhook responded with status code %d", res.StatusCode)
+	}
+
+	return delivery
+}
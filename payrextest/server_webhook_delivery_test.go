@@ -0,0 +1,135 @@
+package payrextest
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/angelofallars/payrex-go"
+)
+
+// newWebhookReceiver starts a server that responds to webhook requests with the handler,
+// once it's set, and counts the requests it receives.
+func newWebhookReceiver(t *testing.T) (*httptest.Server, *atomic.Pointer[http.Handler], *atomic.Int32) {
+	t.Helper()
+
+	var handler atomic.Pointer[http.Handler]
+	var requests atomic.Int32
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		if h := handler.Load(); h != nil {
+			(*h).ServeHTTP(w, r)
+		}
+	}))
+	t.Cleanup(receiver.Close)
+
+	return receiver, &handler, &requests
+}
+
+func TestServerWebhookDelivery(t *testing.T) {
+	client, server := NewClient(t)
+	server.WithWebhookRetries(3, time.Millisecond)
+	liveClient := payrex.NewClient(NewID("sk_live")).WithBaseURL(server.URL)
+
+	receiver, handler, requests := newWebhookReceiver(t)
+	webhook, err := client.Webhooks.Create(&payrex.WebhookCreateParams{
+		URL:    receiver.URL,
+		Events: []payrex.EventType{payrex.EventTypePaymentIntentSucceeded},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Webhooks that must not receive the event
+	ignoredReceiver, _, ignoredRequests := newWebhookReceiver(t)
+	ignored := []struct {
+		client *payrex.Client
+		events []payrex.EventType
+	}{
+		{client: liveClient, events: []payrex.EventType{payrex.EventTypePaymentIntentSucceeded}},
+		{client: client, events: []payrex.EventType{payrex.EventTypeRefundCreated}},
+		{client: client, events: []payrex.EventType{payrex.EventTypePaymentIntentSucceeded}},
+	}
+	for i, definition := range ignored {
+		ignoredWebhook, err := definition.client.Webhooks.Create(&payrex.WebhookCreateParams{
+			URL:    ignoredReceiver.URL,
+			Events: definition.events,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if i == len(ignored)-1 {
+			if _, err := client.Webhooks.Disable(ignoredWebhook.ID); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	var mu sync.Mutex
+	var received []string
+	failures := 1
+	router := payrex.NewWebhookRouter(payrex.NewWebhookVerifier(webhook.SecretKey))
+	payrex.HandleEventResource(router, payrex.EventTypePaymentIntentSucceeded,
+		func(_ context.Context, _ *payrex.Event, paymentIntent *payrex.PaymentIntent) error {
+			mu.Lock()
+			defer mu.Unlock()
+
+			// Fail the first delivery, so it's retried
+			if failures > 0 {
+				failures--
+				return payrex.WithStatus(http.StatusServiceUnavailable, errors.New("database is down"))
+			}
+			received = append(received, paymentIntent.ID)
+			return nil
+		},
+	)
+	var h http.Handler = router
+	handler.Store(&h)
+
+	paymentIntent, err := client.PaymentIntents.Create(&payrex.PaymentIntentCreateParams{
+		Amount:         100_00,
+		Currency:       payrex.CurrencyPHP,
+		PaymentMethods: payrex.Slice(payrex.PaymentMethodCard),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := server.PayPaymentIntent(paymentIntent.ID, payrex.PaymentMethodCard); err != nil {
+		t.Fatal(err)
+	}
+	server.WaitForWebhooks()
+
+	deliveries := server.WebhookDeliveries()
+	if len(deliveries) != 2 {
+		t.Fatalf("got %d deliveries, want 2", len(deliveries))
+	}
+	if deliveries[0].StatusCode != http.StatusServiceUnavailable || deliveries[0].Succeeded() {
+		t.Errorf("got first delivery with status %d, want a failed delivery with status 503", deliveries[0].StatusCode)
+	}
+	if deliveries[1].Attempt != 2 || !deliveries[1].Succeeded() {
+		t.Errorf("got attempt %d succeeded: %t, want the second attempt to succeed", deliveries[1].Attempt, deliveries[1].Succeeded())
+	}
+
+	// The event is delivered again to the webhooks listening to it
+	if err := server.ResendEvent(deliveries[1].Event.ID); err != nil {
+		t.Fatal(err)
+	}
+	server.WaitForWebhooks()
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(received) != 2 || received[0] != paymentIntent.ID || received[1] != paymentIntent.ID {
+		t.Errorf("got verified events for payment intents %v, want '%s' twice", received, paymentIntent.ID)
+	}
+	if got := requests.Load(); got != 3 {
+		t.Errorf("got %d requests, want 3", got)
+	}
+	if got := ignoredRequests.Load(); got != 0 {
+		t.Errorf("got %d requests to webhooks in another mode, of other event types, or disabled, want none", got)
+	}
+}
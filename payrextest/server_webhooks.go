@@ -0,0 +1,150 @@
+package payrextest
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/angelofallars/payrex-go"
+)
+
+func (s *Server) routeWebhooks() {
+	s.handle("POST /webhooks", s.handleCreateWebhook)
+	s.handle("GET /webhooks", s.handleListWebhooks)
+	s.handle("GET /webhooks/{id}", func(r *http.Request) (any, error) {
+		return s.webhooks.get(r.PathValue("id"))
+	})
+	s.handle("PUT /webhooks/{id}", s.handleUpdateWebhook)
+	s.handle("DELETE /webhooks/{id}", func(r *http.Request) (any, error) {
+		webhook, err := s.webhooks.get(r.PathValue("id"))
+		if err != nil {
+			return nil, err
+		}
+		s.webhooks.remove(webhook.ID)
+
+		return &payrex.DeletedResource{ID: webhook.ID, Deleted: true}, nil
+	})
+	s.handle("POST /webhooks/{id}/enable", s.webhookTransition(payrex.WebhookStatusEnabled))
+	s.handle("POST /webhooks/{id}/disable", s.webhookTransition(payrex.WebhookStatusDisabled))
+}
+
+func (s *Server) handleCreateWebhook(r *http.Request) (any, error) {
+	var params payrex.WebhookCreateParams
+	if err := decodeParams(r, &params); err != nil {
+		return nil, err
+	}
+
+	if err := validateWebhookURL("url", params.URL); err != nil {
+		return nil, err
+	}
+	if err := validateEventTypes("events", params.Events); err != nil {
+		return nil, err
+	}
+
+	webhook := &payrex.Webhook{
+		Resource:    s.newResource(isLivemode(r), "wh"),
+		SecretKey:   NewID("whsk"),
+		Status:      payrex.WebhookStatusEnabled,
+		Description: params.Description,
+		URL:         params.URL,
+		Events:      params.Events,
+	}
+	s.webhooks.put(webhook.ID, webhook)
+
+	return webhook, nil
+}
+
+func (s *Server) handleListWebhooks(r *http.Request) (any, error) {
+	var params payrex.WebhookListParams
+	if err := decodeParams(r, &params); err != nil {
+		return nil, err
+	}
+
+	var webhooks []*payrex.Webhook
+	for _, webhook := range s.webhooks.all() {
+		switch {
+		case params.URL != nil && webhook.URL != *params.URL:
+		case params.Description != nil && (webhook.Description == nil || *webhook.Description != *params.Description):
+		default:
+			webhooks = append(webhooks, webhook)
+		}
+	}
+
+	return paginate(r, webhooks, func(w *payrex.Webhook) string { return w.ID })
+}
+
+func (s *Server) handleUpdateWebhook(r *http.Request) (any, error) {
+	webhook, err := s.webhooks.get(r.PathValue("id"))
+	if err != nil {
+		return nil, err
+	}
+
+	var params payrex.WebhookUpdateParams
+	if err := decodeParams(r, &params); err != nil {
+		return nil, err
+	}
+
+	if params.URL != nil {
+		if err := validateWebhookURL("url", *params.URL); err != nil {
+			return nil, err
+		}
+		webhook.URL = *params.URL
+	}
+	if params.Events != nil {
+		if err := validateEventTypes("events", *params.Events); err != nil {
+			return nil, err
+		}
+		webhook.Events = *params.Events
+	}
+	if params.Description != nil {
+		webhook.Description = params.Description
+	}
+	s.touch(&webhook.Resource)
+
+	return webhook, nil
+}
+
+// webhookTransition returns a handler that sets the status of a webhook.
+func (s *Server) webhookTransition(to payrex.WebhookStatus) func(r *http.Request) (any, error) {
+	return func(r *http.Request) (any, error) {
+		webhook, err := s.webhooks.get(r.PathValue("id"))
+		if err != nil {
+			return nil, err
+		}
+
+		if webhook.Status == to {
+			return nil, unexpectedState(fmt.Sprintf("The webhook is already %s.", to))
+		}
+		webhook.Status = to
+		s.touch(&webhook.Resource)
+
+		return webhook, nil
+	}
+}
+
+func validateWebhookURL(parameter, rawURL string) error {
+	if rawURL == "" {
+		return requiredParameter(parameter)
+	}
+
+	u, err := url.Parse(rawURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return invalidParameter(parameter, fmt.Sprintf("The %s must be a valid HTTP or HTTPS URL.", parameter))
+	}
+
+	return nil
+}
+
+func validateEventTypes(parameter string, eventTypes []payrex.EventType) error {
+	if len(eventTypes) == 0 {
+		return requiredParameter(parameter)
+	}
+
+	for _, eventType := range eventTypes {
+		if !eventType.Valid() {
+			return invalidParameter(parameter, fmt.Sprintf("The event type '%s' is invalid.", eventType))
+		}
+	}
+
+	return nil
+}
@@ -1,5 +1,7 @@
 package payrex
 
+import "net/url"
+
 // Refund resources represent a refunded amount of a paid payment.
 //
 // Service: [ServiceRefunds]
@@ -7,14 +9,16 @@ package payrex
 // API reference: https://docs.payrexhq.com/docs/api/refunds
 type Refund struct {
 	Resource
-	Amount      int                `json:"amount"`
-	Currency    Currency           `json:"currency"`
-	Status      RefundStatus       `json:"status"`
-	Description *string            `json:"description"`
-	Reason      RefundReason       `json:"reason"`
-	Remarks     *string            `json:"remarks"`
-	PaymentID   string             `json:"payment_id"`
-	Metadata    *map[string]string `json:"metadata"`
+	Amount      int          `json:"amount"`
+	Currency    Currency     `json:"currency"`
+	Status      RefundStatus `json:"status"`
+	Description *string      `json:"description"`
+	Reason      RefundReason `json:"reason"`
+	Remarks     *string      `json:"remarks"`
+	PaymentID   string       `json:"payment_id"`
+	Metadata    Metadata     `json:"metadata"`
+
+	Extra ExtraFields `json:"-"`
 }
 
 // RefundStatus enumerates the valid values for the [Refund].Status field.
@@ -72,18 +76,22 @@ func (s *ServiceRefunds) Update(id string, params *RefundUpdateParams) (*Refund,
 //
 // API reference: https://docs.payrexhq.com/docs/api/refunds/create
 type RefundCreateParams struct {
-	Amount      int                `form:"amount"`
-	Currency    Currency           `form:"currency"`
-	Description *string            `form:"description"`
-	PaymentID   string             `form:"payment_id"`
-	Remarks     *string            `form:"remarks"`
-	Reason      RefundReason       `form:"reason"`
-	Metadata    *map[string]string `form:"metadata"`
+	Amount      int          `form:"amount"`
+	Currency    Currency     `form:"currency"`
+	Description *string      `form:"description"`
+	PaymentID   string       `form:"payment_id"`
+	Remarks     *string      `form:"remarks"`
+	Reason      RefundReason `form:"reason"`
+	Metadata    Metadata     `form:"metadata"`
+
+	Extra url.Values `form:",extra"`
 }
 
 // RefundUpdateParams represents the available [ServiceRefunds.Update] parameters.
 //
 // API reference: https://docs.payrexhq.com/docs/api/refunds/update
 type RefundUpdateParams struct {
-	Metadata *map[string]string `form:"metadata"`
+	Metadata Metadata `form:"metadata"`
+
+	Extra url.Values `form:",extra"`
 }
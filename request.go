@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"encoding/json"
 	"fmt"
+	"io"
 	"iter"
 	"net/http"
 	"reflect"
@@ -14,6 +15,14 @@ import (
 // request makes a request to the PayRex API with the given payload,
 // and returns the JSON response parsed into a value.
 func request[T any](client *Client, method string, path urlPath, payload any) (*T, error) {
+	if err := client.checkMode(); err != nil {
+		return nil, err
+	}
+
+	if err := validateParamsMetadata(payload); err != nil {
+		return nil, err
+	}
+
 	reqURL := client.apiBaseURL + string(path)
 
 	var req *http.Request
@@ -51,6 +60,7 @@ func request[T any](client *Client, method string, path urlPath, payload any) (*
 	if err != nil {
 		return nil, fmt.Errorf("could not do request: %w", err)
 	}
+	defer res.Body.Close()
 
 	if res.StatusCode < 200 || res.StatusCode >= 400 {
 		var errBody Error
@@ -61,11 +71,20 @@ func request[T any](client *Client, method string, path urlPath, payload any) (*
 		return nil, errBody
 	}
 
+	body, err := io.ReadAll(res.Body)
+	if err != nil {
+		return nil, fmt.Errorf("could not read response: %w", err)
+	}
+
 	var resource T
-	if err := json.NewDecoder(res.Body).Decode(&resource); err != nil {
+	if err := json.NewDecoder(bytes.NewReader(body)).Decode(&resource); err != nil {
 		return nil, fmt.Errorf("could not decode JSON: %w", err)
 	}
 
+	if client.driftHandler != nil {
+		client.reportDrift(method, path, reflect.TypeFor[T](), body)
+	}
+
 	return &resource, nil
 }
 
@@ -103,11 +122,7 @@ func (s *service[T]) delete(id string) (*DeletedResource, error) {
 
 func (s *service[T]) list(params any) iter.Seq2[*T, error] {
 	return func(yield func(*T, error) bool) {
-		resources, err := request[List[T]](s.client,
-			http.MethodGet,
-			s.path.make(),
-			params,
-		)
+		resources, err := s.listPage(params)
 		if err != nil {
 			yield(nil, err)
 			return
@@ -121,6 +136,16 @@ func (s *service[T]) list(params any) iter.Seq2[*T, error] {
 	}
 }
 
+// listPage returns a single page of resources. Use the After parameter
+// with the ID of the last resource to get the next page.
+func (s *service[T]) listPage(params any) (*List[T], error) {
+	return request[List[T]](s.client,
+		http.MethodGet,
+		s.path.make(),
+		params,
+	)
+}
+
 func (s *service[T]) post(path urlPath, params any) (*T, error) {
 	return request[T](s.client,
 		http.MethodPost,
@@ -0,0 +1,172 @@
+package payrex
+
+import "iter"
+
+// API is the interface of [Client], with a method for each of its services.
+//
+// Application code can depend on API or on the interfaces of single services instead of [Client],
+// to swap in fakes such as the mocks of the payrexmock package in unit tests:
+//
+//	type Checkout struct {
+//		PaymentIntents payrex.PaymentIntentsService
+//	}
+//
+//	checkout := &Checkout{PaymentIntents: payrexClient.PaymentIntentsService()}
+type API interface {
+	BillingStatementLineItemsService() BillingStatementLineItemsService
+	BillingStatementsService() BillingStatementsService
+	CheckoutSessionsService() CheckoutSessionsService
+	CustomerSessionsService() CustomerSessionsService
+	CustomersService() CustomersService
+	PaymentIntentsService() PaymentIntentsService
+	PaymentsService() PaymentsService
+	PayoutsService() PayoutsService
+	RefundsService() RefundsService
+	WebhooksService() WebhooksService
+}
+
+// BillingStatementLineItemsService is the interface of [ServiceBillingStatementLineItems].
+type BillingStatementLineItemsService interface {
+	Create(params *BillingStatementLineItemCreateParams) (*BillingStatementLineItem, error)
+	Update(id string, params *BillingStatementLineItemUpdateParams) (*BillingStatementLineItem, error)
+	Delete(id string) (*DeletedResource, error)
+}
+
+// BillingStatementsService is the interface of [ServiceBillingStatements].
+type BillingStatementsService interface {
+	Create(params *BillingStatementCreateParams) (*BillingStatement, error)
+	Retrieve(id string) (*BillingStatement, error)
+	List(params *BillingStatementListParams) iter.Seq2[*BillingStatement, error]
+	Update(id string, params *BillingStatementUpdateParams) (*BillingStatement, error)
+	Delete(id string) (*DeletedResource, error)
+	Finalize(id string) (*BillingStatement, error)
+	MarkUncollectible(id string) (*BillingStatement, error)
+	Send(id string) (*BillingStatement, error)
+	Void(id string) (*BillingStatement, error)
+}
+
+// CheckoutSessionsService is the interface of [ServiceCheckoutSessions].
+type CheckoutSessionsService interface {
+	Create(params *CheckoutSessionCreateParams) (*CheckoutSession, error)
+	List(params *ListCheckoutSessionsParams) iter.Seq2[*CheckoutSession, error]
+	Retrieve(id string) (*CheckoutSession, error)
+	Expire(id string) (*CheckoutSession, error)
+}
+
+// CustomerSessionsService is the interface of [ServiceCustomerSessions].
+type CustomerSessionsService interface {
+	Create(params *CustomerSessionCreateParams) (*CustomerSession, error)
+	Retrieve(id string) (*CustomerSession, error)
+}
+
+// CustomersService is the interface of [ServiceCustomers].
+type CustomersService interface {
+	Create(params *CustomerCreateParams) (*Customer, error)
+	Retrieve(id string) (*Customer, error)
+	List(params *CustomerListParams) iter.Seq2[*Customer, error]
+	Update(id string, params *CustomerUpdateParams) (*Customer, error)
+	Delete(id string) (*DeletedResource, error)
+}
+
+// PaymentIntentsService is the interface of [ServicePaymentIntents].
+type PaymentIntentsService interface {
+	Cancel(id string) (*PaymentIntent, error)
+	Capture(id string, params *PaymentIntentCaptureParams) (*PaymentIntent, error)
+	Create(params *PaymentIntentCreateParams) (*PaymentIntent, error)
+	Retrieve(id string) (*PaymentIntent, error)
+}
+
+// PaymentsService is the interface of [ServicePayments].
+type PaymentsService interface {
+	Retrieve(id string) (*Payment, error)
+	Update(id string, params *PaymentUpdateParams) (*Payment, error)
+}
+
+// PayoutsService is the interface of [ServicePayouts].
+type PayoutsService interface {
+	ListTransactions(id string, params *PayoutTransactionListParams) (*List[PayoutTransaction], error)
+}
+
+// RefundsService is the interface of [ServiceRefunds].
+type RefundsService interface {
+	Create(params *RefundCreateParams) (*Refund, error)
+	Update(id string, params *RefundUpdateParams) (*Refund, error)
+}
+
+// WebhooksService is the interface of [ServiceWebhooks].
+type WebhooksService interface {
+	Create(params *WebhookCreateParams) (*Webhook, error)
+	Retrieve(id string) (*Webhook, error)
+	List(params *WebhookListParams) iter.Seq2[*Webhook, error]
+	Update(id string, params *WebhookUpdateParams) (*Webhook, error)
+	Enable(id string) (*Webhook, error)
+	Disable(id string) (*Webhook, error)
+	Delete(id string) (*DeletedResource, error)
+	Plan(params *WebhookReconcileParams) (*WebhookPlan, error)
+	Apply(plan *WebhookPlan) error
+	Reconcile(params *WebhookReconcileParams) (*WebhookPlan, error)
+}
+
+var (
+	_ API                              = (*Client)(nil)
+	_ BillingStatementLineItemsService = (*ServiceBillingStatementLineItems)(nil)
+	_ BillingStatementsService         = (*ServiceBillingStatements)(nil)
+	_ CheckoutSessionsService          = (*ServiceCheckoutSessions)(nil)
+	_ CustomerSessionsService          = (*ServiceCustomerSessions)(nil)
+	_ CustomersService                 = (*ServiceCustomers)(nil)
+	_ PaymentIntentsService            = (*ServicePaymentIntents)(nil)
+	_ PaymentsService                  = (*ServicePayments)(nil)
+	_ PayoutsService                   = (*ServicePayouts)(nil)
+	_ RefundsService                   = (*ServiceRefunds)(nil)
+	_ WebhooksService                  = (*ServiceWebhooks)(nil)
+)
+
+// BillingStatementLineItemsService returns the [Client.BillingStatementLineItems] service.
+func (c *Client) BillingStatementLineItemsService() BillingStatementLineItemsService {
+	return &c.BillingStatementLineItems
+}
+
+// BillingStatementsService returns the [Client.BillingStatements] service.
+func (c *Client) BillingStatementsService() BillingStatementsService {
+	return &c.BillingStatements
+}
+
+// CheckoutSessionsService returns the [Client.CheckoutSessions] service.
+func (c *Client) CheckoutSessionsService() CheckoutSessionsService {
+	return &c.CheckoutSessions
+}
+
+// CustomerSessionsService returns the [Client.CustomerSessions] service.
+func (c *Client) CustomerSessionsService() CustomerSessionsService {
+	return &c.CustomerSessions
+}
+
+// CustomersService returns the [Client.Customers] service.
+func (c *Client) CustomersService() CustomersService {
+	return &c.Customers
+}
+
+// PaymentIntentsService returns the [Client.PaymentIntents] service.
+func (c *Client) PaymentIntentsService() PaymentIntentsService {
+	return &c.PaymentIntents
+}
+
+// PaymentsService returns the [Client.Payments] service.
+func (c *Client) PaymentsService() PaymentsService {
+	return &c.Payments
+}
+
+// PayoutsService returns the [Client.Payouts] service.
+func (c *Client) PayoutsService() PayoutsService {
+	return &c.Payouts
+}
+
+// RefundsService returns the [Client.Refunds] service.
+func (c *Client) RefundsService() RefundsService {
+	return &c.Refunds
+}
+
+// WebhooksService returns the [Client.Webhooks] service.
+func (c *Client) WebhooksService() WebhooksService {
+	return &c.Webhooks
+}
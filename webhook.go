@@ -1,6 +1,9 @@
 package payrex
 
-import "iter"
+import (
+	"iter"
+	"net/url"
+)
 
 // Webhook is used to notify your application about events in your PayRex account.
 //
@@ -14,6 +17,8 @@ type Webhook struct {
 	Description *string       `json:"description"`
 	URL         string        `json:"url"`
 	Events      []EventType   `json:"events"`
+
+	Extra ExtraFields `json:"-"`
 }
 
 // WebhookStatus enumerates the valid values for the [Webhook].Status field.
@@ -36,10 +41,24 @@ func (s *ServiceWebhooks) setup() {
 
 // Create creates a webhook resource.
 //
+// Wildcard patterns in the events, such as "billing_statement.*", are expanded
+// using [ExpandEventTypes], and patterns matching no known event type are rejected
+// with [ErrUnknownEventType]. Other event types are sent as they are.
+//
 // Endpoint: POST /webhooks
 //
 // API reference: https://docs.payrexhq.com/docs/api/webhooks/create
 func (s *ServiceWebhooks) Create(params *WebhookCreateParams) (*Webhook, error) {
+	if params != nil {
+		events, err := ExpandEventTypes(params.Events...)
+		if err != nil {
+			return nil, err
+		}
+
+		expanded := *params
+		expanded.Events = events
+		params = &expanded
+	}
 	return s.create(params)
 }
 
@@ -63,10 +82,24 @@ func (s *ServiceWebhooks) List(params *WebhookListParams) iter.Seq2[*Webhook, er
 
 // Update updates a webhook resource by ID.
 //
+// Wildcard patterns in the events, such as "billing_statement.*", are expanded
+// using [ExpandEventTypes], and patterns matching no known event type are rejected
+// with [ErrUnknownEventType]. Other event types are sent as they are.
+//
 // Endpoint: PUT /webhooks/:id
 //
 // API reference: https://docs.payrexhq.com/docs/api/webhooks/update
 func (s *ServiceWebhooks) Update(id string, params *WebhookUpdateParams) (*Webhook, error) {
+	if params != nil && params.Events != nil {
+		events, err := ExpandEventTypes(*params.Events...)
+		if err != nil {
+			return nil, err
+		}
+
+		expanded := *params
+		expanded.Events = &events
+		params = &expanded
+	}
 	return s.update(id, params)
 }
 
@@ -104,6 +137,8 @@ type WebhookCreateParams struct {
 	URL         string      `form:"url"`
 	Description *string     `form:"description"`
 	Events      []EventType `form:"events"`
+
+	Extra url.Values `form:",extra"`
 }
 
 // WebhookUpdateParams represents the available [ServiceWebhooks.Update] parameters.
@@ -113,15 +148,19 @@ type WebhookUpdateParams struct {
 	URL         *string      `form:"url"`
 	Description *string      `form:"description"`
 	Events      *[]EventType `form:"events"`
+
+	Extra url.Values `form:",extra"`
 }
 
 // WebhookListParams represents the available [ServiceWebhooks.List] parameters.
 //
 // API reference: https://docs.payrexhq.com/docs/api/webhooks/list
 type WebhookListParams struct {
-	Limit       *int    `form:"int"`
+	Limit       *int    `form:"limit"`
 	Before      *string `form:"before"`
 	After       *string `form:"after"`
 	URL         *string `form:"url"`
 	Description *string `form:"description"`
+
+	Extra url.Values `form:",extra"`
 }
@@ -0,0 +1,320 @@
+package payrex
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// WebhookDefinition is the desired state of a [Webhook],
+// used by [ServiceWebhooks.Plan] and [ServiceWebhooks.Reconcile].
+//
+// Webhooks are identified by their URL.
+type WebhookDefinition struct {
+	URL         string
+	Description string
+	// The event types of the webhook. Wildcard patterns such as "billing_statement.*"
+	// are expanded using [ExpandEventTypes]. At least one event type is required.
+	Events []EventType
+	// Whether the webhook should be disabled. Webhooks are enabled by default.
+	Disabled bool
+}
+
+// status returns the desired status of the webhook.
+func (d *WebhookDefinition) status() WebhookStatus {
+	if d.Disabled {
+		return WebhookStatusDisabled
+	}
+	return WebhookStatusEnabled
+}
+
+// WebhookReconcileParams represents the available [ServiceWebhooks.Plan]
+// and [ServiceWebhooks.Reconcile] parameters.
+type WebhookReconcileParams struct {
+	// The desired webhooks of the account.
+	Webhooks []WebhookDefinition
+	// Whether to delete webhooks with a URL not in the desired webhooks.
+	Prune bool
+}
+
+// WebhookActionType enumerates the types of changes in a [WebhookPlan].
+type WebhookActionType string
+
+const (
+	WebhookActionTypeCreate  WebhookActionType = "create"
+	WebhookActionTypeUpdate  WebhookActionType = "update"
+	WebhookActionTypeEnable  WebhookActionType = "enable"
+	WebhookActionTypeDisable WebhookActionType = "disable"
+	WebhookActionTypeDelete  WebhookActionType = "delete"
+)
+
+// WebhookAction is a change to a webhook in a [WebhookPlan].
+type WebhookAction struct {
+	Type WebhookActionType
+	// The existing webhook. Nil for [WebhookActionTypeCreate] actions.
+	Webhook *Webhook
+	// The desired state of the webhook. Nil for [WebhookActionTypeDelete] actions.
+	Definition *WebhookDefinition
+	// Human-readable descriptions of the changed fields, for [WebhookActionTypeUpdate] actions.
+	Changes []string
+}
+
+// String returns a human-readable description of the action.
+func (a *WebhookAction) String() string {
+	switch a.Type {
+	case WebhookActionTypeCreate:
+		s := fmt.Sprintf("+ create webhook %s (events: %s)", a.Definition.URL, formatEventTypes(a.Definition.Events))
+		if a.Definition.Disabled {
+			s += " (disabled)"
+		}
+		return s
+	case WebhookActionTypeUpdate:
+		return fmt.Sprintf("~ update webhook %s %s: %s", a.Webhook.ID, a.Webhook.URL, strings.Join(a.Changes, ", "))
+	case WebhookActionTypeDelete:
+		return fmt.Sprintf("- delete webhook %s %s", a.Webhook.ID, a.Webhook.URL)
+	default:
+		return fmt.Sprintf("~ %s webhook %s %s", a.Type, a.Webhook.ID, a.Webhook.URL)
+	}
+}
+
+// WebhookPlan lists the changes needed to make the webhooks of the account
+// match a set of [WebhookDefinition] values.
+type WebhookPlan struct {
+	Actions []WebhookAction
+}
+
+// Empty reports whether the plan has no changes.
+func (p *WebhookPlan) Empty() bool {
+	return len(p.Actions) == 0
+}
+
+// String returns a human-readable description of the plan, with one action per line.
+func (p *WebhookPlan) String() string {
+	if p.Empty() {
+		return "no changes"
+	}
+
+	lines := make([]string, len(p.Actions))
+	for i := range p.Actions {
+		lines[i] = p.Actions[i].String()
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Plan compares the webhooks of the account to the desired webhooks,
+// and returns the changes needed to make them match without applying them.
+//
+// Useful for a dry run of [ServiceWebhooks.Reconcile].
+func (s *ServiceWebhooks) Plan(params *WebhookReconcileParams) (*WebhookPlan, error) {
+	if params == nil {
+		return nil, ErrNilParams
+	}
+
+	definitions := slices.Clone(params.Webhooks)
+	desired := map[string]*WebhookDefinition{}
+	for i := range definitions {
+		definition := &definitions[i]
+		if definition.URL == "" {
+			return nil, errors.New("webhook definition has no URL")
+		}
+		if len(definition.Events) == 0 {
+			return nil, fmt.Errorf("webhook definition for URL '%s' has no events", definition.URL)
+		}
+
+		events, err := ExpandEventTypes(definition.Events...)
+		if err != nil {
+			return nil, fmt.Errorf("invalid events of webhook definition for URL '%s': %w", definition.URL, err)
+		}
+		definition.Events = events
+		if _, ok := desired[definition.URL]; ok {
+			return nil, fmt.Errorf("duplicate webhook definition for URL '%s'", definition.URL)
+		}
+		desired[definition.URL] = definition
+	}
+
+	plan := &WebhookPlan{}
+	matched := map[string]bool{}
+
+	listParams := &WebhookListParams{Limit: NotNil(100)}
+	for {
+		webhooks, err := s.listPage(listParams)
+		if err != nil {
+			return nil, fmt.Errorf("could not list webhooks: %w", err)
+		}
+
+		for i := range webhooks.Data {
+			plan.addWebhook(&webhooks.Data[i], desired, matched, params.Prune)
+		}
+
+		if !webhooks.HasMore || len(webhooks.Data) == 0 {
+			break
+		}
+		listParams.After = &webhooks.Data[len(webhooks.Data)-1].ID
+	}
+
+	for i := range definitions {
+		definition := &definitions[i]
+		if !matched[definition.URL] {
+			plan.Actions = append(plan.Actions, WebhookAction{Type: WebhookActionTypeCreate, Definition: definition})
+		}
+	}
+
+	return plan, nil
+}
+
+// addWebhook adds the actions needed to make an existing webhook match its desired state,
+// marking its URL as matched.
+func (p *WebhookPlan) addWebhook(webhook *Webhook, desired map[string]*WebhookDefinition, matched map[string]bool, prune bool) {
+	definition, ok := desired[webhook.URL]
+	if !ok || matched[webhook.URL] {
+		if prune {
+			p.Actions = append(p.Actions, WebhookAction{Type: WebhookActionTypeDelete, Webhook: webhook})
+		}
+		return
+	}
+	matched[webhook.URL] = true
+
+	if changes := webhookChanges(webhook, definition); len(changes) > 0 {
+		p.Actions = append(p.Actions, WebhookAction{
+			Type:       WebhookActionTypeUpdate,
+			Webhook:    webhook,
+			Definition: definition,
+			Changes:    changes,
+		})
+	}
+
+	if webhook.Status != definition.status() {
+		actionType := WebhookActionTypeEnable
+		if definition.Disabled {
+			actionType = WebhookActionTypeDisable
+		}
+		p.Actions = append(p.Actions, WebhookAction{Type: actionType, Webhook: webhook, Definition: definition})
+	}
+}
+
+// Apply applies the changes of a plan returned by [ServiceWebhooks.Plan].
+//
+// Actions are applied in order, stopping at the first error.
+func (s *ServiceWebhooks) Apply(plan *WebhookPlan) error {
+	for _, action := range plan.Actions {
+		if err := s.applyAction(&action); err != nil {
+			return fmt.Errorf("could not apply '%s': %w", action.String(), err)
+		}
+	}
+	return nil
+}
+
+// Reconcile makes the webhooks of the account match the desired webhooks,
+// creating, updating, enabling, disabling and deleting webhooks as needed.
+//
+// Returns the applied plan. Use [ServiceWebhooks.Plan] to see the changes without applying them.
+func (s *ServiceWebhooks) Reconcile(params *WebhookReconcileParams) (*WebhookPlan, error) {
+	plan, err := s.Plan(params)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.Apply(plan); err != nil {
+		return plan, err
+	}
+
+	return plan, nil
+}
+
+func (s *ServiceWebhooks) applyAction(action *WebhookAction) error {
+	switch action.Type {
+	case WebhookActionTypeCreate:
+		webhook, err := s.Create(&WebhookCreateParams{
+			URL:         action.Definition.URL,
+			Description: optionalString(action.Definition.Description),
+			Events:      action.Definition.Events,
+		})
+		if err != nil {
+			return err
+		}
+
+		if action.Definition.Disabled {
+			_, err = s.Disable(webhook.ID)
+		}
+		return err
+
+	case WebhookActionTypeUpdate:
+		// Only the changed fields are sent, so fields changed since the plan was made aren't reverted
+		params := &WebhookUpdateParams{}
+		if webhookDescription(action.Webhook) != action.Definition.Description {
+			params.Description = &action.Definition.Description
+		}
+		if !sameEventTypes(action.Webhook.Events, action.Definition.Events) {
+			params.Events = &action.Definition.Events
+		}
+
+		_, err := s.Update(action.Webhook.ID, params)
+		return err
+
+	case WebhookActionTypeEnable:
+		_, err := s.Enable(action.Webhook.ID)
+		return err
+
+	case WebhookActionTypeDisable:
+		_, err := s.Disable(action.Webhook.ID)
+		return err
+
+	case WebhookActionTypeDelete:
+		_, err := s.Delete(action.Webhook.ID)
+		return err
+
+	default:
+		return fmt.Errorf("unknown webhook action type '%s'", action.Type)
+	}
+}
+
+// webhookChanges describes the fields of the webhook that differ from the definition,
+// apart from the status.
+func webhookChanges(webhook *Webhook, definition *WebhookDefinition) []string {
+	var changes []string
+
+	description := webhookDescription(webhook)
+	if description != definition.Description {
+		changes = append(changes, fmt.Sprintf("description %q -> %q", description, definition.Description))
+	}
+
+	if !sameEventTypes(webhook.Events, definition.Events) {
+		changes = append(changes, fmt.Sprintf("events [%s] -> [%s]",
+			formatEventTypes(webhook.Events), formatEventTypes(definition.Events)))
+	}
+
+	return changes
+}
+
+// webhookDescription returns the description of the webhook, or an empty string if it has none.
+func webhookDescription(webhook *Webhook) string {
+	if webhook.Description == nil {
+		return ""
+	}
+	return *webhook.Description
+}
+
+// sameEventTypes reports whether both lists have the same event types, in any order.
+func sameEventTypes(a, b []EventType) bool {
+	a, b = slices.Clone(a), slices.Clone(b)
+	slices.Sort(a)
+	slices.Sort(b)
+	return slices.Equal(slices.Compact(a), slices.Compact(b))
+}
+
+func formatEventTypes(eventTypes []EventType) string {
+	names := make([]string, len(eventTypes))
+	for i, eventType := range eventTypes {
+		names[i] = string(eventType)
+	}
+	return strings.Join(names, ", ")
+}
+
+// optionalString returns nil for an empty string, or a pointer to the string otherwise.
+func optionalString(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
@@ -0,0 +1,124 @@
+package payrex_test
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"testing"
+
+	"github.com/angelofallars/payrex-go"
+	"github.com/angelofallars/payrex-go/payrextest"
+)
+
+func TestReconcileWebhooksPastFirstPage(t *testing.T) {
+	payrexClient, _ := payrextest.NewClient(t)
+
+	var definitions []payrex.WebhookDefinition
+	for i := range 150 {
+		definition := payrex.WebhookDefinition{
+			URL:    fmt.Sprintf("https://example.com/webhooks/%d", i),
+			Events: []payrex.EventType{payrex.EventTypePaymentIntentSucceeded},
+		}
+		if _, err := payrexClient.Webhooks.Create(&payrex.WebhookCreateParams{URL: definition.URL, Events: definition.Events}); err != nil {
+			t.Fatal(err)
+		}
+		definitions = append(definitions, definition)
+	}
+
+	plan, err := payrexClient.Webhooks.Plan(&payrex.WebhookReconcileParams{Webhooks: definitions, Prune: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !plan.Empty() {
+		t.Errorf("got plan\n%s\nwant no changes", plan)
+	}
+}
+
+func TestReconcileWebhookUpdatesChangedFields(t *testing.T) {
+	payrexClient, _ := payrextest.NewClient(t)
+
+	webhook, err := payrexClient.Webhooks.Create(&payrex.WebhookCreateParams{
+		URL:         "https://example.com/webhooks",
+		Description: payrex.NotNil("Payments"),
+		Events:      []payrex.EventType{payrex.EventTypePaymentIntentSucceeded},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = payrexClient.Webhooks.Reconcile(&payrex.WebhookReconcileParams{
+		Webhooks: []payrex.WebhookDefinition{{
+			URL:         webhook.URL,
+			Description: "Payments and refunds",
+			Events:      []payrex.EventType{payrex.EventTypePaymentIntentSucceeded, "refund.*"},
+		}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	webhook, err = payrexClient.Webhooks.Retrieve(webhook.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if webhook.Description == nil || *webhook.Description != "Payments and refunds" {
+		t.Errorf("got description %v, want 'Payments and refunds'", webhook.Description)
+	}
+	if len(webhook.Events) != 3 {
+		t.Errorf("got events %v, want the payment intent and refund events", webhook.Events)
+	}
+}
+
+func TestPlanWebhooksInvalidEvents(t *testing.T) {
+	tests := map[string][]payrex.EventType{
+		"no events":          nil,
+		"unknown pattern":    {"foo.*"},
+		"one unknown of two": {payrex.EventTypePaymentIntentSucceeded, "refunds.*"},
+	}
+
+	for name, events := range tests {
+		t.Run(name, func(t *testing.T) {
+			payrexClient, _ := payrextest.NewClient(t)
+
+			_, err := payrexClient.Webhooks.Plan(&payrex.WebhookReconcileParams{
+				Webhooks: []payrex.WebhookDefinition{{URL: "https://example.com/webhooks", Events: events}},
+			})
+			if err == nil {
+				t.Error("got no error, want an error for the invalid events")
+			}
+		})
+	}
+}
+
+func TestCreateWebhookEventTypes(t *testing.T) {
+	payrexClient, _ := payrextest.NewClient(t)
+
+	webhook, err := payrexClient.Webhooks.Create(&payrex.WebhookCreateParams{
+		URL:    "https://example.com/webhooks",
+		Events: []payrex.EventType{"refund.*"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []payrex.EventType{payrex.EventTypeRefundCreated, payrex.EventTypeRefundUpdated}; !slices.Equal(webhook.Events, want) {
+		t.Errorf("got events %v, want %v", webhook.Events, want)
+	}
+
+	_, err = payrexClient.Webhooks.Create(&payrex.WebhookCreateParams{
+		URL:    "https://example.com/webhooks",
+		Events: []payrex.EventType{"refunds.*"},
+	})
+	if !errors.Is(err, payrex.ErrUnknownEventType) {
+		t.Errorf("got error %v for an unknown pattern, want %v", err, payrex.ErrUnknownEventType)
+	}
+
+	// Event types the SDK doesn't know are left for the API to validate
+	_, err = payrexClient.Webhooks.Create(&payrex.WebhookCreateParams{
+		URL:    "https://example.com/webhooks",
+		Events: []payrex.EventType{"payment_intent.created"},
+	})
+	var apiErr payrex.Error
+	if !errors.As(err, &apiErr) {
+		t.Errorf("got error %v for an unknown event type, want an API error", err)
+	}
+}
@@ -0,0 +1,319 @@
+package payrex
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// DefaultWebhookMaxBodySize is the default maximum size of a webhook request body
+// accepted by a [WebhookRouter], in bytes.
+const DefaultWebhookMaxBodySize = 1 << 20
+
+var ErrWebhookBodyTooLarge = errors.New("webhook request body is too large")
+
+// EventHandler handles an [Event] dispatched by a [WebhookRouter].
+//
+// Returning nil acknowledges the event. Returning an error makes the router respond
+// with a status code that makes PayRex retry the delivery later, unless the error
+// is wrapped with [NoRetry] or [WithStatus].
+type EventHandler func(ctx context.Context, event *Event) error
+
+// EventMiddleware wraps an [EventHandler] to run code before or after it,
+// such as logging or tracing.
+type EventMiddleware func(next EventHandler) EventHandler
+
+// EventHandlerError is an error returned by an [EventHandler]
+// along with the HTTP status code to respond with.
+type EventHandlerError struct {
+	// The HTTP status code of the webhook response.
+	StatusCode int
+	// The underlying error.
+	Err error
+}
+
+func (e *EventHandlerError) Error() string {
+	return fmt.Sprintf("event handler failed with status %d: %v", e.StatusCode, e.Err)
+}
+
+func (e *EventHandlerError) Unwrap() error {
+	return e.Err
+}
+
+// NoRetry wraps an [EventHandler] error so the event is still acknowledged,
+// and PayRex does not retry the delivery.
+//
+// Useful for errors that retrying won't fix, such as an event referencing an order that doesn't exist.
+func NoRetry(err error) error {
+	return WithStatus(http.StatusOK, err)
+}
+
+// WithStatus wraps an [EventHandler] error so the [WebhookRouter]
+// responds with the given HTTP status code.
+//
+// Status codes outside of the 2xx to 5xx range, which can't be used as the final status
+// of a response, are replaced with 500 Internal Server Error.
+func WithStatus(statusCode int, err error) error {
+	return &EventHandlerError{StatusCode: responseStatusCode(statusCode), Err: err}
+}
+
+// responseStatusCode returns the status code if it can be used as the final status of a response,
+// and 500 Internal Server Error otherwise.
+func responseStatusCode(statusCode int) int {
+	if statusCode < 200 || statusCode > 599 {
+		return http.StatusInternalServerError
+	}
+	return statusCode
+}
+
+// isAcknowledged reports whether an [EventHandler] error still acknowledges the event,
+// i.e. it was wrapped with a 2xx status code.
+func isAcknowledged(err error) bool {
+	var handlerErr *EventHandlerError
+	return errors.As(err, &handlerErr) && handlerErr.StatusCode >= 200 && handlerErr.StatusCode < 300
+}
+
+// WebhookRouter is an [http.Handler] that verifies PayRex webhook requests, parses their events,
+// and dispatches them to the handlers registered for each [EventType].
+//
+// The router responds with:
+//   - 200 OK if the event was handled, if there is no handler for the event type,
+//     if the event was already processed according to the [EventStore],
+//     or if the event was rejected with [ErrEventModeMismatch], without running any handler.
+//   - 400 Bad Request if the event could not be parsed.
+//   - 401 Unauthorized if the webhook signature could not be verified.
+//   - 405 Method Not Allowed if the request method is not POST.
+//   - 413 Request Entity Too Large if the request body is larger than the maximum body size.
+//   - 500 Internal Server Error if the handler returned an error or panicked, so PayRex retries the delivery.
+//
+// Errors wrapped with [WithStatus], whether returned by the handler or by the
+// [WebhookEventParser], are responded to with their status code instead.
+//
+// Example:
+//
+//	router := payrex.NewWebhookRouter(payrex.NewWebhookVerifier(webhookSecretKey))
+//
+//	payrex.HandleEventResource(router, payrex.EventTypePaymentIntentSucceeded,
+//		func(ctx context.Context, event *payrex.Event, paymentIntent *payrex.PaymentIntent) error {
+//			return fulfillOrder(ctx, paymentIntent)
+//		},
+//	)
+//
+//	http.Handle("/webhooks/payrex", router)
+type WebhookRouter struct {
+	parser       WebhookEventParser
+	handlers     map[EventType]EventHandler
+	fallback     EventHandler
+	middlewares  []EventMiddleware
+	maxBodySize  int64
+	errorHandler func(r *http.Request, err error)
+	eventStore   EventStore
+}
+
+// NewWebhookRouter creates a new [WebhookRouter] instance that verifies and parses
+// requests using the given [WebhookEventParser], such as a [WebhookVerifier]
+// or a [MultiTenantWebhookVerifier].
+//
+// The parser can be nil if the router is only used through [WebhookRouter.Dispatch].
+func NewWebhookRouter(parser WebhookEventParser) *WebhookRouter {
+	return &WebhookRouter{
+		parser:       parser,
+		handlers:     map[EventType]EventHandler{},
+		maxBodySize:  DefaultWebhookMaxBodySize,
+		errorHandler: func(*http.Request, error) {},
+	}
+}
+
+// WithMaxBodySize sets the maximum size of a webhook request body, in bytes.
+//
+// Requests with a larger body are rejected before verifying their signature.
+func (rt *WebhookRouter) WithMaxBodySize(maxBodySize int64) *WebhookRouter {
+	rt.maxBodySize = maxBodySize
+	return rt
+}
+
+// WithErrorHandler sets a function that is called with every error that occurs
+// while processing a webhook request, such as failed signature verification or
+// errors returned from an [EventHandler]. Useful for logging.
+func (rt *WebhookRouter) WithErrorHandler(errorHandler func(r *http.Request, err error)) *WebhookRouter {
+	rt.errorHandler = errorHandler
+	return rt
+}
+
+// WithEventStore sets the [EventStore] used to process each event only once.
+//
+// Events that are already completed or in progress are acknowledged
+// without running their handler again.
+func (rt *WebhookRouter) WithEventStore(eventStore EventStore) *WebhookRouter {
+	rt.eventStore = eventStore
+	return rt
+}
+
+// Handle registers the handler for events of the given type,
+// replacing any existing handler for the event type.
+//
+// The event type can be a wildcard pattern such as "billing_statement.*" or [EventTypeAll].
+// Events are dispatched to the most specific handler: a handler for their exact event type,
+// then for their resource pattern, then for [EventTypeAll], then the fallback handler.
+//
+// Handle panics if the event type is a pattern matching no known event type,
+// such as a misspelled "refunds.*", as the handler would never run.
+func (rt *WebhookRouter) Handle(eventType EventType, handler EventHandler) {
+	if eventType.IsPattern() {
+		if _, err := ParseEventType(string(eventType)); err != nil {
+			panic(fmt.Sprintf("payrex: Handle: %v", err))
+		}
+	}
+	rt.handlers[eventType] = handler
+}
+
+// HandleFallback registers the handler for events without a handler for their event type.
+//
+// Without a fallback handler, these events are acknowledged without doing anything.
+func (rt *WebhookRouter) HandleFallback(handler EventHandler) {
+	rt.fallback = handler
+}
+
+// Use adds middlewares that wrap every handler of the router.
+//
+// Middlewares are run in the order they were added.
+func (rt *WebhookRouter) Use(middlewares ...EventMiddleware) {
+	rt.middlewares = append(rt.middlewares, middlewares...)
+}
+
+// HandleEventResource registers a handler for events of the given type
+// that receives the resource of the event as a value of type T.
+//
+// T must be the resource type of the event type, e.g. [PaymentIntent] for
+// [EventTypePaymentIntentSucceeded] or "payment_intent.*". HandleEventResource panics
+// if T is one of the resource types of [EventResourceType] and the event type has another
+// resource type, or is [EventTypeAll]. See [EventData] for details.
+func HandleEventResource[T any](rt *WebhookRouter, eventType EventType, handler func(ctx context.Context, event *Event, resource *T) error) {
+	if resourceType, ok := eventResourceTypeOf[T](); ok && resourceType != eventType.ResourceType() {
+		panic(fmt.Sprintf("payrex: HandleEventResource: events of type '%s' do not have a resource of type '%s'",
+			eventType, resourceType))
+	}
+
+	rt.Handle(eventType, func(ctx context.Context, event *Event) error {
+		resource, err := EventData[T](event)
+		if err != nil {
+			return err
+		}
+
+		return handler(ctx, event, resource)
+	})
+}
+
+// ServeHTTP implements [http.Handler].
+func (rt *WebhookRouter) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, rt.maxBodySize)
+
+	event, err := rt.parser.ParseEvent(r)
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		var handlerErr *EventHandlerError
+
+		switch {
+		case errors.As(err, &maxBytesErr):
+			rt.fail(w, r, http.StatusRequestEntityTooLarge, ErrWebhookBodyTooLarge)
+		case errors.Is(err, ErrWebhookVerificationFailed):
+			rt.fail(w, r, http.StatusUnauthorized, err)
+		case errors.As(err, &handlerErr):
+			rt.fail(w, r, handlerErr.StatusCode, err)
+		case errors.Is(err, ErrEventModeMismatch):
+			// Acknowledged so PayRex doesn't keep retrying an event this endpoint will never accept.
+			rt.fail(w, r, http.StatusOK, err)
+		default:
+			rt.fail(w, r, http.StatusBadRequest, err)
+		}
+		return
+	}
+
+	if err := rt.process(r.Context(), event); err != nil {
+		statusCode := http.StatusInternalServerError
+
+		var handlerErr *EventHandlerError
+		if errors.As(err, &handlerErr) {
+			statusCode = handlerErr.StatusCode
+		}
+
+		rt.fail(w, r, statusCode, fmt.Errorf("could not handle event '%s' of type '%s': %w", event.ID, event.Type, err))
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// process dispatches the event, consulting the event store if there is one.
+func (rt *WebhookRouter) process(ctx context.Context, event *Event) error {
+	if rt.eventStore == nil {
+		return rt.Dispatch(ctx, event)
+	}
+
+	_, err := ProcessEventOnce(ctx, rt.eventStore, event, rt.Dispatch)
+	return err
+}
+
+// Dispatch runs the handler registered for the event type, wrapped in the middlewares,
+// without verifying the event or consulting the [EventStore].
+//
+// Useful for dispatching events that were already verified, such as events
+// processed asynchronously by an [EventProcessor].
+func (rt *WebhookRouter) Dispatch(ctx context.Context, event *Event) (err error) {
+	handler := rt.handlerFor(event.Type)
+	if handler == nil {
+		return nil
+	}
+
+	for i := len(rt.middlewares) - 1; i >= 0; i-- {
+		handler = rt.middlewares[i](handler)
+	}
+
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			err = fmt.Errorf("event handler panicked: %v", recovered)
+		}
+	}()
+
+	return handler(ctx, event)
+}
+
+// handlerFor returns the most specific handler registered for the event type,
+// or the fallback handler if none matches.
+func (rt *WebhookRouter) handlerFor(eventType EventType) EventHandler {
+	candidates := []EventType{
+		eventType,
+		EventType(eventType.ResourceType() + ".*"),
+		EventTypeAll,
+	}
+
+	for _, candidate := range candidates {
+		if handler, ok := rt.handlers[candidate]; ok {
+			return handler
+		}
+	}
+
+	return rt.fallback
+}
+
+// fail reports the error to the error handler and responds with the status code.
+func (rt *WebhookRouter) fail(w http.ResponseWriter, r *http.Request, statusCode int, err error) {
+	rt.errorHandler(r, err)
+
+	// EventHandlerError values can be created without WithStatus.
+	statusCode = responseStatusCode(statusCode)
+
+	if statusCode >= 200 && statusCode < 300 {
+		w.WriteHeader(statusCode)
+		return
+	}
+
+	http.Error(w, http.StatusText(statusCode), statusCode)
+}
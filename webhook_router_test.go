@@ -0,0 +1,126 @@
+package payrex
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// newSignedWebhookRequest returns a webhook request with the payload
+// signed with [testWebhookSecretKey] in test mode.
+func newSignedWebhookRequest(payload []byte) *http.Request {
+	signature := ComputeWebhookSignature(payload, testWebhookTimestamp, testWebhookSecretKey)
+
+	r := httptest.NewRequest(http.MethodPost, "/webhooks/payrex", bytes.NewReader(payload))
+	r.Header.Set(WebhookSignatureHeader, "t="+strconv.Itoa(testWebhookTimestamp)+",te="+signature+",li=")
+	return r
+}
+
+func TestWebhookRouterAcknowledgesEventModeMismatch(t *testing.T) {
+	verifier := NewWebhookVerifier(testWebhookSecretKey).
+		WithClock(func() time.Time { return time.Unix(testWebhookTimestamp, 0) }).
+		WithEventMode(ModeLive)
+
+	var reported error
+	router := NewWebhookRouter(verifier).
+		WithErrorHandler(func(_ *http.Request, err error) { reported = err })
+	router.HandleFallback(func(context.Context, *Event) error {
+		t.Error("handler ran for an event in the wrong mode")
+		return nil
+	})
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, newSignedWebhookRequest(testWebhookPayload))
+
+	if w.Code != http.StatusOK {
+		t.Errorf("got status %d, want %d", w.Code, http.StatusOK)
+	}
+	if !errors.Is(reported, ErrEventModeMismatch) {
+		t.Errorf("got reported error %v, want %v", reported, ErrEventModeMismatch)
+	}
+}
+
+func TestWithStatusInvalidStatusCode(t *testing.T) {
+	tests := map[string]struct {
+		statusCode int
+		want       int
+	}{
+		"valid":         {statusCode: http.StatusConflict, want: http.StatusConflict},
+		"negative":      {statusCode: -1, want: http.StatusInternalServerError},
+		"informational": {statusCode: http.StatusContinue, want: http.StatusInternalServerError},
+		"too large":     {statusCode: 1000, want: http.StatusInternalServerError},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			router := NewWebhookRouter(NewWebhookVerifier(testWebhookSecretKey).
+				WithClock(func() time.Time { return time.Unix(testWebhookTimestamp, 0) }))
+			router.HandleFallback(func(context.Context, *Event) error {
+				return WithStatus(tt.statusCode, errors.New("handler failed"))
+			})
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, newSignedWebhookRequest(testWebhookPayload))
+
+			if w.Code != tt.want {
+				t.Errorf("got status %d, want %d", w.Code, tt.want)
+			}
+		})
+	}
+}
+
+func TestHandleEventResourceTypeMismatch(t *testing.T) {
+	tests := map[string]struct {
+		eventType EventType
+		wantPanic bool
+	}{
+		"event type":       {eventType: EventTypePaymentIntentSucceeded},
+		"pattern":          {eventType: "payment_intent.*"},
+		"other event type": {eventType: EventTypeRefundCreated, wantPanic: true},
+		"other pattern":    {eventType: "refund.*", wantPanic: true},
+		"all event types":  {eventType: EventTypeAll, wantPanic: true},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			defer func() {
+				if recovered := recover(); (recovered != nil) != tt.wantPanic {
+					t.Errorf("got panic %v, want panic: %t", recovered, tt.wantPanic)
+				}
+			}()
+
+			HandleEventResource(NewWebhookRouter(nil), tt.eventType,
+				func(context.Context, *Event, *PaymentIntent) error { return nil })
+		})
+	}
+}
+
+func TestHandleUnknownPattern(t *testing.T) {
+	tests := map[string]struct {
+		eventType EventType
+		wantPanic bool
+	}{
+		"event type":         {eventType: EventTypePaymentIntentSucceeded},
+		"unknown event type": {eventType: "payment_intent.created"},
+		"pattern":            {eventType: "refund.*"},
+		"all event types":    {eventType: EventTypeAll},
+		"misspelled pattern": {eventType: "refunds.*", wantPanic: true},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			defer func() {
+				if recovered := recover(); (recovered != nil) != tt.wantPanic {
+					t.Errorf("got panic %v, want panic: %t", recovered, tt.wantPanic)
+				}
+			}()
+
+			NewWebhookRouter(nil).Handle(tt.eventType, func(context.Context, *Event) error { return nil })
+		})
+	}
+}
@@ -0,0 +1,198 @@
+package payrex
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+var (
+	ErrNoWebhookTenant      = errors.New("could not resolve the tenant of the webhook request")
+	ErrUnknownWebhookTenant = errors.New("unknown webhook tenant")
+)
+
+// WebhookTenantResolver returns the tenant that a PayRex webhook request is for,
+// such as the ID of a merchant account of a platform.
+//
+// Returning an empty tenant rejects the request with [ErrNoWebhookTenant].
+type WebhookTenantResolver func(r *http.Request) (string, error)
+
+// WebhookTenantFromPathValue returns a [WebhookTenantResolver] that resolves the tenant
+// from a wildcard of the request path, as returned by [http.Request.PathValue].
+//
+// Example:
+//
+//	http.Handle("POST /webhooks/payrex/{account}", router)
+//
+//	verifier := payrex.NewMultiTenantWebhookVerifier(payrex.WebhookTenantFromPathValue("account"), secrets)
+func WebhookTenantFromPathValue(name string) WebhookTenantResolver {
+	return func(r *http.Request) (string, error) {
+		return r.PathValue(name), nil
+	}
+}
+
+// WebhookTenantFromHeader returns a [WebhookTenantResolver] that resolves the tenant
+// from a header of the request.
+func WebhookTenantFromHeader(name string) WebhookTenantResolver {
+	return func(r *http.Request) (string, error) {
+		return r.Header.Get(name), nil
+	}
+}
+
+// WebhookSecretLookup looks up the webhook secrets of a tenant, such as the
+// secret keys of the webhooks of a merchant account stored in a database.
+//
+// Implementations must be safe for concurrent use.
+type WebhookSecretLookup interface {
+	// LookupWebhookSecrets returns the secrets accepted for webhook requests of the tenant,
+	// in the order they should be tried. Returns an error wrapping
+	// [ErrUnknownWebhookTenant] if the tenant doesn't exist.
+	LookupWebhookSecrets(ctx context.Context, tenant string) ([]WebhookSecret, error)
+}
+
+// WebhookSecretLookupFunc is a function that implements [WebhookSecretLookup].
+type WebhookSecretLookupFunc func(ctx context.Context, tenant string) ([]WebhookSecret, error)
+
+// LookupWebhookSecrets implements [WebhookSecretLookup].
+func (f WebhookSecretLookupFunc) LookupWebhookSecrets(ctx context.Context, tenant string) ([]WebhookSecret, error) {
+	return f(ctx, tenant)
+}
+
+// WebhookSecretMap is a [WebhookSecretLookup] with a fixed set of secrets per tenant.
+type WebhookSecretMap map[string][]WebhookSecret
+
+// LookupWebhookSecrets implements [WebhookSecretLookup].
+func (m WebhookSecretMap) LookupWebhookSecrets(_ context.Context, tenant string) ([]WebhookSecret, error) {
+	secrets, ok := m[tenant]
+	if !ok {
+		return nil, fmt.Errorf("%w: '%s'", ErrUnknownWebhookTenant, tenant)
+	}
+	return secrets, nil
+}
+
+// MultiTenantWebhookVerifier verifies the signatures of PayRex webhook requests
+// for many tenants on the same endpoint, each with their own webhook secrets.
+//
+// For every request, the tenant is resolved using a [WebhookTenantResolver], then the
+// request is verified with the secrets returned by a [WebhookSecretLookup] for the tenant.
+// Parsed events have their Event.Tenant set.
+//
+// Example:
+//
+//	verifier := payrex.NewMultiTenantWebhookVerifier(
+//		payrex.WebhookTenantFromPathValue("account"),
+//		payrex.WebhookSecretLookupFunc(func(ctx context.Context, account string) ([]payrex.WebhookSecret, error) {
+//			secretKey, err := db.WebhookSecretKey(ctx, account)
+//			if errors.Is(err, sql.ErrNoRows) {
+//				return nil, payrex.ErrUnknownWebhookTenant
+//			}
+//			if err != nil {
+//				return nil, err
+//			}
+//			return []payrex.WebhookSecret{{Key: secretKey}}, nil
+//		}),
+//	)
+//
+//	router := payrex.NewWebhookRouter(verifier)
+//	http.Handle("POST /webhooks/payrex/{account}", router)
+type MultiTenantWebhookVerifier struct {
+	resolver      WebhookTenantResolver
+	lookup        WebhookSecretLookup
+	tolerance     time.Duration
+	now           func() time.Time
+	signatureMode Mode
+	eventMode     Mode
+}
+
+// NewMultiTenantWebhookVerifier creates a new [MultiTenantWebhookVerifier] instance
+// that resolves the tenant of requests with the resolver, and their secrets with the lookup.
+//
+// Like [NewWebhookVerifier], signatures with a timestamp older or newer than
+// [DefaultWebhookTolerance] are rejected by default.
+func NewMultiTenantWebhookVerifier(resolver WebhookTenantResolver, lookup WebhookSecretLookup) *MultiTenantWebhookVerifier {
+	return &MultiTenantWebhookVerifier{
+		resolver:  resolver,
+		lookup:    lookup,
+		tolerance: DefaultWebhookTolerance,
+		now:       time.Now,
+	}
+}
+
+// WithTolerance works like [WebhookVerifier.WithTolerance].
+func (v *MultiTenantWebhookVerifier) WithTolerance(tolerance time.Duration) *MultiTenantWebhookVerifier {
+	c := *v
+	c.tolerance = tolerance
+	return &c
+}
+
+// WithClock works like [WebhookVerifier.WithClock].
+func (v *MultiTenantWebhookVerifier) WithClock(now func() time.Time) *MultiTenantWebhookVerifier {
+	c := *v
+	c.now = now
+	return &c
+}
+
+// WithSignatureMode works like [WebhookVerifier.WithSignatureMode].
+func (v *MultiTenantWebhookVerifier) WithSignatureMode(mode Mode) *MultiTenantWebhookVerifier {
+	c := *v
+	c.signatureMode = mode
+	return &c
+}
+
+// WithEventMode works like [WebhookVerifier.WithEventMode].
+func (v *MultiTenantWebhookVerifier) WithEventMode(mode Mode) *MultiTenantWebhookVerifier {
+	c := *v
+	c.eventMode = mode
+	return &c
+}
+
+// ParseEvent resolves the tenant of a PayRex webhook request,
+// then verifies and parses its event with the secrets of the tenant.
+//
+// The request body is read, and replaced so it can be read again afterwards.
+func (v *MultiTenantWebhookVerifier) ParseEvent(r *http.Request) (*Event, error) {
+	tenant, err := v.resolver(r)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w: %w", ErrWebhookVerificationFailed, ErrNoWebhookTenant, err)
+	}
+	if tenant == "" {
+		return nil, fmt.Errorf("%w: %w", ErrWebhookVerificationFailed, ErrNoWebhookTenant)
+	}
+
+	payload, signatureHeader, err := readWebhookRequest(r)
+	if err != nil {
+		return nil, err
+	}
+
+	return v.ParseEventFromBytes(r.Context(), tenant, payload, signatureHeader)
+}
+
+// ParseEventFromBytes verifies and parses an event of the tenant from the payload
+// and 'Payrex-Signature' header value of a PayRex webhook request.
+func (v *MultiTenantWebhookVerifier) ParseEventFromBytes(ctx context.Context, tenant string, payload []byte, signatureHeader string) (*Event, error) {
+	secrets, err := v.lookup.LookupWebhookSecrets(ctx, tenant)
+	if errors.Is(err, ErrUnknownWebhookTenant) {
+		return nil, fmt.Errorf("%w: %w", ErrWebhookVerificationFailed, err)
+	}
+	if err != nil {
+		// Looking up the secrets may fail temporarily, so the delivery should be retried.
+		return nil, WithStatus(http.StatusInternalServerError,
+			fmt.Errorf("could not look up webhook secrets of tenant '%s': %w", tenant, err))
+	}
+
+	verifier := NewWebhookVerifierWithSecrets(secrets...).
+		WithTolerance(v.tolerance).
+		WithClock(v.now).
+		WithSignatureMode(v.signatureMode).
+		WithEventMode(v.eventMode)
+
+	event, err := verifier.ParseEventFromBytes(payload, signatureHeader)
+	if err != nil {
+		return nil, err
+	}
+	event.Tenant = tenant
+
+	return event, nil
+}
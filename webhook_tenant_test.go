@@ -0,0 +1,145 @@
+package payrex
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+)
+
+var testWebhookSecrets = WebhookSecretMap{
+	"acme":   {{Key: testWebhookSecretKey}},
+	"globex": {{Key: "whsk_test_globex"}},
+}
+
+// newTestMultiTenantWebhookVerifier returns a [MultiTenantWebhookVerifier] resolving tenants
+// from the 'X-Tenant' header, with a clock at [testWebhookTimestamp].
+func newTestMultiTenantWebhookVerifier(lookup WebhookSecretLookup) *MultiTenantWebhookVerifier {
+	return NewMultiTenantWebhookVerifier(WebhookTenantFromHeader("X-Tenant"), lookup).
+		WithClock(func() time.Time { return time.Unix(testWebhookTimestamp, 0) })
+}
+
+func TestMultiTenantWebhookRouter(t *testing.T) {
+	lookupErr := errors.New("database is down")
+
+	tests := map[string]struct {
+		tenant     string
+		lookup     WebhookSecretLookup
+		wantStatus int
+		wantErr    error
+	}{
+		"tenant of the secret": {
+			tenant:     "acme",
+			lookup:     testWebhookSecrets,
+			wantStatus: http.StatusOK,
+		},
+		"other tenant": {
+			tenant:     "globex",
+			lookup:     testWebhookSecrets,
+			wantStatus: http.StatusUnauthorized,
+			wantErr:    ErrWebhookVerificationFailed,
+		},
+		"unknown tenant": {
+			tenant:     "initech",
+			lookup:     testWebhookSecrets,
+			wantStatus: http.StatusUnauthorized,
+			wantErr:    ErrUnknownWebhookTenant,
+		},
+		"no tenant": {
+			lookup:     testWebhookSecrets,
+			wantStatus: http.StatusUnauthorized,
+			wantErr:    ErrNoWebhookTenant,
+		},
+		"lookup error": {
+			tenant: "acme",
+			lookup: WebhookSecretLookupFunc(func(context.Context, string) ([]WebhookSecret, error) {
+				return nil, lookupErr
+			}),
+			wantStatus: http.StatusInternalServerError,
+			wantErr:    lookupErr,
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			var reported error
+			router := NewWebhookRouter(newTestMultiTenantWebhookVerifier(tt.lookup)).
+				WithErrorHandler(func(_ *http.Request, err error) { reported = err })
+
+			handled := false
+			router.HandleFallback(func(_ context.Context, event *Event) error {
+				handled = true
+				if event.Tenant != tt.tenant {
+					t.Errorf("got tenant '%s', want '%s'", event.Tenant, tt.tenant)
+				}
+				return nil
+			})
+
+			r := newSignedWebhookRequest(testWebhookPayload)
+			if tt.tenant != "" {
+				r.Header.Set("X-Tenant", tt.tenant)
+			}
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, r)
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("got status %d, want %d", w.Code, tt.wantStatus)
+			}
+			if !errors.Is(reported, tt.wantErr) {
+				t.Errorf("got reported error %v, want %v", reported, tt.wantErr)
+			}
+			if wantHandled := tt.wantErr == nil; handled != wantHandled {
+				t.Errorf("got handler run: %t, want %t", handled, wantHandled)
+			}
+		})
+	}
+}
+
+func TestMultiTenantWebhookVerifierTenantFromPathValue(t *testing.T) {
+	verifier := NewMultiTenantWebhookVerifier(WebhookTenantFromPathValue("account"), testWebhookSecrets).
+		WithClock(func() time.Time { return time.Unix(testWebhookTimestamp, 0) })
+
+	var event *Event
+	var err error
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /webhooks/payrex/{account}", func(_ http.ResponseWriter, r *http.Request) {
+		event, err = verifier.ParseEvent(r)
+	})
+
+	r := newSignedWebhookRequest(testWebhookPayload)
+	r.URL.Path = "/webhooks/payrex/acme"
+	mux.ServeHTTP(httptest.NewRecorder(), r)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+	if event.Tenant != "acme" {
+		t.Errorf("got tenant '%s', want 'acme'", event.Tenant)
+	}
+}
+
+func TestMultiTenantWebhookVerifierParseEventFromBytes(t *testing.T) {
+	verifier := newTestMultiTenantWebhookVerifier(testWebhookSecrets)
+	signature := ComputeWebhookSignature(testWebhookPayload, testWebhookTimestamp, testWebhookSecretKey)
+	header := "t=" + strconv.Itoa(testWebhookTimestamp) + ",te=" + signature + ",li="
+
+	event, err := verifier.ParseEventFromBytes(context.Background(), "globex", testWebhookPayload, header)
+	if !errors.Is(err, ErrWebhookVerificationFailed) {
+		t.Errorf("got error %v for the secret of another tenant, want %v", err, ErrWebhookVerificationFailed)
+	}
+	if event != nil {
+		t.Errorf("got event %+v of tenant '%s' that failed verification, want nil", event, event.Tenant)
+	}
+
+	event, err = verifier.ParseEventFromBytes(context.Background(), "acme", testWebhookPayload, header)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if event.Tenant != "acme" || event.MatchedSecret == nil {
+		t.Errorf("got tenant '%s' with matched secret %v, want tenant 'acme' with a matched secret", event.Tenant, event.MatchedSecret)
+	}
+}
@@ -0,0 +1,415 @@
+package payrex
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// WebhookSignatureHeader is the name of the HTTP header containing
+// the signature of a PayRex webhook request.
+const WebhookSignatureHeader = "Payrex-Signature"
+
+// DefaultWebhookTolerance is the default maximum difference between the timestamp
+// of a webhook signature and the current time for the signature to be accepted.
+const DefaultWebhookTolerance = 5 * time.Minute
+
+var (
+	ErrNoSignatureHeader            = errors.New("header 'Payrex-Signature' not found in request")
+	ErrInvalidSignatureFormat       = errors.New("invalid PayRex signature format")
+	ErrInvalidSignature             = errors.New("invalid PayRex signature")
+	ErrNoSignatureTimestamp         = errors.New("PayRex signature has no timestamp")
+	ErrInvalidSignatureTimestamp    = errors.New("invalid PayRex signature timestamp")
+	ErrSignatureTimestampOutOfRange = errors.New("PayRex signature timestamp is outside the tolerance window")
+	ErrNoSignatureForMode           = errors.New("PayRex signature has no signature for the expected mode")
+	ErrNoActiveWebhookSecret        = errors.New("all webhook secret keys have expired")
+	// ErrWebhookVerificationFailed wraps every error caused by a webhook request
+	// that could not be verified, as opposed to a request that could not be read or parsed.
+	ErrWebhookVerificationFailed = errors.New("webhook signature verification failed")
+)
+
+// WebhookEventParser verifies and parses events from PayRex webhook requests.
+//
+// Implemented by [WebhookVerifier] and [MultiTenantWebhookVerifier].
+type WebhookEventParser interface {
+	// ParseEvent verifies and parses an event from a PayRex webhook request.
+	// Errors caused by a request that could not be verified wrap [ErrWebhookVerificationFailed].
+	ParseEvent(r *http.Request) (*Event, error)
+}
+
+// WebhookSignature is the parsed value of the 'Payrex-Signature' header
+// of a PayRex webhook request.
+//
+// The header has the format 't=<timestamp>,te=<test mode signature>,li=<live mode signature>'.
+//
+// Reference: https://docs.payrexhq.com/docs/guide/developer_handbook/webhooks
+type WebhookSignature struct {
+	// The time the signature was generated, measured in seconds since the Unix epoch.
+	Timestamp int64
+	// The signature of the payload generated in test mode. Empty for live mode events.
+	TestModeSignature string
+	// The signature of the payload generated in live mode. Empty for test mode events.
+	LiveModeSignature string
+}
+
+// ParseWebhookSignature parses the value of a 'Payrex-Signature' header.
+//
+// The parts of the header can appear in any order, and unrecognized parts are ignored.
+func ParseWebhookSignature(header string) (*WebhookSignature, error) {
+	if header == "" {
+		return nil, ErrNoSignatureHeader
+	}
+
+	var signature WebhookSignature
+	seen := map[string]bool{}
+	for _, part := range strings.Split(header, ",") {
+		key, value, ok := strings.Cut(strings.TrimSpace(part), "=")
+		if !ok {
+			return nil, fmt.Errorf("%w: part '%s' is not a key-value pair", ErrInvalidSignatureFormat, part)
+		}
+
+		switch key {
+		case "t", "te", "li":
+		default:
+			continue
+		}
+
+		if seen[key] {
+			return nil, fmt.Errorf("%w: duplicate part '%s'", ErrInvalidSignatureFormat, key)
+		}
+		seen[key] = true
+
+		switch key {
+		case "t":
+			timestamp, err := strconv.ParseInt(value, 10, 64)
+			if err != nil || timestamp <= 0 {
+				return nil, fmt.Errorf("%w: '%s'", ErrInvalidSignatureTimestamp, value)
+			}
+			signature.Timestamp = timestamp
+		case "te":
+			signature.TestModeSignature = value
+		case "li":
+			signature.LiveModeSignature = value
+		}
+	}
+
+	if !seen["t"] {
+		return nil, ErrNoSignatureTimestamp
+	}
+
+	return &signature, nil
+}
+
+// String returns the signature in the format of a 'Payrex-Signature' header.
+func (s *WebhookSignature) String() string {
+	return fmt.Sprintf("t=%d,te=%s,li=%s", s.Timestamp, s.TestModeSignature, s.LiveModeSignature)
+}
+
+// signatureForMode returns the signature to check for the given mode.
+//
+// If the mode is empty, the live mode signature is used if it's present,
+// otherwise the test mode signature is used.
+func (s *WebhookSignature) signatureForMode(mode Mode) (string, error) {
+	var signature string
+	switch mode {
+	case ModeTest:
+		signature = s.TestModeSignature
+	case ModeLive:
+		signature = s.LiveModeSignature
+	default:
+		signature = s.LiveModeSignature
+		if signature == "" {
+			signature = s.TestModeSignature
+		}
+	}
+
+	if signature == "" {
+		return "", ErrNoSignatureForMode
+	}
+
+	return signature, nil
+}
+
+// ComputeWebhookSignature returns the hex-encoded HMAC-SHA256 signature of a webhook payload,
+// signed at the given timestamp (in seconds since the Unix epoch) with a webhook secret key.
+func ComputeWebhookSignature(payload []byte, timestamp int64, webhookSecretKey string) string {
+	return hex.EncodeToString(webhookMAC(payload, timestamp, webhookSecretKey))
+}
+
+// webhookMAC returns the HMAC-SHA256 of the message '<timestamp>.<payload>'.
+func webhookMAC(payload []byte, timestamp int64, webhookSecretKey string) []byte {
+	mac := hmac.New(sha256.New, []byte(webhookSecretKey))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10) + "."))
+	mac.Write(payload)
+	return mac.Sum(nil)
+}
+
+// WebhookSecret is a webhook secret key accepted by a [WebhookVerifier].
+//
+// Multiple secrets can be accepted at the same time to rotate the secret key of a [Webhook]
+// without failing deliveries signed with the previous secret key.
+type WebhookSecret struct {
+	// The secret key of the [Webhook].
+	Key string
+	// Optional name of the secret, e.g. "current" or "previous",
+	// to identify which secret verified an event.
+	Name string
+	// The time the secret stops being accepted. The zero value means the secret never expires.
+	ExpiresAt time.Time
+}
+
+// Expired reports whether the secret is expired at the given time.
+func (s *WebhookSecret) Expired(now time.Time) bool {
+	return !s.ExpiresAt.IsZero() && !now.Before(s.ExpiresAt)
+}
+
+// WebhookSecretMatch identifies the [WebhookSecret] that verified an event,
+// without its secret key, so events can be logged safely.
+type WebhookSecretMatch struct {
+	// The name of the secret.
+	Name string
+	// The position of the secret among the secrets of the verifier, starting at 0.
+	Index int
+}
+
+// WebhookVerifier verifies the signatures of PayRex webhook requests and parses their events.
+//
+// Reference: https://docs.payrexhq.com/docs/guide/developer_handbook/webhooks
+type WebhookVerifier struct {
+	secrets       []WebhookSecret
+	tolerance     time.Duration
+	now           func() time.Time
+	signatureMode Mode
+	eventMode     Mode
+}
+
+// NewWebhookVerifier creates a new [WebhookVerifier] instance
+// using the secret key of a [Webhook].
+//
+// By default, signatures with a timestamp older or newer than [DefaultWebhookTolerance]
+// are rejected, and either the live mode or the test mode signature is checked,
+// depending on which one is present.
+func NewWebhookVerifier(webhookSecretKey string) *WebhookVerifier {
+	return NewWebhookVerifierWithSecrets(WebhookSecret{Key: webhookSecretKey})
+}
+
+// NewWebhookVerifierWithSecrets creates a new [WebhookVerifier] instance
+// that accepts signatures made with any of the given secrets that are not expired.
+//
+// Secrets are tried in order, so the current secret should be passed in first.
+//
+// Example of rotating the secret key of a webhook:
+//
+//	verifier := payrex.NewWebhookVerifierWithSecrets(
+//		payrex.WebhookSecret{Key: newSecretKey, Name: "current"},
+//		payrex.WebhookSecret{Key: oldSecretKey, Name: "previous", ExpiresAt: rotatedAt.Add(24 * time.Hour)},
+//	)
+func NewWebhookVerifierWithSecrets(secrets ...WebhookSecret) *WebhookVerifier {
+	return &WebhookVerifier{
+		secrets:   secrets,
+		tolerance: DefaultWebhookTolerance,
+		now:       time.Now,
+	}
+}
+
+// WithSecret returns a copy of the verifier that also accepts the secret,
+// tried after the existing secrets. The verifier itself is left unchanged.
+func (v *WebhookVerifier) WithSecret(secret WebhookSecret) *WebhookVerifier {
+	c := *v
+	c.secrets = append(slices.Clone(v.secrets), secret)
+	return &c
+}
+
+// WithTolerance returns a copy of the verifier with the maximum difference between the
+// signature timestamp and the current time for a signature to be accepted,
+// to protect against replay attacks. The verifier itself is left unchanged.
+//
+// A tolerance of zero or less disables the timestamp check.
+func (v *WebhookVerifier) WithTolerance(tolerance time.Duration) *WebhookVerifier {
+	c := *v
+	c.tolerance = tolerance
+	return &c
+}
+
+// WithClock returns a copy of the verifier with the function used to get the current time
+// when checking the signature timestamp replaced. The verifier itself is left unchanged.
+func (v *WebhookVerifier) WithClock(now func() time.Time) *WebhookVerifier {
+	c := *v
+	c.now = now
+	return &c
+}
+
+// WithSignatureMode returns a copy of the verifier that checks the given signature of the
+// 'Payrex-Signature' header: the test mode signature for [ModeTest], or the live mode
+// signature for [ModeLive]. The verifier itself is left unchanged.
+//
+// Requests without a signature for the given mode are rejected with [ErrNoSignatureForMode].
+func (v *WebhookVerifier) WithSignatureMode(mode Mode) *WebhookVerifier {
+	c := *v
+	c.signatureMode = mode
+	return &c
+}
+
+// WithEventMode returns a copy of the verifier that rejects events with [ErrEventModeMismatch]
+// if their Livemode doesn't match the given mode, e.g. test mode events received by a
+// production webhook handler expecting [ModeLive]. The verifier itself is left unchanged.
+//
+// A [WebhookRouter] acknowledges these events with 200 OK without handling them,
+// so PayRex doesn't retry them, and reports the error to its error handler.
+// To respond with another status code, wrap the parser's error with [WithStatus].
+func (v *WebhookVerifier) WithEventMode(mode Mode) *WebhookVerifier {
+	c := *v
+	c.eventMode = mode
+	return &c
+}
+
+// Verify verifies that the webhook payload was signed by PayRex
+// according to the 'Payrex-Signature' header value.
+func (v *WebhookVerifier) Verify(payload []byte, signatureHeader string) error {
+	_, err := v.VerifySecret(payload, signatureHeader)
+	return err
+}
+
+// VerifySecret verifies that the webhook payload was signed by PayRex
+// according to the 'Payrex-Signature' header value, and returns
+// the secret that the payload was signed with.
+//
+// Useful for detecting deliveries still signed with an old secret during secret rotation.
+func (v *WebhookVerifier) VerifySecret(payload []byte, signatureHeader string) (*WebhookSecret, error) {
+	i, err := v.verify(payload, signatureHeader)
+	if err != nil {
+		return nil, err
+	}
+
+	secret := v.secrets[i]
+	return &secret, nil
+}
+
+// verify verifies the webhook payload like [WebhookVerifier.VerifySecret],
+// and returns the index of the secret that the payload was signed with.
+func (v *WebhookVerifier) verify(payload []byte, signatureHeader string) (int, error) {
+	signature, err := ParseWebhookSignature(signatureHeader)
+	if err != nil {
+		return 0, err
+	}
+
+	now := v.now()
+
+	if v.tolerance > 0 {
+		signedAt := time.Unix(signature.Timestamp, 0)
+		if diff := now.Sub(signedAt).Abs(); diff > v.tolerance {
+			return 0, fmt.Errorf("%w: signed at %s", ErrSignatureTimestampOutOfRange, signedAt.UTC().Format(time.RFC3339))
+		}
+	}
+
+	comparisonSig, err := signature.signatureForMode(v.signatureMode)
+	if err != nil {
+		return 0, err
+	}
+
+	hasActiveSecret := false
+	for i := range v.secrets {
+		secret := &v.secrets[i]
+		if secret.Expired(now) {
+			continue
+		}
+		hasActiveSecret = true
+
+		if checkWebhookSignature(payload, signature.Timestamp, comparisonSig, secret.Key) {
+			return i, nil
+		}
+	}
+
+	if !hasActiveSecret {
+		return 0, ErrNoActiveWebhookSecret
+	}
+
+	return 0, ErrInvalidSignature
+}
+
+// checkWebhookSignature reports whether the hex-encoded signature matches
+// the payload signature, in constant time.
+func checkWebhookSignature(payload []byte, timestamp int64, signature, webhookSecretKey string) bool {
+	actualMAC, err := hex.DecodeString(signature)
+	if err != nil {
+		return false
+	}
+
+	return hmac.Equal(webhookMAC(payload, timestamp, webhookSecretKey), actualMAC)
+}
+
+// ParseEvent verifies and parses an event from a PayRex webhook request.
+//
+// The request body is read, and replaced so it can be read again afterwards.
+func (v *WebhookVerifier) ParseEvent(r *http.Request) (*Event, error) {
+	payload, signatureHeader, err := readWebhookRequest(r)
+	if err != nil {
+		return nil, err
+	}
+
+	return v.ParseEventFromBytes(payload, signatureHeader)
+}
+
+// ParseEventFromBytes verifies and parses an event from the payload
+// and 'Payrex-Signature' header value of a PayRex webhook request.
+func (v *WebhookVerifier) ParseEventFromBytes(payload []byte, signatureHeader string) (*Event, error) {
+	i, err := v.verify(payload, signatureHeader)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrWebhookVerificationFailed, err)
+	}
+
+	event, err := parseEvent(payload)
+	if err != nil {
+		return nil, err
+	}
+	event.MatchedSecret = &WebhookSecretMatch{Name: v.secrets[i].Name, Index: i}
+
+	if err := checkEventMode(event, v.eventMode); err != nil {
+		return nil, err
+	}
+
+	return event, nil
+}
+
+// readWebhookRequest reads the payload and 'Payrex-Signature' header value
+// of a PayRex webhook request, replacing the request body so it can be read again.
+func readWebhookRequest(r *http.Request) ([]byte, string, error) {
+	signatureHeader := r.Header.Get(WebhookSignatureHeader)
+
+	if signatureHeader == "" {
+		return nil, "", fmt.Errorf("%w: %w", ErrWebhookVerificationFailed, ErrNoSignatureHeader)
+	}
+
+	payload, err := io.ReadAll(r.Body)
+	if err != nil {
+		return nil, "", fmt.Errorf("could not read request body: %w", err)
+	}
+	_ = r.Body.Close()
+
+	r.Body = io.NopCloser(bytes.NewReader(payload))
+
+	return payload, signatureHeader, nil
+}
+
+// checkEventMode returns an error if the event is not in the expected mode.
+// An empty expected mode accepts events in any mode.
+func checkEventMode(event *Event, expected Mode) error {
+	if expected == "" {
+		return nil
+	}
+
+	if mode := ModeFromLivemode(event.Livemode); mode != expected {
+		return fmt.Errorf("%w: event '%s' is a %s mode event, but %s mode is expected", ErrEventModeMismatch, event.ID, mode, expected)
+	}
+
+	return nil
+}
@@ -0,0 +1,196 @@
+package payrex
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+const (
+	testWebhookSecretKey = "whsk_test_secret"
+	testWebhookTimestamp = 1_700_000_000
+)
+
+var testWebhookPayload = []byte(`{"id":"evt_123","type":"payment_intent.succeeded","data":{"resource":"payment_intent"}}`)
+
+// addWebhookSignatureSeeds adds valid and malformed 'Payrex-Signature' headers to the corpus.
+func addWebhookSignatureSeeds(f *testing.F) {
+	timestamp := strconv.Itoa(testWebhookTimestamp)
+	signature := ComputeWebhookSignature(testWebhookPayload, testWebhookTimestamp, testWebhookSecretKey)
+
+	for _, header := range []string{
+		"t=" + timestamp + ",te=" + signature + ",li=",
+		"t=" + timestamp + ",te=,li=" + signature,
+		"li=" + signature + ",te=" + signature + ",t=" + timestamp,
+		" te=" + signature + " , t=" + timestamp + " ",
+		"t=" + timestamp + ",t=" + timestamp + ",te=" + signature,
+		"t=" + timestamp + ",te=" + signature + ",te=" + signature,
+		"t=,te=,li=",
+		"t=" + timestamp + ",te=,li=",
+		"t=" + timestamp + ",te=" + strings.ToUpper(signature),
+		"t=" + timestamp + ",te=zz" + signature[2:],
+		"t=" + timestamp + ",te=" + signature[1:],
+		"t=0,te=" + signature,
+		"t=-1,te=" + signature,
+		"t=99999999999999999999,te=" + signature,
+		"t=" + timestamp + ",v1=" + signature + ",te=" + signature,
+		"te=" + signature,
+		"t",
+		",",
+		"",
+	} {
+		f.Add(header)
+	}
+}
+
+func FuzzParseWebhookSignature(f *testing.F) {
+	addWebhookSignatureSeeds(f)
+
+	f.Fuzz(func(t *testing.T, header string) {
+		signature, err := ParseWebhookSignature(header)
+		if err != nil {
+			if !errors.Is(err, ErrNoSignatureHeader) &&
+				!errors.Is(err, ErrInvalidSignatureFormat) &&
+				!errors.Is(err, ErrNoSignatureTimestamp) &&
+				!errors.Is(err, ErrInvalidSignatureTimestamp) {
+				t.Fatalf("unexpected error for header %q: %v", header, err)
+			}
+			return
+		}
+
+		if signature.Timestamp <= 0 {
+			t.Fatalf("got timestamp %d for header %q, want a positive timestamp", signature.Timestamp, header)
+		}
+
+		reparsed, err := ParseWebhookSignature(signature.String())
+		if err != nil {
+			t.Fatalf("could not parse %q, formatted from header %q: %v", signature.String(), header, err)
+		}
+		if *reparsed != *signature {
+			t.Fatalf("got %+v after formatting and parsing again, want %+v", reparsed, signature)
+		}
+	})
+}
+
+func FuzzWebhookVerifierVerify(f *testing.F) {
+	addWebhookSignatureSeeds(f)
+
+	verifier := NewWebhookVerifier(testWebhookSecretKey).
+		WithClock(func() time.Time { return time.Unix(testWebhookTimestamp, 0) })
+
+	f.Fuzz(func(t *testing.T, header string) {
+		err := verifier.Verify(testWebhookPayload, header)
+		if err == nil {
+			signature, parseErr := ParseWebhookSignature(header)
+			if parseErr != nil {
+				t.Fatalf("verified header %q that can't be parsed: %v", header, parseErr)
+			}
+
+			expected := ComputeWebhookSignature(testWebhookPayload, signature.Timestamp, testWebhookSecretKey)
+			// Hex signatures are accepted in any case
+			if !strings.EqualFold(signature.LiveModeSignature, expected) && !strings.EqualFold(signature.TestModeSignature, expected) {
+				t.Fatalf("verified header %q without a valid signature", header)
+			}
+		}
+
+		// Tampering with the payload must always fail verification
+		tampered := append([]byte("x"), testWebhookPayload...)
+		if err := verifier.Verify(tampered, header); err == nil {
+			t.Fatalf("verified tampered payload with header %q", header)
+		}
+	})
+}
+
+func FuzzVerifyComputedSignature(f *testing.F) {
+	f.Add(testWebhookPayload, int64(testWebhookTimestamp), testWebhookSecretKey, false)
+	f.Add([]byte{}, int64(1), "", true)
+	f.Add([]byte("\x00\xff,t=1"), int64(1<<40), "key,with=separators", false)
+
+	f.Fuzz(func(t *testing.T, payload []byte, timestamp int64, secretKey string, livemode bool) {
+		if timestamp <= 0 {
+			return
+		}
+
+		signature := &WebhookSignature{Timestamp: timestamp}
+		if livemode {
+			signature.LiveModeSignature = ComputeWebhookSignature(payload, timestamp, secretKey)
+		} else {
+			signature.TestModeSignature = ComputeWebhookSignature(payload, timestamp, secretKey)
+		}
+
+		verifier := NewWebhookVerifier(secretKey).WithTolerance(0)
+		if err := verifier.Verify(payload, signature.String()); err != nil {
+			t.Fatalf("could not verify payload signed with the secret key: %v", err)
+		}
+
+		other := NewWebhookVerifier(secretKey + "x").WithTolerance(0)
+		if err := other.Verify(payload, signature.String()); !errors.Is(err, ErrInvalidSignature) {
+			t.Fatalf("got error %v verifying with another secret key, want %v", err, ErrInvalidSignature)
+		}
+	})
+}
+
+func TestWebhookVerifierMatchedSecret(t *testing.T) {
+	signature := ComputeWebhookSignature(testWebhookPayload, testWebhookTimestamp, testWebhookSecretKey)
+	header := "t=" + strconv.Itoa(testWebhookTimestamp) + ",te=" + signature + ",li="
+
+	verifier := NewWebhookVerifierWithSecrets(WebhookSecret{Key: "whsk_new", Name: "current"}).
+		WithClock(func() time.Time { return time.Unix(testWebhookTimestamp, 0) })
+	rotated := verifier.WithSecret(WebhookSecret{Key: testWebhookSecretKey, Name: "previous"})
+
+	if _, err := verifier.ParseEventFromBytes(testWebhookPayload, header); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("got error %v from the original verifier, want %v", err, ErrInvalidSignature)
+	}
+
+	event, err := rotated.ParseEventFromBytes(testWebhookPayload, header)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := WebhookSecretMatch{Name: "previous", Index: 1}
+	if event.MatchedSecret == nil || *event.MatchedSecret != want {
+		t.Errorf("got matched secret %+v, want %+v", event.MatchedSecret, want)
+	}
+	if formatted := fmt.Sprintf("%+v", event); strings.Contains(formatted, testWebhookSecretKey) {
+		t.Errorf("formatted event %s contains the secret key", formatted)
+	}
+}
+
+func TestWebhookVerifierWithReturnsCopy(t *testing.T) {
+	at := func(timestamp int64) func() time.Time {
+		return func() time.Time { return time.Unix(timestamp, 0) }
+	}
+
+	signature := ComputeWebhookSignature(testWebhookPayload, testWebhookTimestamp, testWebhookSecretKey)
+	header := "t=" + strconv.Itoa(testWebhookTimestamp) + ",te=" + signature + ",li="
+
+	base := NewWebhookVerifier(testWebhookSecretKey).WithClock(at(testWebhookTimestamp))
+
+	// Derived verifiers must not change the settings of the verifier they branch from
+	base.WithClock(at(testWebhookTimestamp + 3600))
+	base.WithTolerance(time.Second).WithClock(at(testWebhookTimestamp + 60))
+	base.WithSignatureMode(ModeLive)
+	base.WithEventMode(ModeLive)
+	base.WithSecret(WebhookSecret{Key: "whsk_test_other"})
+
+	if _, err := base.ParseEventFromBytes(testWebhookPayload, header); err != nil {
+		t.Errorf("got error %v from the original verifier, want it unchanged", err)
+	}
+
+	multiTenant := NewMultiTenantWebhookVerifier(WebhookTenantFromHeader("X-Tenant"), WebhookSecretMap{
+		"acme": {{Key: testWebhookSecretKey}},
+	}).WithClock(at(testWebhookTimestamp))
+
+	multiTenant.WithClock(at(testWebhookTimestamp + 3600))
+	multiTenant.WithTolerance(time.Second).WithClock(at(testWebhookTimestamp + 60))
+	multiTenant.WithSignatureMode(ModeLive)
+	multiTenant.WithEventMode(ModeLive)
+
+	if _, err := multiTenant.ParseEventFromBytes(context.Background(), "acme", testWebhookPayload, header); err != nil {
+		t.Errorf("got error %v from the original multi-tenant verifier, want it unchanged", err)
+	}
+}